@@ -0,0 +1,288 @@
+// logq queries the log files written by logx, including the rotated and compressed backups.
+//
+// Usage:
+//
+//	logq [flags] file-or-dir...
+//
+// A directory is expanded to the access, error, severe, slow and stat logs in it.
+// Examples:
+//
+//	logq -level error,severe -since 1h logs
+//	logq -trace 7a3f9c -where 'duration>500ms' logs/access.log
+//	logq -f -where 'user=alice' logs/access.log
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx/reader"
+)
+
+const defaultTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// 与 logx 写入的日志文件保持一致
+var logFilenames = []string{"access.log", "error.log", "severe.log", "slow.log", "stat.log"}
+
+type exprsFlag []reader.Expr
+
+func (f *exprsFlag) Set(s string) error {
+	expr, err := reader.ParseExpr(s)
+	if err != nil {
+		return err
+	}
+
+	*f = append(*f, expr)
+	return nil
+}
+
+func (f *exprsFlag) String() string {
+	items := make([]string, 0, len(*f))
+	for _, expr := range *f {
+		items = append(items, expr.String())
+	}
+
+	return strings.Join(items, ",")
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "logq: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	var (
+		levels     string
+		since      string
+		until      string
+		exprs      exprsFlag
+		keys       reader.FieldKeys
+		filter     reader.Filter
+		follow     bool
+		interval   time.Duration
+		delimiter  string
+		timeFormat string
+	)
+
+	fs := flag.NewFlagSet("logq", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&levels, "level", "", "comma separated levels to match, like error,severe")
+	fs.StringVar(&since, "since", "", "match the entries since the time, or the duration ago like 30m")
+	fs.StringVar(&until, "until", "", "match the entries before the time, or the duration ago like 30m")
+	fs.StringVar(&filter.TraceId, "trace", "", "match the entries with the trace id")
+	fs.Var(&exprs, "where", "field expression to match, like status>=500, can be repeated")
+	fs.BoolVar(&follow, "f", false, "follow the log files across rotations like tail -F")
+	fs.DurationVar(&interval, "interval", time.Second, "interval to check the log files when following")
+	fs.StringVar(&delimiter, "delimiter", "-", "delimiter in the backup file names")
+	fs.StringVar(&timeFormat, "timeformat", defaultTimeFormat, "time format of the logs")
+	fs.StringVar(&keys.Content, "contentkey", "", "key of the content field")
+	fs.StringVar(&keys.Level, "levelkey", "", "key of the level field")
+	fs.StringVar(&keys.Timestamp, "timestampkey", "", "key of the timestamp field")
+	fs.StringVar(&keys.Trace, "tracekey", "", "key of the trace field")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: logq [flags] file-or-dir...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	now := time.Now()
+	var err error
+	if filter.Since, err = parseTime(since, timeFormat, now); err != nil {
+		return err
+	}
+	if filter.Until, err = parseTime(until, timeFormat, now); err != nil {
+		return err
+	}
+	if len(levels) > 0 {
+		filter.Levels = strings.Split(levels, ",")
+	}
+	filter.Exprs = exprs
+
+	files, err := expandFiles(fs.Args())
+	if err != nil {
+		return err
+	}
+
+	opts := []reader.Option{
+		reader.WithDelimiter(delimiter),
+		reader.WithFieldKeys(keys),
+		reader.WithFilter(filter),
+		reader.WithTimeFormat(timeFormat),
+	}
+	if follow {
+		opts = append(opts, reader.WithFollow(interval))
+	}
+
+	sources := make([]reader.Source, 0, len(files))
+	for _, file := range files {
+		r, err := reader.Open(file, opts...)
+		if err != nil {
+			for _, source := range sources {
+				source.Close()
+			}
+			return err
+		}
+		sources = append(sources, r)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	out := bufio.NewWriter(stdout)
+	defer out.Flush()
+	p := printer{
+		writer:     out,
+		timeFormat: timeFormat,
+		flush:      follow,
+	}
+
+	if follow {
+		return followAll(ctx, sources, &p)
+	}
+
+	return drain(ctx, reader.Merge(sources...), &p)
+}
+
+// drain 输出 source 中的所有日志，直到结束或者被中断
+func drain(ctx context.Context, source reader.Source, p *printer) error {
+	defer source.Close()
+
+	for {
+		entry, err := source.Next(ctx)
+		if errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if err = p.print(entry); err != nil {
+			return err
+		}
+	}
+}
+
+// expandFiles 目录展开为其中存在的日志文件，包括只有备份文件的日志
+func expandFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		for _, name := range logFilenames {
+			file := filepath.Join(arg, name)
+			ext := filepath.Ext(name)
+			backups, err := filepath.Glob(filepath.Join(arg, strings.TrimSuffix(name, ext)+"*"))
+			if err != nil {
+				return nil, err
+			}
+			if len(backups) > 0 {
+				files = append(files, file)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, errors.New("no log files found")
+	}
+
+	return files, nil
+}
+
+// followAll 跟随多个日志文件时按到达的顺序输出，无法按时间排序
+func followAll(ctx context.Context, sources []reader.Source, p *printer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(sources))
+	for _, source := range sources {
+		wg.Add(1)
+		go func(source reader.Source) {
+			defer wg.Done()
+			if err := drain(ctx, source, p); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(source)
+	}
+	wg.Wait()
+	close(errs)
+
+	return <-errs
+}
+
+// parseTime 支持日志的时间格式、RFC3339、日期和相对于 now 的时长
+func parseTime(s, layout string, now time.Time) (time.Time, error) {
+	if len(s) == 0 {
+		return time.Time{}, nil
+	}
+
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(-d), nil
+	}
+	for _, l := range []string{layout, time.RFC3339Nano} {
+		if t, err := time.Parse(l, s); err == nil {
+			return t, nil
+		}
+	}
+	// 不带时区的时间按本地时间解析
+	for _, l := range []string{time.DateTime, time.DateOnly} {
+		if t, err := time.ParseInLocation(l, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time: %q", s)
+}
+
+// printer 并发安全地以 plain 编码输出日志
+type printer struct {
+	lock       sync.Mutex
+	writer     *bufio.Writer
+	timeFormat string
+	flush      bool
+	buf        []byte
+}
+
+func (p *printer) print(entry reader.Entry) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.buf = entry.AppendPlain(p.buf[:0], p.timeFormat)
+	p.buf = append(p.buf, '\n')
+	if _, err := p.writer.Write(p.buf); err != nil {
+		return err
+	}
+	if p.flush {
+		return p.writer.Flush()
+	}
+
+	return nil
+}
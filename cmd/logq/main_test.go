@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeLogs(t *testing.T, dir string) {
+	files := map[string]string{
+		"access.log-2024-01-01": `{"@timestamp":"2024-01-01T10:00:00.000Z","level":"info","content":"login","trace":"t1","user":"alice"}` + "\n",
+		"access.log": `{"@timestamp":"2024-01-02T10:00:00.000Z","level":"slow","content":"query","trace":"t2","duration":"1.5s"}` + "\n" +
+			`{"@timestamp":"2024-01-02T12:00:00.000Z","level":"info","content":"logout","trace":"t1","user":"alice"}` + "\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fp, err := os.Create(filepath.Join(dir, "error.log-2024-01-01.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+	w := gzip.NewWriter(fp)
+	w.Write([]byte("2024-01-01T11:00:00.000Z\terror\tdb down\ttrace=t1\n"))
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeLogs(t, dir)
+
+	tests := []struct {
+		name   string
+		args   []string
+		expect string
+	}{
+		{
+			name: "trace",
+			args: []string{"-trace", "t1", dir},
+			expect: "2024-01-01T10:00:00.000Z\tinfo\tlogin\ttrace=t1\tuser=alice\n" +
+				"2024-01-01T11:00:00.000Z\terror\tdb down\ttrace=t1\n" +
+				"2024-01-02T12:00:00.000Z\tinfo\tlogout\ttrace=t1\tuser=alice\n",
+		},
+		{
+			name:   "level",
+			args:   []string{"-level", "error,slow", dir},
+			expect: "2024-01-01T11:00:00.000Z\terror\tdb down\ttrace=t1\n" + "2024-01-02T10:00:00.000Z\tslow\tquery\ttrace=t2\tduration=1.5s\n",
+		},
+		{
+			name:   "where",
+			args:   []string{"-where", "duration>1s", filepath.Join(dir, "access.log")},
+			expect: "2024-01-02T10:00:00.000Z\tslow\tquery\ttrace=t2\tduration=1.5s\n",
+		},
+		{
+			name:   "time range",
+			args:   []string{"-since", "2024-01-01T10:30:00Z", "-until", "2024-01-02T11:00:00Z", "-where", "user", dir},
+			expect: "",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if err := run(test.args, &stdout, &stderr); err != nil {
+				t.Fatalf("执行失败: %v, %s", err, stderr.String())
+			}
+			if stdout.String() != test.expect {
+				t.Errorf("输出错误:\n%s\n期望:\n%s", stdout.String(), test.expect)
+			}
+		})
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if err := run(nil, &stdout, &stderr); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("没有参数时应输出用法: %v", err)
+	}
+	if err := run([]string{"-where", "=1", "."}, &stdout, &stderr); err == nil {
+		t.Error("表达式错误时应返回错误")
+	}
+	if err := run([]string{"-since", "yesterday", "."}, &stdout, &stderr); err == nil {
+		t.Error("时间错误时应返回错误")
+	}
+	if err := run([]string{t.TempDir()}, &stdout, &stderr); err == nil {
+		t.Error("目录中没有日志时应返回错误")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		input  string
+		expect time.Time
+	}{
+		{"", time.Time{}},
+		{"30m", now.Add(-30 * time.Minute)},
+		{"2024-01-02T03:04:05.000Z", now},
+		{"2024-01-02T03:04:05+08:00", time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("", 8*3600))},
+		{"2024-01-02 03:04:05", time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.Local)},
+	}
+	for _, test := range tests {
+		actual, err := parseTime(test.input, defaultTimeFormat, now)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", test.input, err)
+			continue
+		}
+		if !actual.Equal(test.expect) {
+			t.Errorf("解析 %q 错误: %v", test.input, actual)
+		}
+	}
+}
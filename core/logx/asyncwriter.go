@@ -0,0 +1,379 @@
+package logx
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/lang"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+const (
+	defaultAsyncBufferSize    = 8192
+	defaultAsyncBatchSize     = 256
+	defaultAsyncFlushInterval = time.Second
+
+	// Stack 写入没有对应的级别常量，仅用于区分异步条目
+	asyncStack = "stack"
+)
+
+const (
+	// OverflowBlock blocks the caller until there is room in the buffer.
+	// While the background goroutine is writing, the caller writes the entry itself
+	// instead, so that a Writer logging through logx while writing doesn't deadlock,
+	// such entries may be written before the buffered ones.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest drops the entry being written.
+	OverflowDropNewest
+	// OverflowDropOldest drops the oldest buffered entry to make room.
+	OverflowDropOldest
+	// OverflowDropBelowLevel drops entries below the drop level, and blocks for the others.
+	OverflowDropBelowLevel
+)
+
+type (
+	// OverflowPolicy defines what AsyncWriter does when its buffer is full.
+	OverflowPolicy int
+
+	// AsyncOption customizes an AsyncWriter.
+	AsyncOption func(options *asyncOptions)
+
+	// AsyncWriter is a Writer that buffers entries in a bounded ring buffer,
+	// and writes them to the underlying Writer in a background goroutine.
+	AsyncWriter struct {
+		writer  Writer
+		options asyncOptions
+		// 环形缓冲区，head 为最早的条目，count 为条目数
+		entries []asyncEntry
+		head    int
+		count   int
+		closed  bool
+		lock    sync.Mutex
+		notFull *sync.Cond
+		// 缓冲区达到 batchSize 时通知后台协程写出
+		ready    chan lang.PlaceholderType
+		done     chan lang.PlaceholderType
+		stopped  chan lang.PlaceholderType
+		once     sync.Once
+		closeErr error
+		dropped  uint64
+		// 后台协程正在写出，此时写出中经 logx 重入的调用等待缓冲区会死锁
+		writing bool
+	}
+
+	asyncOptions struct {
+		bufferSize    int
+		batchSize     int
+		flushInterval time.Duration
+		policy        OverflowPolicy
+		dropLevel     uint32
+	}
+
+	asyncEntry struct {
+		level  string
+		val    any
+		fields []LogField
+		// 入队时已编码的条目，写出时直接调用
+		write func()
+	}
+
+	// entryEncoder 由可以提前编码日志的 Writer 实现，AsyncWriter 在入队时编码，
+	// 日志时间为调用时间，调用方之后修改 val 也不会影响写出的内容
+	entryEncoder interface {
+		encodeEntry(level string, val any, fields []LogField) func()
+	}
+)
+
+// NewAsyncWriter returns an AsyncWriter that writes to w in a background goroutine.
+// Close flushes all buffered entries before closing w.
+// The entries for the file and console writers are encoded when written, so their timestamps
+// are the time of the calls. The other writers encode the entries in the background goroutine.
+func NewAsyncWriter(w Writer, opts ...AsyncOption) *AsyncWriter {
+	options := asyncOptions{
+		bufferSize:    defaultAsyncBufferSize,
+		batchSize:     defaultAsyncBatchSize,
+		flushInterval: defaultAsyncFlushInterval,
+		policy:        OverflowBlock,
+		dropLevel:     ErrorLevel,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.batchSize > options.bufferSize {
+		options.batchSize = options.bufferSize
+	}
+
+	aw := &AsyncWriter{
+		writer:  w,
+		options: options,
+		entries: make([]asyncEntry, options.bufferSize),
+		ready:   make(chan lang.PlaceholderType, 1),
+		done:    make(chan lang.PlaceholderType),
+		stopped: make(chan lang.PlaceholderType),
+	}
+	aw.notFull = sync.NewCond(&aw.lock)
+	go aw.run(clock.NewTicker(options.flushInterval))
+
+	return aw
+}
+
+// WithAsyncBatchSize customizes the number of buffered entries that triggers a flush.
+func WithAsyncBatchSize(size int) AsyncOption {
+	return func(opts *asyncOptions) {
+		if size > 0 {
+			opts.batchSize = size
+		}
+	}
+}
+
+// WithAsyncBufferSize customizes the capacity of the ring buffer.
+func WithAsyncBufferSize(size int) AsyncOption {
+	return func(opts *asyncOptions) {
+		if size > 0 {
+			opts.bufferSize = size
+		}
+	}
+}
+
+// WithAsyncDropLevel customizes the level below which entries are dropped
+// when OverflowDropBelowLevel is used.
+func WithAsyncDropLevel(level uint32) AsyncOption {
+	return func(opts *asyncOptions) {
+		opts.dropLevel = level
+	}
+}
+
+// WithAsyncFlushInterval customizes the interval of periodic flushes.
+func WithAsyncFlushInterval(interval time.Duration) AsyncOption {
+	return func(opts *asyncOptions) {
+		if interval > 0 {
+			opts.flushInterval = interval
+		}
+	}
+}
+
+// WithAsyncOverflowPolicy customizes the policy when the buffer is full.
+func WithAsyncOverflowPolicy(policy OverflowPolicy) AsyncOption {
+	return func(opts *asyncOptions) {
+		opts.policy = policy
+	}
+}
+
+func (w *AsyncWriter) Alert(v any) {
+	w.enqueue(levelAlert, v, nil)
+}
+
+// Close flushes all buffered entries, then closes the underlying writer.
+// Entries written after Close are dropped.
+func (w *AsyncWriter) Close() error {
+	w.once.Do(func() {
+		w.lock.Lock()
+		w.closed = true
+		// 唤醒阻塞的调用方，让其放弃写入
+		w.notFull.Broadcast()
+		w.lock.Unlock()
+		close(w.done)
+		<-w.stopped
+
+		w.closeErr = w.writer.Close()
+	})
+
+	return w.closeErr
+}
+
+func (w *AsyncWriter) Debug(v any, fields ...LogField) {
+	w.enqueue(levelDebug, v, fields)
+}
+
+// Dropped returns the number of entries dropped by the overflow policy or after Close.
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+func (w *AsyncWriter) Error(v any, fields ...LogField) {
+	w.enqueue(levelError, v, fields)
+}
+
+func (w *AsyncWriter) Fatal(v any, fields ...LogField) {
+	w.enqueue(levelFatal, v, fields)
+}
+
+func (w *AsyncWriter) Info(v any, fields ...LogField) {
+	w.enqueue(levelInfo, v, fields)
+}
+
+func (w *AsyncWriter) Severe(v any) {
+	w.enqueue(levelSevere, v, nil)
+}
+
+func (w *AsyncWriter) Slow(v any, fields ...LogField) {
+	w.enqueue(levelSlow, v, fields)
+}
+
+func (w *AsyncWriter) Stack(v any) {
+	w.enqueue(asyncStack, v, nil)
+}
+
+func (w *AsyncWriter) Stat(v any, fields ...LogField) {
+	w.enqueue(levelStat, v, fields)
+}
+
+func (w *AsyncWriter) enqueue(level string, val any, fields []LogField) {
+	var entry asyncEntry
+	if encoder, ok := w.writer.(entryEncoder); ok {
+		entry.write = encoder.encodeEntry(level, val, fields)
+	} else {
+		entry.level = level
+		entry.val = val
+		// 调用方可能复用 fields 切片，需要复制
+		if len(fields) > 0 {
+			entry.fields = append([]LogField(nil), fields...)
+		}
+	}
+
+	w.lock.Lock()
+	for !w.closed && w.count == len(w.entries) {
+		switch w.options.policy {
+		case OverflowDropNewest:
+			w.lock.Unlock()
+			atomic.AddUint64(&w.dropped, 1)
+			return
+		case OverflowDropOldest:
+			w.entries[w.head] = asyncEntry{}
+			w.head = (w.head + 1) % len(w.entries)
+			w.count--
+			atomic.AddUint64(&w.dropped, 1)
+		case OverflowDropBelowLevel:
+			if asyncLevel(level) < w.options.dropLevel {
+				w.lock.Unlock()
+				atomic.AddUint64(&w.dropped, 1)
+				return
+			}
+			if w.writeIfWriting(entry) {
+				return
+			}
+		default:
+			if w.writeIfWriting(entry) {
+				return
+			}
+		}
+	}
+	if w.closed {
+		w.lock.Unlock()
+		atomic.AddUint64(&w.dropped, 1)
+		return
+	}
+
+	w.entries[(w.head+w.count)%len(w.entries)] = entry
+	w.count++
+	full := w.count >= w.options.batchSize
+	w.lock.Unlock()
+
+	if full {
+		select {
+		case w.ready <- lang.Placeholder:
+		default:
+		}
+	}
+}
+
+// flush 取出缓冲区中的全部条目并写入底层写入器
+func (w *AsyncWriter) flush(batch []asyncEntry) []asyncEntry {
+	w.lock.Lock()
+	for w.count > 0 {
+		batch = append(batch, w.entries[w.head])
+		w.entries[w.head] = asyncEntry{}
+		w.head = (w.head + 1) % len(w.entries)
+		w.count--
+	}
+	w.writing = true
+	w.notFull.Broadcast()
+	w.lock.Unlock()
+
+	for _, entry := range batch {
+		w.write(entry)
+	}
+
+	w.lock.Lock()
+	w.writing = false
+	w.lock.Unlock()
+
+	// 清空后复用 batch，避免继续引用已写出的条目
+	clear(batch)
+	return batch[:0]
+}
+
+func (w *AsyncWriter) run(ticker timex.Ticker) {
+	defer close(w.stopped)
+	defer ticker.Stop()
+
+	var batch []asyncEntry
+	for {
+		select {
+		case <-w.ready:
+			batch = w.flush(batch)
+		case <-ticker.Chan():
+			batch = w.flush(batch)
+		case <-w.done:
+			w.flush(batch)
+			return
+		}
+	}
+}
+
+func (w *AsyncWriter) write(entry asyncEntry) {
+	if entry.write != nil {
+		entry.write()
+		return
+	}
+
+	switch entry.level {
+	case levelAlert:
+		w.writer.Alert(entry.val)
+	case levelDebug:
+		w.writer.Debug(entry.val, entry.fields...)
+	case levelError:
+		w.writer.Error(entry.val, entry.fields...)
+	case levelFatal:
+		writeFatal(w.writer, entry.val, entry.fields)
+	case levelInfo:
+		w.writer.Info(entry.val, entry.fields...)
+	case levelSevere:
+		w.writer.Severe(entry.val)
+	case levelSlow:
+		w.writer.Slow(entry.val, entry.fields...)
+	case asyncStack:
+		w.writer.Stack(entry.val)
+	case levelStat:
+		w.writer.Stat(entry.val, entry.fields...)
+	}
+}
+
+// writeIfWriting 在持有锁且缓冲区已满时调用，后台协程正在写出时由调用方直接写出并释放锁，
+// 否则等待缓冲区有空位，返回是否已写出
+// 写出中经 logx 重入的调用（如 lessWriter 输出丢弃数）等待后台协程会死锁，直接写出可以避免
+func (w *AsyncWriter) writeIfWriting(entry asyncEntry) bool {
+	if !w.writing {
+		w.notFull.Wait()
+		return false
+	}
+
+	w.lock.Unlock()
+	w.write(entry)
+	return true
+}
+
+// asyncLevel 返回条目对应的日志级别，用于 OverflowDropBelowLevel
+func asyncLevel(level string) uint32 {
+	switch level {
+	case levelDebug:
+		return DebugLevel
+	case levelInfo, levelStat:
+		return InfoLevel
+	case levelSevere, levelFatal:
+		return SevereLevel
+	default:
+		return ErrorLevel
+	}
+}
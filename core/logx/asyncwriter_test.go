@@ -0,0 +1,322 @@
+package logx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// gateWriter 第一次写入时阻塞，直到 release 被关闭，用于制造缓冲区写满的场景
+type gateWriter struct {
+	*mockWriter
+	entered chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func newGateWriter() *gateWriter {
+	return &gateWriter{
+		mockWriter: new(mockWriter),
+		entered:    make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+}
+
+func (w *gateWriter) Error(v any, fields ...LogField) {
+	w.wait()
+	w.mockWriter.Error(v, fields...)
+}
+
+func (w *gateWriter) Info(v any, fields ...LogField) {
+	w.wait()
+	w.mockWriter.Info(v, fields...)
+}
+
+func (w *gateWriter) wait() {
+	w.once.Do(func() {
+		close(w.entered)
+		<-w.release
+	})
+}
+
+// fillAsyncWriter 让后台协程阻塞在 e0 上，并写满容量为2的缓冲区
+func fillAsyncWriter(t *testing.T, policy OverflowPolicy) (*AsyncWriter, *gateWriter) {
+	gw := newGateWriter()
+	aw := NewAsyncWriter(gw, WithAsyncBufferSize(2), WithAsyncBatchSize(1),
+		WithAsyncFlushInterval(time.Hour), WithAsyncOverflowPolicy(policy))
+
+	aw.Info("e0")
+	select {
+	case <-gw.entered:
+	case <-time.After(time.Second):
+		t.Fatal("后台协程没有开始写入")
+	}
+	aw.Info("e1")
+	aw.Info("e2")
+
+	return aw, gw
+}
+
+func contents(w *mockWriter) []string {
+	var result []string
+	for _, line := range strings.Split(strings.TrimSpace(w.String()), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		start := strings.Index(line, `"content":"`) + len(`"content":"`)
+		end := strings.Index(line[start:], `"`)
+		result = append(result, line[start:start+end])
+	}
+	return result
+}
+
+func TestAsyncWriterCloseFlushes(t *testing.T) {
+	w := new(mockWriter)
+	aw := NewAsyncWriter(w, WithAsyncFlushInterval(time.Hour))
+
+	for i := 0; i < 100; i++ {
+		aw.Info(fmt.Sprintf("e%d", i))
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+
+	got := contents(w)
+	if len(got) != 100 {
+		t.Fatalf("期望 Close 后写出 100 条，实际为 %d", len(got))
+	}
+	for i, c := range got {
+		if c != fmt.Sprintf("e%d", i) {
+			t.Fatalf("第 %d 条顺序错误: %s", i, c)
+		}
+	}
+
+	aw.Info("after close")
+	if aw.Dropped() != 1 {
+		t.Errorf("Close 后的写入应该计入丢弃数，实际为 %d", aw.Dropped())
+	}
+	if err := aw.Close(); err != nil {
+		t.Errorf("重复 Close 失败: %v", err)
+	}
+}
+
+func TestAsyncWriterBatchFlush(t *testing.T) {
+	w := new(mockWriter)
+	aw := NewAsyncWriter(w, WithAsyncBatchSize(2), WithAsyncFlushInterval(time.Hour))
+	defer aw.Close()
+
+	aw.Info("e0")
+	aw.Error("e1", Field("foo", "bar"))
+
+	deadline := time.Now().Add(time.Second)
+	for len(contents(w)) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("达到 batchSize 后应该立即写出: %s", w.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if !w.Contains(`"foo":"bar"`) || !w.Contains(`"level":"error"`) {
+		t.Errorf("字段或级别丢失: %s", w.String())
+	}
+}
+
+func TestAsyncWriterFlushInterval(t *testing.T) {
+	w := new(mockWriter)
+	aw := NewAsyncWriter(w, WithAsyncFlushInterval(10*time.Millisecond))
+	defer aw.Close()
+
+	aw.Info("e0")
+	deadline := time.Now().Add(time.Second)
+	for !w.Contains("e0") {
+		if time.Now().After(deadline) {
+			t.Fatal("到达 flushInterval 后应该写出")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAsyncWriterCopiesFields(t *testing.T) {
+	w := new(mockWriter)
+	aw := NewAsyncWriter(w, WithAsyncFlushInterval(time.Hour))
+
+	fields := []LogField{Field("foo", "bar")}
+	aw.Info("e0", fields...)
+	fields[0] = Field("foo", "changed")
+	aw.Close()
+
+	if !w.Contains(`"foo":"bar"`) {
+		t.Errorf("调用方修改 fields 不应该影响已写入的条目: %s", w.String())
+	}
+}
+
+func TestAsyncWriterOverflow(t *testing.T) {
+	t.Run("drop newest", func(t *testing.T) {
+		aw, gw := fillAsyncWriter(t, OverflowDropNewest)
+		aw.Info("e3")
+		close(gw.release)
+		aw.Close()
+
+		if got := strings.Join(contents(gw.mockWriter), ","); got != "e0,e1,e2" {
+			t.Errorf("期望丢弃最新的条目，实际输出 %s", got)
+		}
+		if aw.Dropped() != 1 {
+			t.Errorf("期望丢弃 1 条，实际为 %d", aw.Dropped())
+		}
+	})
+
+	t.Run("drop oldest", func(t *testing.T) {
+		aw, gw := fillAsyncWriter(t, OverflowDropOldest)
+		aw.Info("e3")
+		close(gw.release)
+		aw.Close()
+
+		if got := strings.Join(contents(gw.mockWriter), ","); got != "e0,e2,e3" {
+			t.Errorf("期望丢弃最早的条目，实际输出 %s", got)
+		}
+		if aw.Dropped() != 1 {
+			t.Errorf("期望丢弃 1 条，实际为 %d", aw.Dropped())
+		}
+	})
+
+	t.Run("drop below level", func(t *testing.T) {
+		aw, gw := fillAsyncWriter(t, OverflowDropBelowLevel)
+		aw.Info("e3")
+
+		var written int32
+		go func() {
+			aw.Error("e4")
+			atomic.StoreInt32(&written, 1)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		if atomic.LoadInt32(&written) != 0 {
+			t.Error("达到丢弃级别的条目应该阻塞等待")
+		}
+		close(gw.release)
+		for atomic.LoadInt32(&written) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		aw.Close()
+
+		if got := strings.Join(contents(gw.mockWriter), ","); got != "e0,e1,e2,e4" {
+			t.Errorf("期望只丢弃低于丢弃级别的条目，实际输出 %s", got)
+		}
+		if aw.Dropped() != 1 {
+			t.Errorf("期望丢弃 1 条，实际为 %d", aw.Dropped())
+		}
+	})
+
+	t.Run("block", func(t *testing.T) {
+		aw, gw := fillAsyncWriter(t, OverflowBlock)
+
+		var written int32
+		go func() {
+			aw.Info("e3")
+			atomic.StoreInt32(&written, 1)
+		}()
+		time.Sleep(20 * time.Millisecond)
+		if atomic.LoadInt32(&written) != 0 {
+			t.Error("缓冲区满时应该阻塞调用方")
+		}
+		close(gw.release)
+		for atomic.LoadInt32(&written) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		aw.Close()
+
+		// 后台协程写出时由调用方直接写出，e3 可能先于缓冲区中的条目
+		got := contents(gw.mockWriter)
+		sort.Strings(got)
+		if strings.Join(got, ",") != "e0,e1,e2,e3" {
+			t.Errorf("阻塞策略不应该丢弃条目，实际输出 %s", got)
+		}
+		if aw.Dropped() != 0 {
+			t.Errorf("阻塞策略不应该丢弃条目，实际为 %d", aw.Dropped())
+		}
+	})
+}
+
+func TestAsyncWriterLogxClose(t *testing.T) {
+	originalLevel := atomic.LoadUint32(&logLevel)
+	atomic.StoreUint32(&logLevel, InfoLevel)
+	old := writer.Swap(nil)
+	defer func() {
+		writer.Store(old)
+		atomic.StoreUint32(&logLevel, originalLevel)
+	}()
+
+	w := new(mockWriter)
+	SetWriter(NewAsyncWriter(w, WithAsyncFlushInterval(time.Hour)))
+	for i := 0; i < 10; i++ {
+		Infof("e%d", i)
+	}
+	if err := Close(); err != nil {
+		t.Fatalf("Close 失败: %v", err)
+	}
+
+	if n := len(contents(w)); n != 10 {
+		t.Errorf("logx.Close 应该等待异步写入完成，实际写出 %d 条", n)
+	}
+}
+
+func TestAsyncWriterEncodesOnEnqueue(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fake := useFakeClock(t, start)
+	var buf strings.Builder
+	aw := NewAsyncWriter(NewWriter(&buf), WithAsyncFlushInterval(time.Hour))
+
+	val := map[string]string{"foo": "bar"}
+	aw.Info(val)
+	val["foo"] = "changed"
+	fake.Advance(time.Minute)
+	aw.Close()
+
+	if !strings.Contains(buf.String(), `"foo":"bar"`) {
+		t.Errorf("调用方修改 val 不应该影响已写入的条目: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), start.Format(timeFormat)) {
+		t.Errorf("日志时间应该是调用时间: %s", buf.String())
+	}
+}
+
+// reentrantWriter 写出时再次通过 AsyncWriter 输出日志，模拟 lessWriter 输出丢弃数
+type reentrantWriter struct {
+	*mockWriter
+	aw   *AsyncWriter
+	done chan struct{}
+}
+
+func (w *reentrantWriter) Info(v any, fields ...LogField) {
+	w.mockWriter.Info(v, fields...)
+	w.aw.Error("r1")
+	w.aw.Error("r2")
+	close(w.done)
+}
+
+func TestAsyncWriterReentrant(t *testing.T) {
+	w := &reentrantWriter{
+		mockWriter: new(mockWriter),
+		done:       make(chan struct{}),
+	}
+	w.aw = NewAsyncWriter(w, WithAsyncBufferSize(1), WithAsyncBatchSize(1),
+		WithAsyncFlushInterval(time.Hour))
+
+	w.aw.Info("e0")
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Fatal("后台协程重入时阻塞")
+	}
+	w.aw.Close()
+
+	// 缓冲区满时重入的条目直接写出，不会阻塞也不会丢弃
+	if got := strings.Join(contents(w.mockWriter), ","); got != "e0,r2,r1" {
+		t.Errorf("缓冲区满时重入的条目应该直接写出，实际输出 %s", got)
+	}
+	if w.aw.Dropped() != 0 {
+		t.Errorf("不应丢弃，实际丢弃 %d 条", w.aw.Dropped())
+	}
+}
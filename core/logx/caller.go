@@ -0,0 +1,280 @@
+package logx
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// 调用位置的路径格式
+	callerFormatFull   = "full"   // 完整的文件路径
+	callerFormatModule = "module" // 相对主模块的路径，其他模块使用导入路径
+	callerFormatShort  = "short"  // 只保留最后两级路径
+
+	defaultStackDepth = 32
+	// 超出栈深度时的提示
+	stackEllipsis = "...\n"
+)
+
+// 可以记录调用位置的级别，Alert、Severe 和 Stack 不支持字段，不记录调用位置
+const (
+	callerDebug uint8 = 1 << iota
+	callerInfo
+	callerError
+	callerSlow
+	callerStat
+
+	callerAll = callerDebug | callerInfo | callerError | callerSlow | callerStat
+)
+
+var (
+	// 当前生效的调用位置和堆栈配置，SetUp 时整体替换
+	activeCaller atomic.Pointer[callerSettings]
+
+	// logx 自身的包路径，用于从堆栈中过滤 logx 的帧
+	logxPackage = reflect.TypeOf(callerSettings{}).PkgPath()
+
+	// 主模块路径，module 格式下去掉此前缀
+	mainModule = sync.OnceValue(func() string {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			return info.Main.Path
+		}
+		return ""
+	})
+)
+
+func init() {
+	activeCaller.Store(&callerSettings{
+		levels:     callerAll,
+		format:     callerFormatShort,
+		stackDepth: defaultStackDepth,
+	})
+}
+
+// callerSettings 调用位置和堆栈的配置
+type callerSettings struct {
+	levels        uint8
+	format        string
+	function      bool
+	stackDisabled bool
+	stackDepth    int
+}
+
+func newCallerSettings(c callerConf, sc stackConf) (*callerSettings, error) {
+	s := &callerSettings{
+		format:        c.Format,
+		function:      c.Function,
+		stackDisabled: sc.Disabled,
+		stackDepth:    sc.Depth,
+	}
+
+	switch s.format {
+	case "":
+		s.format = callerFormatShort
+	case callerFormatFull, callerFormatModule, callerFormatShort:
+	default:
+		return nil, fmt.Errorf("unknown caller format %q", c.Format)
+	}
+
+	if s.stackDepth <= 0 {
+		s.stackDepth = defaultStackDepth
+	}
+
+	if c.Disabled {
+		return s, nil
+	}
+	if len(c.Levels) == 0 {
+		s.levels = callerAll
+		return s, nil
+	}
+
+	for _, level := range c.Levels {
+		bit := callerBit(strings.ToLower(strings.TrimSpace(level)))
+		if bit == 0 {
+			return nil, fmt.Errorf("unknown caller level %q", level)
+		}
+		s.levels |= bit
+	}
+
+	return s, nil
+}
+
+// setupCaller 按配置替换调用位置和堆栈的设置
+func setupCaller(c callerConf, sc stackConf) error {
+	s, err := newCallerSettings(c, sc)
+	if err != nil {
+		return err
+	}
+
+	activeCaller.Store(s)
+	return nil
+}
+
+// appendCaller 追加调用位置字段，skip 为 0 时表示调用 appendCaller 的函数
+// 级别未开启时直接返回，不做栈回溯
+func appendCaller(keys *systemKeys, fields []LogField, level string, skip int) []LogField {
+	s := activeCaller.Load()
+	if s.levels&callerBit(level) == 0 {
+		return fields
+	}
+
+	var pcs [1]uintptr
+	// 0: runtime.Callers 1: appendCaller
+	if runtime.Callers(skip+2, pcs[:]) == 0 {
+		return fields
+	}
+
+	frame, _ := runtime.CallersFrames(pcs[:]).Next()
+	return s.appendFrame(keys, fields, frame)
+}
+
+// appendFrame 追加指定帧的调用位置和函数名
+func (s *callerSettings) appendFrame(keys *systemKeys, fields []LogField, frame runtime.Frame) []LogField {
+	fields = append(fields, Field(keys.caller, s.formatFile(frame)+":"+strconv.Itoa(frame.Line)))
+	if s.function && len(frame.Function) > 0 {
+		fields = append(fields, Field(keys.function, shortFunction(frame.Function)))
+	}
+
+	return fields
+}
+
+// enabled 判断级别是否需要记录调用位置
+func (s *callerSettings) enabled(level string) bool {
+	return s.levels&callerBit(level) != 0
+}
+
+// formatFile 按配置格式化文件路径
+func (s *callerSettings) formatFile(frame runtime.Frame) string {
+	switch s.format {
+	case callerFormatFull:
+		return frame.File
+	case callerFormatModule:
+		if file, ok := modulePath(frame); ok {
+			return file
+		}
+	}
+
+	return shortPath(frame.File)
+}
+
+// captureStack 返回当前协程的堆栈，过滤掉 runtime 和 logx 自身的帧，未开启时返回空字符串
+func captureStack() string {
+	s := activeCaller.Load()
+	if s.stackDisabled {
+		return ""
+	}
+
+	// 多取一些，过滤掉的帧不占用深度
+	pcs := make([]uintptr, s.stackDepth+16)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var builder strings.Builder
+	var depth int
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame) {
+			if depth == s.stackDepth {
+				builder.WriteString(stackEllipsis)
+				break
+			}
+
+			depth++
+			builder.WriteString(frame.Function)
+			builder.WriteString("\n\t")
+			builder.WriteString(s.formatFile(frame))
+			builder.WriteByte(':')
+			builder.WriteString(strconv.Itoa(frame.Line))
+			builder.WriteByte('\n')
+		}
+		if !more {
+			break
+		}
+	}
+
+	return builder.String()
+}
+
+// withStack 将堆栈追加到 msg 后，未开启堆栈时原样返回
+func withStack(msg string) string {
+	stack := captureStack()
+	if len(stack) == 0 {
+		return msg
+	}
+
+	return msg + "\n" + stack
+}
+
+func callerBit(level string) uint8 {
+	switch level {
+	case levelDebug:
+		return callerDebug
+	case levelInfo:
+		return callerInfo
+	case levelError:
+		return callerError
+	case levelSlow:
+		return callerSlow
+	case levelStat:
+		return callerStat
+	default:
+		return 0
+	}
+}
+
+// isInternalFrame 判断是否为 runtime 或 logx 自身的帧，logx 的测试代码不过滤
+func isInternalFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "runtime.") {
+		return true
+	}
+
+	return packageOfFunc(frame.Function) == logxPackage && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// modulePath 返回包导入路径加文件名，属于主模块时去掉模块前缀
+// 如 github.com/foo/bar/handler/user.go -> handler/user.go
+func modulePath(frame runtime.Frame) (string, bool) {
+	pkg := packageOfFunc(frame.Function)
+	// main 包没有导入路径
+	if len(pkg) == 0 || pkg == "main" {
+		return "", false
+	}
+
+	file := frame.File[strings.LastIndexByte(frame.File, '/')+1:]
+	if module := mainModule(); len(module) > 0 {
+		if pkg == module {
+			return file, true
+		}
+		if rel, ok := strings.CutPrefix(pkg, module+"/"); ok {
+			return rel + "/" + file, true
+		}
+	}
+
+	return pkg + "/" + file, true
+}
+
+// shortFunction 去掉函数名中的包路径，github.com/foo/bar.(*T).Method -> bar.(*T).Method
+func shortFunction(name string) string {
+	return name[strings.LastIndexByte(name, '/')+1:]
+}
+
+// shortPath 只保留最后两级路径，/path/to/project/handler/user.go -> handler/user.go
+func shortPath(file string) string {
+	idx := strings.LastIndexByte(file, '/')
+	if idx < 0 {
+		return file
+	}
+
+	idx = strings.LastIndexByte(file[:idx], '/')
+	if idx < 0 {
+		return file
+	}
+
+	return file[idx+1:]
+}
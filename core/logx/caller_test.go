@@ -0,0 +1,235 @@
+package logx
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func useCaller(t *testing.T, c callerConf, sc stackConf) {
+	t.Helper()
+	old := activeCaller.Load()
+	t.Cleanup(func() {
+		activeCaller.Store(old)
+	})
+
+	if err := setupCaller(c, sc); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPackageFuncCaller(t *testing.T) {
+	last := captureEntry(t)
+	expect := func(name string) {
+		t.Helper()
+		_, file, line, _ := runtime.Caller(1)
+		want := prettyCaller(file, line-1)
+		if entry := last(); entry[defaultCallerKey] != want {
+			t.Errorf("%s caller 期望 %s, 实际 %v", name, want, entry[defaultCallerKey])
+		}
+	}
+
+	Info("foo")
+	expect("Info")
+	Infow("foo", Field("a", 1))
+	expect("Infow")
+	Errorf("foo %d", 1)
+	expect("Errorf")
+	Debugv("foo")
+	expect("Debugv")
+	Slowf("foo")
+	expect("Slowf")
+	Stat("foo")
+	expect("Stat")
+}
+
+func TestCallerLevels(t *testing.T) {
+	last := captureEntry(t)
+	useCaller(t, callerConf{Levels: []string{"error", " Slow "}}, stackConf{})
+
+	Info("foo")
+	if entry := last(); entry[defaultCallerKey] != nil {
+		t.Errorf("info 不应记录调用位置: %v", entry)
+	}
+	WithContext(context.Background()).Info("foo")
+	if entry := last(); entry[defaultCallerKey] != nil {
+		t.Errorf("info 不应记录调用位置: %v", entry)
+	}
+
+	Error("foo")
+	if entry := last(); !strings.HasPrefix(entry[defaultCallerKey].(string), "logx/caller_test.go:") {
+		t.Errorf("error 应记录调用位置: %v", entry)
+	}
+	Slow("foo")
+	if entry := last(); entry[defaultCallerKey] == nil {
+		t.Errorf("slow 应记录调用位置: %v", entry)
+	}
+}
+
+func TestCallerDisabled(t *testing.T) {
+	last := captureEntry(t)
+	useCaller(t, callerConf{Disabled: true, Levels: []string{"info"}}, stackConf{})
+
+	Error("foo")
+	if entry := last(); entry[defaultCallerKey] != nil {
+		t.Errorf("不应记录调用位置: %v", entry)
+	}
+
+	// 关闭时用户字段原样输出
+	Errorw("foo", Field(defaultCallerKey, "user"))
+	if entry := last(); entry[defaultCallerKey] != "user" || entry[reservedFieldPrefix+defaultCallerKey] != nil {
+		t.Errorf("关闭时不应重命名字段: %v", entry)
+	}
+}
+
+func TestCallerFunction(t *testing.T) {
+	last := captureEntry(t)
+	useCaller(t, callerConf{Function: true}, stackConf{})
+
+	Info("foo")
+	if entry := last(); entry[defaultFunctionKey] != "logx.TestCallerFunction" {
+		t.Errorf("函数名错误: %v", entry[defaultFunctionKey])
+	}
+
+	func() {
+		WithContext(context.Background()).Info("foo")
+	}()
+	if entry := last(); entry[defaultFunctionKey] != "logx.TestCallerFunction.func1" {
+		t.Errorf("函数名错误: %v", entry[defaultFunctionKey])
+	}
+}
+
+func TestCallerFormat(t *testing.T) {
+	last := captureEntry(t)
+
+	useCaller(t, callerConf{Format: callerFormatFull}, stackConf{})
+	Info("foo")
+	_, file, line, _ := runtime.Caller(0)
+	if entry := last(); entry[defaultCallerKey] != file+":"+strconv.Itoa(line-1) {
+		t.Errorf("full 格式错误: %v", entry[defaultCallerKey])
+	}
+
+	useCaller(t, callerConf{Format: callerFormatModule}, stackConf{})
+	Info("foo")
+	if entry := last(); !strings.HasPrefix(entry[defaultCallerKey].(string), "core/logx/caller_test.go:") {
+		t.Errorf("module 格式错误: %v", entry[defaultCallerKey])
+	}
+}
+
+func TestCallerReservedField(t *testing.T) {
+	last := captureEntry(t)
+	useCaller(t, callerConf{Function: true}, stackConf{})
+
+	fields := []LogField{Field(defaultCallerKey, "user"), Field(defaultFunctionKey, "fn")}
+	Infow("foo", fields...)
+	entry := last()
+	if entry[reservedFieldPrefix+defaultCallerKey] != "user" || entry[reservedFieldPrefix+defaultFunctionKey] != "fn" {
+		t.Errorf("用户字段应加上前缀: %v", entry)
+	}
+	if !strings.HasPrefix(entry[defaultCallerKey].(string), "logx/caller_test.go:") {
+		t.Errorf("调用位置不应被覆盖: %v", entry)
+	}
+	if fields[0].Key != defaultCallerKey {
+		t.Errorf("不应修改传入的字段: %v", fields)
+	}
+}
+
+func TestSlogCallerLevels(t *testing.T) {
+	last := captureEntry(t)
+	useCaller(t, callerConf{Levels: []string{"error"}}, stackConf{})
+	l := slog.New(NewSlogHandler())
+
+	l.Info("foo")
+	if entry := last(); entry[defaultCallerKey] != nil {
+		t.Errorf("info 不应记录调用位置: %v", entry)
+	}
+	l.Error("foo")
+	if entry := last(); !strings.HasPrefix(entry[defaultCallerKey].(string), "logx/caller_test.go:") {
+		t.Errorf("error 应记录调用位置: %v", entry)
+	}
+}
+
+func TestCaptureStack(t *testing.T) {
+	last := captureEntry(t)
+
+	ErrorStack("foo")
+	content := last()[defaultContentKey].(string)
+	if !strings.HasPrefix(content, "foo\n") {
+		t.Errorf("内容错误: %s", content)
+	}
+	if !strings.Contains(content, "logx.TestCaptureStack\n\tlogx/caller_test.go:") {
+		t.Errorf("堆栈应包含调用方: %s", content)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "runtime.") || strings.HasPrefix(line, logxPackage+".write") ||
+			strings.HasPrefix(line, logxPackage+".ErrorStack") {
+			t.Errorf("堆栈应过滤内部帧: %s", line)
+		}
+	}
+
+	useCaller(t, callerConf{}, stackConf{Depth: 1})
+	Severe("foo")
+	content = last()[defaultContentKey].(string)
+	if strings.Count(content, "\n\t") != 1 || !strings.HasSuffix(content, "\n"+stackEllipsis) {
+		t.Errorf("堆栈深度错误: %s", content)
+	}
+
+	useCaller(t, callerConf{}, stackConf{Disabled: true})
+	Severe("foo")
+	if content = last()[defaultContentKey].(string); content != "foo" {
+		t.Errorf("关闭堆栈后只输出内容, 实际 %s", content)
+	}
+}
+
+func TestNewCallerSettings(t *testing.T) {
+	s, err := newCallerSettings(callerConf{}, stackConf{})
+	if err != nil || s.levels != callerAll || s.format != callerFormatShort || s.stackDepth != defaultStackDepth {
+		t.Errorf("默认配置错误: %+v, %v", s, err)
+	}
+
+	if _, err = newCallerSettings(callerConf{Format: "relative"}, stackConf{}); err == nil {
+		t.Error("未知的格式应返回错误")
+	}
+	if _, err = newCallerSettings(callerConf{Levels: []string{"severe"}}, stackConf{}); err == nil {
+		t.Error("未知的级别应返回错误")
+	}
+
+	resetSetup(t)
+	if err = SetUp(LogConf{Mode: "console", Caller: callerConf{Levels: []string{"warn"}}}); err == nil {
+		t.Error("SetUp 应返回配置错误")
+	}
+}
+
+func TestShortPath(t *testing.T) {
+	tests := map[string]string{
+		"/path/to/project/handler/user.go": "handler/user.go",
+		"handler/user.go":                  "handler/user.go",
+		"user.go":                          "user.go",
+	}
+	for file, expect := range tests {
+		if actual := shortPath(file); actual != expect {
+			t.Errorf("%s 期望 %s, 实际 %s", file, expect, actual)
+		}
+	}
+}
+
+func TestShortFunction(t *testing.T) {
+	if actual := shortFunction("github.com/foo/bar.(*T).Method"); actual != "bar.(*T).Method" {
+		t.Errorf("函数名错误: %s", actual)
+	}
+}
+
+func TestCallerDisabledNoAllocs(t *testing.T) {
+	useCaller(t, callerConf{Disabled: true}, stackConf{})
+
+	fields := []LogField{Field("a", 1)}
+	allocs := testing.AllocsPerRun(100, func() {
+		withCaller(levelInfo, fields)
+	})
+	if allocs != 0 {
+		t.Errorf("关闭调用位置时不应分配内存, 实际 %v 次", allocs)
+	}
+}
@@ -33,11 +33,13 @@ type (
 		// Encoding 日志编码格式
 		//
 		// 可选值：
-		//  - "json":  JSON格式，机器可读，适合生产环境
-		//  - "plain": 纯文本格式，人类可读，适合开发环境
+		//  - "json":   JSON格式，机器可读，适合生产环境
+		//  - "plain":  纯文本格式，人类可读，适合开发环境
+		//  - "logfmt": key=value格式，兼容Heroku、Loki等日志系统
+		//  - "pretty": 列对齐、级别着色、结构化字段多行展示，仅适合本地开发
 		//
 		// 默认值: "json"
-		Encoding string `json:",default=json,options=[json,plain]"`
+		Encoding string `json:",default=json,options=[json,plain,logfmt,pretty]"`
 
 		// TimeFormat 日志时间格式
 		//
@@ -166,6 +168,136 @@ type (
 		// 用于自定义日志字段的键名，适配不同的日志收集系统
 		// 如ELK、Splunk、Loki等
 		FieldKeys fieldKeyConf `json:",optional"`
+
+		// Sampling 日志采样配置
+		//
+		// 按 级别+消息模板 分组，每个周期内只输出前 Initial 条，
+		// 之后每 Thereafter 条输出一条，被丢弃的条数会定期汇总输出
+		// 用于防止故障期间大量重复日志刷屏
+		//
+		// 默认不采样
+		Sampling samplingConf `json:",optional"`
+
+		// LoggerLevels 命名 Logger 的日志级别覆盖规则
+		//
+		// 格式为 "名称=级别"，名称按 "." 分层，未配置的名称使用最近的上级规则，
+		// "*" 匹配所有命名 Logger，都未匹配时使用全局级别
+		// 只对通过 logx.Named 创建的 Logger 生效
+		//
+		// 示例：
+		//  - ["cache.redis=debug", "*=info"]
+		LoggerLevels []string `json:",optional"`
+
+		// Masking 敏感信息脱敏配置
+		//
+		// 按字段名、内容正则和结构体标签 logx:"sensitive" 对日志脱敏，
+		// 递归处理嵌套的 map、结构体和切片
+		// 未配置时只处理实现了 Sensitive 接口的值和带标签的结构体字段
+		Masking maskingConf `json:",optional"`
+
+		// Caller 调用位置配置
+		//
+		// 默认所有级别都记录调用位置，Alert、Severe 和 ErrorStack 不记录，堆栈中已包含调用位置
+		Caller callerConf `json:",optional"`
+
+		// Stack Severe 和 ErrorStack 的堆栈配置
+		//
+		// 堆栈中过滤了 runtime 和 logx 自身的帧
+		Stack stackConf `json:",optional"`
+
+		// StatReport 运行时指标的定期上报配置
+		//
+		// Stat 开启时，按周期将 Go 运行时、进程 CPU、cgroup 限制和自定义计数器输出到统计日志
+		StatReport statReportConf `json:",optional"`
+
+		// FileSync 日志文件的落盘策略
+		//
+		// 仅当Mode为"file"或"volume"时生效
+		// 日志先进入缓冲区再由后台写入文件，进程崩溃或断电时未落盘的日志会丢失
+		FileSync fileSyncConf `json:",optional"`
+	}
+
+	// callerConf 定义调用位置的记录方式
+	callerConf struct {
+		// Disabled 是否关闭调用位置，关闭后不做任何栈回溯
+		Disabled bool `json:",optional"`
+
+		// Levels 记录调用位置的级别，为空时所有级别都记录
+		//
+		// 可选值: debug、info、error、slow、stat
+		//
+		// 示例：
+		//  - ["error", "slow"]: 只有错误和慢日志记录调用位置
+		Levels []string `json:",optional"`
+
+		// Format 文件路径格式
+		//
+		// 可选值：
+		//  - "short":  只保留最后两级路径，如 handler/user.go:45
+		//  - "module": 相对主模块的路径，其他模块使用导入路径，如 internal/handler/user.go:45
+		//  - "full":   完整的文件路径
+		//
+		// 默认值: "short"
+		Format string `json:",default=short,options=[short,module,full]"`
+
+		// Function 是否记录调用函数名，输出到 FieldKeys.FunctionKey 字段
+		Function bool `json:",optional"`
+	}
+
+	// statReportConf 定义运行时指标的上报方式
+	statReportConf struct {
+		// IntervalMillis 上报周期（毫秒）
+		//
+		// 设置为0表示不上报
+		//
+		// 默认值: 60000
+		IntervalMillis int `json:",default=60000"`
+
+		// Metrics 上报的指标，为空时上报全部
+		//
+		// 可选值：
+		//  - "runtime":  协程数、堆内存和 GC 次数及停顿时间
+		//  - "cpu":      进程的 CPU 使用量，读取 /proc/self/stat，单位为毫核
+		//  - "cgroup":   cgroup v1/v2 的 CPU 和内存限制
+		//  - "counters": 通过 NewStatCounter 注册的计数器在周期内的增量
+		Metrics []string `json:",optional"`
+	}
+
+	// fileSyncConf 定义日志文件的落盘策略
+	fileSyncConf struct {
+		// Policy 落盘策略
+		//
+		// 可选值：
+		//  - "never":    不主动落盘，由操作系统决定，只在轮转和关闭时落盘
+		//  - "interval": 每隔 IntervalMillis 落盘一次
+		//  - "every":    每写入 Every 条日志落盘一次
+		//  - "severe":   severe.log 每条日志落盘后才返回，其他文件不主动落盘
+		//
+		// 默认值: "never"
+		Policy string `json:",default=never,options=[never,interval,every,severe]"`
+
+		// IntervalMillis Policy 为"interval"时的落盘周期（毫秒）
+		//
+		// 默认值: 1000
+		IntervalMillis int `json:",default=1000"`
+
+		// Every Policy 为"every"时每多少条日志落盘一次
+		//
+		// 设置为1时每条日志落盘后才返回
+		//
+		// 默认值: 100
+		Every int `json:",default=100"`
+	}
+
+	// stackConf 定义堆栈的记录方式
+	stackConf struct {
+		// Disabled 是否关闭堆栈，关闭后 Severe 和 ErrorStack 只输出内容
+		Disabled bool `json:",optional"`
+
+		// Depth 最多记录的帧数，超出部分以 ... 结尾
+		//
+		// 默认值: 32
+		Depth int `json:",default=32"`
 	}
 
 	// fieldKeyConf 定义日志字段的键名配置
@@ -196,6 +328,13 @@ type (
 		// 默认值: "duration"
 		DurationKey string `json:",default=duration"`
 
+		// FunctionKey 调用函数名字段键名
+		//
+		// 开启 Caller.Function 时输出，如 handler.(*UserHandler).Login
+		//
+		// 默认值: "func"
+		FunctionKey string `json:",default=func"`
+
 		// LevelKey 日志级别字段键名
 		//
 		// 存储日志级别信息（debug, info, error等）
@@ -203,6 +342,13 @@ type (
 		// 默认值: "level"
 		LevelKey string `json:",default=level"`
 
+		// LoggerKey 命名 Logger 的名称字段键名
+		//
+		// 通过 logx.Named 创建的 Logger 会输出此字段
+		//
+		// 默认值: "logger"
+		LoggerKey string `json:",default=logger"`
+
 		// SpanKey 分布式追踪跨度字段键名
 		//
 		// 用于微服务架构中的请求追踪
@@ -231,4 +377,60 @@ type (
 		// 默认值: "truncated"
 		TruncatedKey string `json:",default=truncated"`
 	}
+
+	// maskingConf 定义敏感信息脱敏的规则
+	maskingConf struct {
+		// Keys 需要脱敏的字段名，不区分大小写
+		//
+		// 对日志字段、嵌套 map 的键和结构体字段（优先使用 json 标签名）生效
+		//
+		// 示例：
+		//  - ["password", "token", "authorization"]
+		Keys []string `json:",optional"`
+
+		// Rules 内置的内容脱敏规则，对字符串内容和字符串字段生效
+		//
+		// 可选值：
+		//  - "credit_card": 银行卡号，经过 Luhn 校验
+		//  - "email":       邮箱地址
+		//  - "jwt":         JWT
+		//  - "phone":       中国大陆手机号和 E.164 格式的国际号码
+		Rules []string `json:",optional"`
+
+		// Patterns 自定义的内容脱敏正则表达式，匹配的部分会被替换
+		Patterns []string `json:",optional"`
+
+		// Replacement 脱敏后的替换内容
+		//
+		// 默认值: "******"
+		Replacement string `json:",default=******"`
+	}
+
+	// samplingConf 定义日志采样的配置
+	//
+	// 消息模板：Xf 系列取格式化字符串，Xw 系列取 msg，X 系列取第一个参数，其余取日志内容本身
+	// 每个周期内超过 1024 个模板后，新的模板共用同一个计数
+	// 只对 debug、info、error、slow 级别生效，severe、alert、stack、stat 不采样
+	samplingConf struct {
+		// Initial 每个周期内每个模板直接输出的条数
+		//
+		// 设置为0表示关闭采样
+		//
+		// 默认值: 0
+		Initial int `json:",optional"`
+
+		// Thereafter 超过 Initial 后，每 Thereafter 条输出一条
+		//
+		// 设置为0表示超过 Initial 后全部丢弃
+		//
+		// 默认值: 0
+		Thereafter int `json:",optional"`
+
+		// IntervalMillis 采样周期（毫秒）
+		//
+		// 每个周期结束时重置计数，并输出该周期内被丢弃的条数
+		//
+		// 默认值: 1000
+		IntervalMillis int `json:",default=1000"`
+	}
 )
@@ -0,0 +1,104 @@
+package logx
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// 致命错误时依次执行的监听函数，按注册顺序执行
+	shutdownListeners     []func()
+	shutdownListenersLock sync.Mutex
+	// 是否正在处理致命错误，监听函数中再次调用 Fatal 时不重复关闭
+	fatalling atomic.Bool
+)
+
+// fatalWriter 可选接口，实现后致命错误以 fatal 级别输出，否则通过 Severe 输出
+type fatalWriter interface {
+	Fatal(v any, fields ...LogField)
+}
+
+// AddShutdownListener adds fn to be called when a fatal error is logged by Fatal, Fatalf,
+// Fatalw or Must. The listeners are called in the order of registration after all the
+// writers are flushed and closed, so the logs written in fn go to the console.
+func AddShutdownListener(fn func()) {
+	shutdownListenersLock.Lock()
+	defer shutdownListenersLock.Unlock()
+	shutdownListeners = append(shutdownListeners, fn)
+}
+
+// Fatal writes v along with call stack into severe log at fatal level, then closes the
+// logging, calls the shutdown listeners and exits.
+// It returns instead of exiting if ExitOnFatal is false.
+func Fatal(v ...any) {
+	fatal(fmt.Sprint(v...), nil)
+}
+
+// Fatalf writes v with format along with call stack into severe log at fatal level, then
+// closes the logging, calls the shutdown listeners and exits.
+// It returns instead of exiting if ExitOnFatal is false.
+func Fatalf(format string, v ...any) {
+	fatal(fmt.Sprintf(format, v...), nil)
+}
+
+// Fatalw writes msg along with fields and call stack into severe log at fatal level, then
+// closes the logging, calls the shutdown listeners and exits.
+// It returns instead of exiting if ExitOnFatal is false.
+func Fatalw(msg string, fields ...LogField) {
+	fatal(msg, fields)
+}
+
+// fatal 输出致命错误，关闭所有写入器（异步和轮转写入器会先落盘），执行监听函数后退出
+func fatal(msg string, fields []LogField) {
+	// 不受日志级别影响，只有禁用日志时不输出
+	if atomic.LoadUint32(&logLevel) != disableLevel {
+		writeFatal(getWriter(), withStack(msg), fields)
+	}
+
+	// 监听函数中再次调用时只输出日志
+	if !fatalling.CompareAndSwap(false, true) {
+		return
+	}
+	defer fatalling.Store(false)
+
+	if err := Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to close logging: %v\n", err)
+	}
+	runShutdownListeners()
+
+	if ExitOnFatal.True() {
+		os.Exit(1)
+	}
+}
+
+func runShutdownListeners() {
+	shutdownListenersLock.Lock()
+	listeners := append([]func(){}, shutdownListeners...)
+	shutdownListenersLock.Unlock()
+
+	for _, listener := range listeners {
+		runShutdownListener(listener)
+	}
+}
+
+// runShutdownListener 执行监听函数，panic 不影响后续的监听函数
+func runShutdownListener(listener func()) {
+	defer func() {
+		if p := recover(); p != nil {
+			fmt.Fprintf(os.Stderr, "shutdown listener panic: %v\n", p)
+		}
+	}()
+
+	listener()
+}
+
+// writeFatal 写入器未实现 fatalWriter 时通过 Severe 输出，字段无法输出
+func writeFatal(w Writer, v any, fields []LogField) {
+	if fw, ok := w.(fatalWriter); ok {
+		fw.Fatal(v, fields...)
+	} else {
+		w.Severe(v)
+	}
+}
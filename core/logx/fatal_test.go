@@ -0,0 +1,155 @@
+package logx
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closeRecorder 记录 Close 的调用，嵌入 Writer 接口而不是 mockWriter，不实现 Fatal
+type closeRecorder struct {
+	Writer
+	closed atomic.Bool
+}
+
+func newCloseRecorder() *closeRecorder {
+	return &closeRecorder{Writer: new(mockWriter)}
+}
+
+func (w *closeRecorder) Contains(text string) bool {
+	return w.Writer.(*mockWriter).Contains(text)
+}
+
+func (w *closeRecorder) String() string {
+	return w.Writer.(*mockWriter).String()
+}
+
+func (w *closeRecorder) Close() error {
+	w.closed.Store(true)
+	return nil
+}
+
+func useFatal(t *testing.T, w Writer) {
+	t.Helper()
+	ExitOnFatal.Set(false)
+	shutdownListenersLock.Lock()
+	oldListeners := shutdownListeners
+	shutdownListeners = nil
+	shutdownListenersLock.Unlock()
+	originalLevel := atomic.LoadUint32(&logLevel)
+	old := writer.Swap(w)
+	t.Cleanup(func() {
+		ExitOnFatal.Set(true)
+		shutdownListenersLock.Lock()
+		shutdownListeners = oldListeners
+		shutdownListenersLock.Unlock()
+		atomic.StoreUint32(&logLevel, originalLevel)
+		writer.Store(old)
+	})
+}
+
+func TestFatalw(t *testing.T) {
+	w := new(mockWriter)
+	useFatal(t, w)
+	atomic.StoreUint32(&logLevel, SevereLevel)
+
+	var calls []string
+	AddShutdownListener(func() {
+		if writer.Load() != nil {
+			t.Error("执行监听函数前应关闭写入器")
+		}
+		calls = append(calls, "first")
+	})
+	AddShutdownListener(func() {
+		calls = append(calls, "second")
+	})
+
+	Fatalw("boom", Field("code", 500))
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(w.String()), &entry); err != nil {
+		t.Fatalf("日志不是合法的 JSON: %s", w.String())
+	}
+	if entry[defaultLevelKey] != levelFatal || entry["code"] != float64(500) {
+		t.Errorf("日志错误: %v", entry)
+	}
+	if content := entry[defaultContentKey].(string); !strings.HasPrefix(content, "boom\n") ||
+		!strings.Contains(content, "logx.TestFatalw") {
+		t.Errorf("应包含堆栈: %s", content)
+	}
+	if strings.Join(calls, ",") != "first,second" {
+		t.Errorf("监听函数执行顺序错误: %v", calls)
+	}
+}
+
+func TestFatalFallbackToSevere(t *testing.T) {
+	w := newCloseRecorder()
+	useFatal(t, w)
+
+	Fatalf("boom %d", 1)
+	if !w.Contains(`"level":"severe"`) || !w.Contains("boom 1") {
+		t.Errorf("未实现 Fatal 的写入器应通过 Severe 输出: %s", w.String())
+	}
+	if !w.closed.Load() {
+		t.Error("应关闭写入器")
+	}
+}
+
+func TestFatalFlushesAsyncWriter(t *testing.T) {
+	w := newCloseRecorder()
+	useFatal(t, NewAsyncWriter(w, WithAsyncFlushInterval(time.Hour)))
+
+	Info("before")
+	Fatal("boom")
+	if !w.Contains("before") || !w.Contains("boom") {
+		t.Errorf("异步写入器应在退出前写出: %s", w.String())
+	}
+	if !w.closed.Load() {
+		t.Error("应关闭底层写入器")
+	}
+}
+
+func TestFatalDisabled(t *testing.T) {
+	w := new(mockWriter)
+	useFatal(t, w)
+	atomic.StoreUint32(&logLevel, disableLevel)
+
+	var called bool
+	AddShutdownListener(func() {
+		called = true
+	})
+	Fatal("boom")
+	if len(w.String()) > 0 {
+		t.Errorf("禁用日志时不应输出: %s", w.String())
+	}
+	if !called {
+		t.Error("禁用日志时仍应执行监听函数")
+	}
+}
+
+func TestShutdownListenerReentrant(t *testing.T) {
+	w := new(mockWriter)
+	useFatal(t, w)
+
+	var calls int
+	AddShutdownListener(func() {
+		calls++
+		Fatal("again")
+	})
+	AddShutdownListener(func() {
+		panic("listener")
+	})
+	AddShutdownListener(func() {
+		calls++
+	})
+
+	Fatal("boom")
+	if calls != 2 {
+		t.Errorf("监听函数应各执行一次, 实际 %d 次", calls)
+	}
+	if fatalling.Load() {
+		t.Error("处理完成后应重置状态")
+	}
+}
@@ -11,17 +11,49 @@ var (
 	globalFieldsLock sync.Mutex
 )
 
+// reservedFieldPrefix 与系统字段同名的用户字段会加上该前缀输出，避免静默覆盖系统字段
+const reservedFieldPrefix = "fields."
+
 type fieldsKey struct{}
 
+// AddGlobalFields adds global fields that will be written into every log entry.
+//
+// When keys collide, the precedence is:
+//
+//	call-site fields > context fields > global fields
+//
+// System keys (see fieldKeyConf) are always written by logx itself,
+// a user field with the same key is renamed with the "fields." prefix instead of overwriting it.
 func AddGlobalFields(fields ...LogField) {
 	globalFieldsLock.Lock()
 	defer globalFieldsLock.Unlock()
-	old := globalFields.Load()
-	if old == nil {
-		globalFields.Store(append([]LogField(nil), fields...))
-	} else {
-		globalFields.Store(append(old.([]LogField), fields...))
+	old, _ := globalFields.Load().([]LogField)
+	merged := make([]LogField, 0, len(old)+len(fields))
+	merged = append(merged, old...)
+	merged = append(merged, fields...)
+	protectReservedFields(loadFieldKeys(), merged[len(old):])
+	globalFields.Store(merged)
+}
+
+// RemoveGlobalFields removes the global fields with the given keys.
+func RemoveGlobalFields(keys ...string) {
+	globalFieldsLock.Lock()
+	defer globalFieldsLock.Unlock()
+	old, _ := globalFields.Load().([]LogField)
+	remained := make([]LogField, 0, len(old))
+	for _, field := range old {
+		if !containsKey(keys, field.Key) {
+			remained = append(remained, field)
+		}
 	}
+	globalFields.Store(remained)
+}
+
+// SetGlobalFields replaces all the global fields with the given fields, calling it with no fields clears them.
+func SetGlobalFields(fields ...LogField) {
+	globalFieldsLock.Lock()
+	defer globalFieldsLock.Unlock()
+	globalFields.Store(protectReservedFields(loadFieldKeys(), append([]LogField(nil), fields...)))
 }
 
 func ContextWithFields(ctx context.Context, fields ...LogField) context.Context {
@@ -39,3 +71,33 @@ func ContextWithFields(ctx context.Context, fields ...LogField) context.Context
 func WithFields(ctx context.Context, fields ...LogField) context.Context {
 	return ContextWithFields(ctx, fields...)
 }
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (k *systemKeys) isReserved(key string) bool {
+	switch key {
+	case k.caller, k.content, k.duration, k.function, k.level, k.logger, k.span, k.timestamp, k.trace,
+		k.truncated:
+		return true
+	default:
+		return false
+	}
+}
+
+// protectReservedFields 将与系统字段同名的用户字段重命名，没有冲突时原样返回
+// 有冲突时会直接修改传入的切片，调用方需保证切片不被其他地方共享
+func protectReservedFields(keys *systemKeys, fields []LogField) []LogField {
+	for i := range fields {
+		if keys.isReserved(fields[i].Key) {
+			fields[i].Key = reservedFieldPrefix + fields[i].Key
+		}
+	}
+	return fields
+}
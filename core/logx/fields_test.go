@@ -3,6 +3,7 @@ package logx
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync/atomic"
 	"testing"
 )
@@ -169,3 +170,99 @@ func TestContextWithFields(t *testing.T) {
 		}
 	})
 }
+
+// resetGlobalFields 清空全局字段，测试结束后恢复
+func resetGlobalFields(t *testing.T) {
+	old, _ := globalFields.Load().([]LogField)
+	SetGlobalFields()
+	t.Cleanup(func() {
+		SetGlobalFields(old...)
+	})
+}
+
+func TestGlobalFieldsPrecedence(t *testing.T) {
+	resetGlobalFields(t)
+	last := captureEntry(t)
+
+	AddGlobalFields(Field("key", "global"), Field("app", "demo"))
+	ctx := ContextWithFields(context.Background(), Field("key", "context"))
+
+	WithContext(context.Background()).Info("only global")
+	if entry := last(); entry["key"] != "global" || entry["app"] != "demo" {
+		t.Errorf("应输出全局字段, 实际 %v", entry)
+	}
+
+	WithContext(ctx).Info("context over global")
+	if entry := last(); entry["key"] != "context" {
+		t.Errorf("context 字段应覆盖全局字段, 实际 %v", entry["key"])
+	}
+
+	WithContext(ctx).Infow("call-site over context", Field("key", "call"))
+	if entry := last(); entry["key"] != "call" || entry["app"] != "demo" {
+		t.Errorf("调用处字段应覆盖 context 字段, 实际 %v", entry)
+	}
+
+	Infow("package level", Field("key", "call"))
+	if entry := last(); entry["key"] != "call" || entry["app"] != "demo" {
+		t.Errorf("包级函数也应合并全局字段, 实际 %v", entry)
+	}
+}
+
+func TestReservedFields(t *testing.T) {
+	resetGlobalFields(t)
+	last := captureEntry(t)
+
+	AddGlobalFields(Field(defaultLevelKey, "global-level"), Field(defaultTraceKey, "global-trace"))
+	ctx := ContextWithTrace(context.Background(), "real-trace", "")
+	WithContext(ctx).Infow("hello", Field(defaultCallerKey, "fake"), Field(defaultContentKey, "fake"))
+
+	entry := last()
+	if entry[defaultLevelKey] != levelInfo || entry[reservedFieldPrefix+defaultLevelKey] != "global-level" {
+		t.Errorf("全局字段不应覆盖 level, 实际 %v", entry)
+	}
+	if entry[defaultTraceKey] != "real-trace" || entry[reservedFieldPrefix+defaultTraceKey] != "global-trace" {
+		t.Errorf("全局字段不应覆盖 trace, 实际 %v", entry)
+	}
+	if entry[defaultCallerKey] == "fake" || entry[reservedFieldPrefix+defaultCallerKey] != "fake" {
+		t.Errorf("调用处字段不应覆盖 caller, 实际 %v", entry)
+	}
+	if entry[defaultContentKey] != "hello" || entry[reservedFieldPrefix+defaultContentKey] != "fake" {
+		t.Errorf("调用处字段不应覆盖 content, 实际 %v", entry)
+	}
+
+	t.Run("直接调用 Writer", func(t *testing.T) {
+		var buf strings.Builder
+		output(&buf, levelInfo, "direct", Field(defaultTimestampKey, "fake"))
+		if !strings.Contains(buf.String(), `"fields.@timestamp":"fake"`) {
+			t.Errorf("同名字段应被重命名, 实际 %s", buf.String())
+		}
+	})
+}
+
+func TestRemoveAndSetGlobalFields(t *testing.T) {
+	resetGlobalFields(t)
+
+	AddGlobalFields(Field("a", 1), Field("b", 2), Field("c", 3))
+	RemoveGlobalFields("a", "c")
+	fields := globalFields.Load().([]LogField)
+	if len(fields) != 1 || fields[0].Key != "b" {
+		t.Errorf("RemoveGlobalFields 后应只剩 b, 实际 %v", fields)
+	}
+
+	SetGlobalFields(Field("d", 4))
+	fields = globalFields.Load().([]LogField)
+	if len(fields) != 1 || fields[0].Key != "d" {
+		t.Errorf("SetGlobalFields 应替换所有全局字段, 实际 %v", fields)
+	}
+
+	SetGlobalFields()
+	if merged := mergeGloablFields([]LogField{Field("x", 1)}); len(merged) != 1 {
+		t.Errorf("清空后不应合并全局字段, 实际 %v", merged)
+	}
+
+	input := []LogField{Field(defaultLevelKey, "x")}
+	AddGlobalFields(input...)
+	if input[0].Key != defaultLevelKey {
+		t.Error("AddGlobalFields 不应修改调用方的切片")
+	}
+}
@@ -0,0 +1,221 @@
+//go:build linux
+
+package logx
+
+import (
+	"encoding/binary"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	journalSocket = "/run/systemd/journal/socket"
+	// 超过数据报大小限制时，通过 /dev/shm 中的临时文件传递
+	journalTempDir     = "/dev/shm"
+	maxJournalFieldLen = 64
+)
+
+type journaldWriter struct {
+	// 未连接的数据报 socket，已连接的 socket 无法附带文件描述符发送
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+}
+
+// NewJournaldWriter returns a Writer that sends entries to the systemd journal with the native protocol.
+// Fields are written as journal fields with upper-cased names, and identifier is used as SYSLOG_IDENTIFIER.
+func NewJournaldWriter(identifier string) (Writer, error) {
+	return newJournaldWriter(journalSocket, identifier)
+}
+
+func newJournaldWriter(socket, identifier string) (Writer, error) {
+	if _, err := os.Stat(socket); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldWriter{
+		conn:       conn,
+		addr:       &net.UnixAddr{Name: socket, Net: "unixgram"},
+		identifier: identifier,
+	}, nil
+}
+
+func (w *journaldWriter) Alert(v any) {
+	w.write(syslogAlert, levelAlert, v, nil)
+}
+
+func (w *journaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+func (w *journaldWriter) Debug(v any, fields ...LogField) {
+	w.write(syslogDebug, levelDebug, v, fields)
+}
+
+func (w *journaldWriter) Error(v any, fields ...LogField) {
+	w.write(syslogError, levelError, v, fields)
+}
+
+func (w *journaldWriter) Fatal(v any, fields ...LogField) {
+	w.write(syslogAlert, levelFatal, v, fields)
+}
+
+func (w *journaldWriter) Info(v any, fields ...LogField) {
+	w.write(syslogInfo, levelInfo, v, fields)
+}
+
+func (w *journaldWriter) Severe(v any) {
+	w.write(syslogCritical, levelSevere, v, nil)
+}
+
+func (w *journaldWriter) Slow(v any, fields ...LogField) {
+	w.write(syslogWarning, levelSlow, v, fields)
+}
+
+func (w *journaldWriter) Stack(v any) {
+	w.write(syslogError, levelError, v, nil)
+}
+
+func (w *journaldWriter) Stat(v any, fields ...LogField) {
+	w.write(syslogInfo, levelStat, v, fields)
+}
+
+// appendEntry 按 journal 原生协议追加一条日志，每个字段一行
+func (w *journaldWriter) appendEntry(b, scratch []byte, priority int, level string, val any,
+	fields []LogField) ([]byte, []byte) {
+	keys := loadFieldKeys()
+	fields = mergeGloablFields(fields)
+	val, truncated := processContent(val)
+	if truncated {
+		fields = append(fields, Field(keys.truncated, true))
+	}
+
+	scratch = appendTextValue(scratch[:0], val)
+	b = appendJournalField(b, "MESSAGE", scratch)
+	b = appendJournalField(b, "PRIORITY", strconv.AppendInt(scratch[:0], int64(priority), 10))
+	if len(w.identifier) > 0 {
+		b = appendJournalField(b, "SYSLOG_IDENTIFIER", append(scratch[:0], w.identifier...))
+	}
+	if name := journalFieldName(keys.level); len(name) > 0 {
+		b = appendJournalField(b, name, append(scratch[:0], level...))
+	}
+
+	for _, field := range dedupFields(fields) {
+		name := journalFieldName(fieldKey(keys, field.Key))
+		if len(name) == 0 {
+			continue
+		}
+		if isJournalReserved(name) {
+			name = journalFieldName(reservedFieldPrefix + name)
+		}
+
+		scratch = appendTextValue(scratch[:0], maskField(field.Key, field.Value))
+		b = appendJournalField(b, name, scratch)
+	}
+
+	return b, scratch
+}
+
+// send 发送一条日志，超过数据报大小限制时改用临时文件传递
+func (w *journaldWriter) send(data []byte) error {
+	_, err := w.conn.WriteToUnix(data, w.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	file, err := os.CreateTemp(journalTempDir, "journal.*")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// 删除文件名，journald 通过文件描述符读取内容
+	if err = os.Remove(file.Name()); err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		return err
+	}
+
+	_, _, err = w.conn.WriteMsgUnix(nil, syscall.UnixRights(int(file.Fd())), w.addr)
+	return err
+}
+
+func (w *journaldWriter) write(priority int, level string, val any, fields []LogField) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	scratch := getBuffer()
+	defer putBuffer(scratch)
+
+	*buf, *scratch = w.appendEntry(*buf, *scratch, priority, level, val, fields)
+	if err := w.send(*buf); err != nil {
+		log.Println("failed to write journald:", err)
+	}
+}
+
+// appendJournalField 追加一个字段，值中包含换行时使用二进制格式：
+// NAME\n + 小端序 uint64 长度 + 值 + \n
+func appendJournalField(b []byte, name string, value []byte) []byte {
+	b = append(b, name...)
+	for _, c := range value {
+		if c == '\n' {
+			b = append(b, '\n')
+			b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+			b = append(b, value...)
+			return append(b, '\n')
+		}
+	}
+
+	b = append(b, '=')
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// journalFieldName 返回合法的字段名：大写字母、数字和下划线，不能以下划线或数字开头，最长 64 字节
+func journalFieldName(key string) string {
+	var builder strings.Builder
+	for i := 0; i < len(key) && builder.Len() < maxJournalFieldLen; i++ {
+		c := key[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+			builder.WriteByte(c - 'a' + 'A')
+		case c >= 'A' && c <= 'Z':
+			builder.WriteByte(c)
+		case c >= '0' && c <= '9':
+			// 开头的数字没有对应的合法字符，直接丢弃
+			if builder.Len() > 0 {
+				builder.WriteByte(c)
+			}
+		default:
+			// 下划线开头的字段由 journald 保留
+			if builder.Len() > 0 {
+				builder.WriteByte('_')
+			}
+		}
+	}
+
+	return builder.String()
+}
+
+// isJournalReserved 判断字段名是否与 journald 写入器自身输出的字段冲突
+func isJournalReserved(name string) bool {
+	switch name {
+	case "MESSAGE", "PRIORITY", "SYSLOG_IDENTIFIER":
+		return true
+	default:
+		return false
+	}
+}
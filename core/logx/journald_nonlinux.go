@@ -0,0 +1,13 @@
+//go:build !linux
+
+package logx
+
+import "errors"
+
+// ErrJournaldUnsupported is returned by NewJournaldWriter on non-linux platforms.
+var ErrJournaldUnsupported = errors.New("journald is only supported on linux")
+
+// NewJournaldWriter is not supported on non-linux platforms.
+func NewJournaldWriter(_ string) (Writer, error) {
+	return nil, ErrJournaldUnsupported
+}
@@ -0,0 +1,198 @@
+//go:build linux
+
+package logx
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newJournalListener(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skip("unixgram 不可用:", err)
+	}
+	t.Cleanup(func() {
+		conn.Close()
+	})
+
+	return conn, path
+}
+
+// parseJournalEntry 解析 journal 原生协议，支持 KEY=value 和二进制两种格式
+func parseJournalEntry(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	result := make(map[string]string)
+	for len(data) > 0 {
+		end := strings.IndexByte(string(data), '\n')
+		if end < 0 {
+			t.Fatalf("字段缺少换行: %q", data)
+		}
+
+		line := string(data[:end])
+		if key, value, ok := strings.Cut(line, "="); ok {
+			result[key] = value
+			data = data[end+1:]
+			continue
+		}
+
+		data = data[end+1:]
+		size := binary.LittleEndian.Uint64(data[:8])
+		result[line] = string(data[8 : 8+size])
+		if data[8+size] != '\n' {
+			t.Fatalf("二进制字段 %s 缺少结尾换行", line)
+		}
+		data = data[8+size+1:]
+	}
+
+	return result
+}
+
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if oobn == 0 {
+		return parseJournalEntry(t, buf[:n])
+	}
+
+	// 通过文件描述符传递的日志
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) != 1 {
+		t.Fatal("解析控制消息失败:", err)
+	}
+	fds, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatal("解析文件描述符失败:", err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "journal")
+	defer file.Close()
+	file.Seek(0, io.SeekStart)
+	data, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return parseJournalEntry(t, data)
+}
+
+func TestJournaldWriter(t *testing.T) {
+	resetGlobalFields(t)
+	conn, path := newJournalListener(t)
+	w, err := newJournaldWriter(path, "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	tests := []struct {
+		name     string
+		log      func()
+		priority string
+		level    string
+	}{
+		{"alert", func() { w.Alert("foo") }, "1", levelAlert},
+		{"severe", func() { w.Severe("foo") }, "2", levelSevere},
+		{"error", func() { w.Error("foo") }, "3", levelError},
+		{"slow", func() { w.Slow("foo") }, "4", levelSlow},
+		{"info", func() { w.Info("foo") }, "6", levelInfo},
+		{"stat", func() { w.Stat("foo") }, "6", levelStat},
+		{"debug", func() { w.Debug("foo") }, "7", levelDebug},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.log()
+			entry := readJournalEntry(t, conn)
+			if entry["MESSAGE"] != "foo" || entry["PRIORITY"] != test.priority ||
+				entry["SYSLOG_IDENTIFIER"] != "myapp" || entry["LEVEL"] != test.level {
+				t.Errorf("日志字段错误: %v", entry)
+			}
+		})
+	}
+}
+
+func TestJournaldWriterFields(t *testing.T) {
+	resetGlobalFields(t)
+	conn, path := newJournalListener(t)
+	w, err := newJournaldWriter(path, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Info("line1\nline2", Field("user-id", 1), Field("message", "custom"),
+		Field("_cursor", "x"), Field("2fa", true), Field("trace", "a\nb"))
+	entry := readJournalEntry(t, conn)
+
+	expect := map[string]string{
+		"MESSAGE":        "line1\nline2",
+		"PRIORITY":       "6",
+		"LEVEL":          levelInfo,
+		"USER_ID":        "1",
+		"FIELDS_MESSAGE": "custom",
+		"CURSOR":         "x",
+		"FA":             "true",
+		"TRACE":          "a\nb",
+	}
+	if len(entry) != len(expect) {
+		t.Errorf("字段数量期望 %d, 实际 %d: %v", len(expect), len(entry), entry)
+	}
+	for key, value := range expect {
+		if entry[key] != value {
+			t.Errorf("字段 %s 期望 %q, 实际 %q", key, value, entry[key])
+		}
+	}
+}
+
+func TestJournaldWriterLargeEntry(t *testing.T) {
+	resetGlobalFields(t)
+	conn, path := newJournalListener(t)
+	w, err := newJournaldWriter(path, "myapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	if _, err = os.Stat(journalTempDir); err != nil {
+		t.Skip(journalTempDir, "不可用")
+	}
+
+	// 超过数据报大小限制，需要通过文件描述符传递
+	large := strings.Repeat("a", 300*1024)
+	w.Info(large)
+	entry := readJournalEntry(t, conn)
+	if entry["MESSAGE"] != large || entry["SYSLOG_IDENTIFIER"] != "myapp" {
+		t.Error("大日志内容错误")
+	}
+}
+
+func TestJournalFieldName(t *testing.T) {
+	tests := map[string]string{
+		"foo":                   "FOO",
+		"Foo.Bar":               "FOO_BAR",
+		"_foo":                  "FOO",
+		"9lives":                "LIVES",
+		"__":                    "",
+		strings.Repeat("a", 80): strings.Repeat("A", maxJournalFieldLen),
+	}
+	for key, expect := range tests {
+		if actual := journalFieldName(key); actual != expect {
+			t.Errorf("%q 期望 %q, 实际 %q", key, expect, actual)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	hex = "0123456789abcdef"
+	// 初始缓冲区大小，覆盖绝大多数日志
+	defaultBufferSize = 1 << 10
+	// 超过该大小的缓冲区不放回池中，避免偶发的大日志长期占用内存
+	maxPooledBufferSize = 64 << 10
+	// 字段数不超过该值时使用线性扫描去重，避免分配 map，超过时使用 map 避免 O(n²) 的扫描
+	maxLinearDedupFields = 32
+)
+
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, defaultBufferSize)
+		return &buf
+	},
+}
+
+func getBuffer() *[]byte {
+	buf := bufferPool.Get().(*[]byte)
+	*buf = (*buf)[:0]
+	return buf
+}
+
+func putBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// writeJsonEntry 编码并写入一条 JSON 日志，字段顺序固定为 timestamp、level、content，然后是其他字段
+func writeJsonEntry(writer io.Writer, keys *systemKeys, level string, val any, fields []LogField) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	*buf = appendJsonEntry(*buf, keys, level, val, fields)
+	*buf = append(*buf, '\n')
+	if writer == nil {
+		log.Print(string(*buf))
+		return
+	}
+	if _, err := writer.Write(*buf); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+func appendJsonEntry(b []byte, keys *systemKeys, level string, val any, fields []LogField) []byte {
+	b = append(b, '{')
+	b = appendJsonKey(b, keys.timestamp)
+	b = appendTimestamp(b)
+	b = append(b, ',')
+	b = appendJsonKey(b, keys.level)
+	b = appendJsonString(b, level)
+	b = append(b, ',')
+	b = appendJsonKey(b, keys.content)
+	b = appendJsonValue(b, val)
+
+	// 同名字段只保留最后一个，与 map 覆盖的语义一致
+	for _, field := range dedupFields(fields) {
+		b = append(b, ',')
+		b = appendJsonKey(b, fieldKey(keys, field.Key))
+		b = appendJsonValue(b, maskField(field.Key, field.Value))
+	}
+
+	return append(b, '}')
+}
+
+// dedupFields 返回去掉同名字段后的字段，同名字段只保留最后一个，位置取最后一次出现的位置
+// 没有同名字段时直接返回原切片，不分配内存
+func dedupFields(fields []LogField) []LogField {
+	if len(fields) <= maxLinearDedupFields {
+		return dedupFieldsLinear(fields)
+	}
+
+	last := make(map[string]int, len(fields))
+	for i, field := range fields {
+		last[field.Key] = i
+	}
+	if len(last) == len(fields) {
+		return fields
+	}
+
+	deduped := make([]LogField, 0, len(last))
+	for i, field := range fields {
+		if last[field.Key] == i {
+			deduped = append(deduped, field)
+		}
+	}
+
+	return deduped
+}
+
+// dedupFieldsLinear 字段较少时线性扫描，避免分配 map
+func dedupFieldsLinear(fields []LogField) []LogField {
+	var deduped []LogField
+	for i, field := range fields {
+		if isOverridden(fields, i) {
+			if deduped == nil {
+				deduped = make([]LogField, i, len(fields)-1)
+				copy(deduped, fields[:i])
+			}
+			continue
+		}
+		if deduped != nil {
+			deduped = append(deduped, field)
+		}
+	}
+	if deduped == nil {
+		return fields
+	}
+
+	return deduped
+}
+
+// isOverridden 判断 fields[i] 是否被后面的同名字段覆盖
+func isOverridden(fields []LogField, i int) bool {
+	for j := i + 1; j < len(fields); j++ {
+		if fields[j].Key == fields[i].Key {
+			return true
+		}
+	}
+
+	return false
+}
+
+func appendJsonKey(b []byte, key string) []byte {
+	b = appendJsonString(b, key)
+	return append(b, ':')
+}
+
+// appendJsonValue 对常见类型走快速路径，编码结果与 processFieldValue + encoding/json 保持一致
+func appendJsonValue(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendJsonString(b, val)
+	case bool:
+		return strconv.AppendBool(b, val)
+	case int:
+		return strconv.AppendInt(b, int64(val), 10)
+	case int8:
+		return strconv.AppendInt(b, int64(val), 10)
+	case int16:
+		return strconv.AppendInt(b, int64(val), 10)
+	case int32:
+		return strconv.AppendInt(b, int64(val), 10)
+	case int64:
+		return strconv.AppendInt(b, val, 10)
+	case uint:
+		return strconv.AppendUint(b, uint64(val), 10)
+	case uint8:
+		return strconv.AppendUint(b, uint64(val), 10)
+	case uint16:
+		return strconv.AppendUint(b, uint64(val), 10)
+	case uint32:
+		return strconv.AppendUint(b, uint64(val), 10)
+	case uint64:
+		return strconv.AppendUint(b, val, 10)
+	case float32:
+		return appendJsonFloat(b, float64(val), 32)
+	case float64:
+		return appendJsonFloat(b, val, 64)
+	case time.Duration:
+		return appendJsonString(b, val.String())
+	case time.Time:
+		b = append(b, '"')
+		b = val.AppendFormat(b, time.RFC3339Nano)
+		return append(b, '"')
+	case error:
+		return appendJsonString(b, encodeError(val))
+	case json.Marshaler:
+		return appendJsonMarshal(b, val)
+	case fmt.Stringer:
+		return appendJsonString(b, encodeStringer(val))
+	case []string:
+		b = append(b, '[')
+		for i, s := range val {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendJsonString(b, s)
+		}
+		return append(b, ']')
+	default:
+		return appendJsonMarshal(b, processFieldValue(val))
+	}
+}
+
+// appendJsonFloat 与 encoding/json 的浮点数格式一致
+func appendJsonFloat(b []byte, f float64, bits int) []byte {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		// JSON 不支持 NaN 和 Inf，以字符串输出
+		return appendJsonString(b, strconv.FormatFloat(f, 'g', -1, bits))
+	}
+
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) ||
+			bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	b = strconv.AppendFloat(b, f, format, -1, bits)
+	if format == 'e' {
+		// e-09 -> e-9
+		n := len(b)
+		if n >= 4 && b[n-4] == 'e' && b[n-3] == '-' && b[n-2] == '0' {
+			b[n-2] = b[n-1]
+			b = b[:n-1]
+		}
+	}
+
+	return b
+}
+
+// appendJsonMarshal 其他类型回退到 encoding/json
+func appendJsonMarshal(b []byte, v any) []byte {
+	content, err := marshalJson(v)
+	if err != nil {
+		return appendJsonString(b, fmt.Sprintf("%+v", v))
+	}
+
+	return append(b, content...)
+}
+
+// appendJsonString 与 encoding/json 关闭 HTML 转义时的输出一致
+func appendJsonString(b []byte, s string) []byte {
+	b = append(b, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+
+			b = append(b, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				b = append(b, '\\', c)
+			case '\n':
+				b = append(b, '\\', 'n')
+			case '\r':
+				b = append(b, '\\', 'r')
+			case '\t':
+				b = append(b, '\\', 't')
+			default:
+				b = append(b, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b = append(b, s[start:i]...)
+			b = append(b, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 和 U+2029 在 JavaScript 中是换行符，encoding/json 总是转义
+		if r == '\u2028' || r == '\u2029' {
+			b = append(b, s[start:i]...)
+			b = append(b, '\\', 'u', '2', '0', '2', hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	b = append(b, s[start:]...)
+	return append(b, '"')
+}
@@ -0,0 +1,268 @@
+package logx
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+type jsonStringer struct{}
+
+func (jsonStringer) String() string {
+	return "stringer"
+}
+
+type jsonMarshaler struct{}
+
+func (jsonMarshaler) MarshalJSON() ([]byte, error) {
+	return []byte(`{"custom":true}`), nil
+}
+
+func TestAppendJsonString(t *testing.T) {
+	tests := []string{
+		"",
+		"hello",
+		"中文内容",
+		`quote " and backslash \`,
+		"tab\tnewline\nreturn\r",
+		"control \x00\x01\x1f",
+		"<html>&</html>",
+		"line separator \u2028 \u2029",
+		"invalid \xff utf8",
+	}
+
+	for _, test := range tests {
+		want, err := marshalJson(test)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appendJsonString(nil, test); string(got) != string(want) {
+			t.Errorf("appendJsonString(%q) = %s, want %s", test, got, want)
+		}
+	}
+}
+
+func TestAppendJsonValue(t *testing.T) {
+	tests := []any{
+		nil,
+		true,
+		1,
+		int8(-8),
+		int16(16),
+		int32(-32),
+		int64(math.MaxInt64),
+		uint(1),
+		uint8(8),
+		uint16(16),
+		uint32(32),
+		uint64(math.MaxUint64),
+		float32(1.5),
+		3.14,
+		1e-7,
+		1e21,
+		float32(1e-7),
+		0.0,
+		time.Second,
+		time.Date(2024, time.January, 2, 3, 4, 5, 6, time.UTC),
+		errors.New("oops"),
+		jsonStringer{},
+		jsonMarshaler{},
+		[]string{"a", "b"},
+		[]int{1, 2},
+		[]error{errors.New("a"), errors.New("b")},
+		[]time.Duration{time.Second},
+		map[string]any{"k": "v"},
+		struct{ Name string }{"bob"},
+	}
+
+	for _, test := range tests {
+		// 与原有的 processFieldValue + encoding/json 结果一致
+		want, err := marshalJson(processFieldValue(test))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appendJsonValue(nil, test); string(got) != string(want) {
+			t.Errorf("appendJsonValue(%#v) = %s, want %s", test, got, want)
+		}
+	}
+
+	t.Run("NaN", func(t *testing.T) {
+		if got := appendJsonValue(nil, math.NaN()); string(got) != `"NaN"` {
+			t.Errorf("NaN 应以字符串输出, 实际 %s", got)
+		}
+	})
+
+	t.Run("nil error", func(t *testing.T) {
+		var err *customError
+		if got := appendJsonValue(nil, error(err)); string(got) != `"<nil>"` {
+			t.Errorf("nil 指针的 error 应输出 <nil>, 实际 %s", got)
+		}
+	})
+}
+
+type customError struct{}
+
+func (e *customError) Error() string {
+	return fmt.Sprintf("%T", *e)
+}
+
+func TestAppendJsonEntry(t *testing.T) {
+	keys := defaultSystemKeys
+	fields := []LogField{
+		Field("b", 1),
+		Field("a", "x"),
+		Field("b", 2),
+		Field(keys.level, "fake"),
+		Field("password", maskedPassword("secret")),
+	}
+
+	got := string(appendJsonEntry(nil, keys, levelInfo, "hello", fields))
+
+	prefix := `{"@timestamp":"`
+	if !strings.HasPrefix(got, prefix) {
+		t.Fatalf("应以时间戳开头, 实际 %s", got)
+	}
+	suffix := `","level":"info","content":"hello","a":"x","b":2,"fields.level":"fake","password":"******"}`
+	if !strings.HasSuffix(got, suffix) {
+		t.Errorf("字段顺序或内容错误:\n实际 %s\n期望后缀 %s", got, suffix)
+	}
+
+	var entry map[string]any
+	if err := json.Unmarshal([]byte(got), &entry); err != nil {
+		t.Errorf("应输出合法的 JSON: %v", err)
+	}
+}
+
+func TestAppendJsonEntryManyFields(t *testing.T) {
+	fields := make([]LogField, 0, maxLinearDedupFields+2)
+	for i := 0; i <= maxLinearDedupFields; i++ {
+		fields = append(fields, Field(fmt.Sprintf("k%d", i), i))
+	}
+	fields = append(fields, Field("k0", "last"))
+
+	got := string(appendJsonEntry(nil, defaultSystemKeys, levelInfo, "many", fields))
+	if strings.Count(got, `"k0"`) != 1 || !strings.Contains(got, `"k0":"last"`) {
+		t.Errorf("字段较多时同名字段也应只保留最后一个: %s", got)
+	}
+}
+
+func TestDedupFields(t *testing.T) {
+	for _, n := range []int{4, maxLinearDedupFields + 4} {
+		fields := make([]LogField, 0, n+1)
+		for i := 0; i < n; i++ {
+			fields = append(fields, Field(fmt.Sprintf("k%d", i), i))
+		}
+		if got := dedupFields(fields); &got[0] != &fields[0] || len(got) != n {
+			t.Errorf("%d 个字段没有同名字段时应返回原切片", n)
+		}
+
+		fields = append(fields, Field("k1", "last"))
+		got := dedupFields(fields)
+		if len(got) != n || got[0].Key != "k0" || got[1].Key != "k2" || got[n-1].Value != "last" {
+			t.Errorf("%d 个字段时同名字段应只保留最后一个: %v", n, got)
+		}
+	}
+}
+
+func TestAppendJsonEntryAllocs(t *testing.T) {
+	fields := []LogField{
+		Field("int", 1),
+		Field("str", "value"),
+		Field("dur", time.Second),
+		Field("err", errors.New("oops")),
+	}
+	buf := make([]byte, 0, defaultBufferSize)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = appendJsonEntry(buf[:0], defaultSystemKeys, levelInfo, "hello", fields)
+	})
+	// time.Duration.String 会分配一次
+	if allocs > 1 {
+		t.Errorf("编码常见类型不应有额外分配, 实际 %v 次", allocs)
+	}
+}
+
+func benchmarkFields() []LogField {
+	return []LogField{
+		Field("int", 1),
+		Field("str", "value"),
+		Field("float", 3.14),
+		Field("bool", true),
+		Field("dur", time.Second),
+		Field("err", errors.New("oops")),
+		Field("time", time.Now()),
+	}
+}
+
+// legacyJsonOutput 原有的 map + encoding/json 编码方式，作为基准对比
+func legacyJsonOutput(writer io.Writer, level string, val any, fields ...LogField) {
+	keys := loadFieldKeys()
+	entry := make(logEntry, len(fields)+3)
+	for _, field := range fields {
+		entry[field.Key] = processFieldValue(maskSensitive(field.Value))
+	}
+	entry[keys.timestamp] = getTimestamp()
+	entry[keys.level] = level
+	entry[keys.content] = val
+	if content, err := marshalJson(entry); err == nil {
+		writer.Write(append(content, '\n'))
+	}
+}
+
+func BenchmarkJsonOutput(b *testing.B) {
+	fields := benchmarkFields()
+	keys := loadFieldKeys()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		writeJsonEntry(io.Discard, keys, levelInfo, "benchmark message", fields)
+	}
+}
+
+// BenchmarkOutput 经过 output 的完整路径，包括全局字段合并、内容处理和去重
+func BenchmarkOutput(b *testing.B) {
+	fields := benchmarkFields()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output(io.Discard, levelInfo, "benchmark message", fields...)
+	}
+}
+
+func BenchmarkOutputManyFields(b *testing.B) {
+	fields := make([]LogField, 0, 256)
+	for i := 0; i < cap(fields); i++ {
+		fields = append(fields, Field(fmt.Sprintf("k%d", i), i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		output(io.Discard, levelInfo, "benchmark message", fields...)
+	}
+}
+
+func BenchmarkJsonOutputLegacy(b *testing.B) {
+	fields := benchmarkFields()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		legacyJsonOutput(io.Discard, levelInfo, "benchmark message", fields...)
+	}
+}
+
+func BenchmarkJsonOutputParallel(b *testing.B) {
+	fields := benchmarkFields()
+	keys := loadFieldKeys()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			writeJsonEntry(io.Discard, keys, levelInfo, "benchmark message", fields)
+		}
+	})
+}
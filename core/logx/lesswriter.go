@@ -10,7 +10,7 @@ type lessWriter struct {
 // newLessWriter creates a new lessWriter that limits the write frequency to the specified milliseconds.
 func newLessWriter(writer io.Writer, milliseconds int) *lessWriter {
 	return &lessWriter{
-		limitedExecutor: newLimitedExecutor(milliseconds),
+		limitedExecutor: newLimitedExecutor(milliseconds, clock),
 		writer:          writer,
 	}
 }
@@ -0,0 +1,92 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+type (
+	// levelRequest 修改日志级别的请求，Package 为空时修改全局级别，
+	// Package 不为空且 Level 为空时删除该包的覆盖级别
+	levelRequest struct {
+		Level   string `json:"level"`
+		Package string `json:"package,omitempty"`
+	}
+
+	levelResponse struct {
+		Level    string            `json:"level"`
+		Packages map[string]string `json:"packages"`
+	}
+
+	levelHandler struct{}
+)
+
+// LevelHandler returns an http.Handler to view and change the logging levels at runtime.
+//
+//	GET                                              -> {"level":"info","packages":{}}
+//	PUT {"level":"debug"}                            -> change the global level
+//	PUT {"package":"github.com/a/b","level":"debug"} -> override the level of a package
+//	PUT {"package":"github.com/a/b"}                 -> remove the override of a package
+func LevelHandler() http.Handler {
+	return levelHandler{}
+}
+
+func (h levelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if err := h.change(r); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	resp := levelResponse{
+		Level:    levelName(atomic.LoadUint32(&logLevel)),
+		Packages: make(map[string]string),
+	}
+	for pkg, level := range PackageLevels() {
+		resp.Packages[pkg] = levelName(level)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (h levelHandler) change(r *http.Request) error {
+	var req levelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+
+	if len(req.Package) == 0 {
+		level, err := parseLevel(req.Level)
+		if err != nil {
+			return err
+		}
+
+		changeLevel(level, "http")
+		return nil
+	}
+
+	if len(req.Level) == 0 {
+		RemovePackageLevel(req.Package)
+		writeInfo(fmt.Sprintf("log level override of package %s removed by http", req.Package))
+		return nil
+	}
+
+	level, err := parseLevel(req.Level)
+	if err != nil {
+		return err
+	}
+
+	SetPackageLevel(req.Package, level)
+	writeInfo(fmt.Sprintf("log level of package %s changed to %s by http", req.Package, levelName(level)))
+	return nil
+}
@@ -0,0 +1,253 @@
+package logx
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/lang"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+var (
+	// 按包覆盖的日志级别（包路径 -> 级别），nil 表示没有覆盖
+	// 只在写时整体替换，读路径无锁
+	packageLevels     atomic.Pointer[map[string]uint32]
+	packageLevelsLock sync.Mutex
+	// pc -> 包路径 的缓存，避免每次都解析函数名
+	pcPackages sync.Map
+	// 信号触发的临时级别调整
+	levelShifter = new(levelReverter)
+)
+
+// levelReverter 临时调整全局日志级别，ttl 到期后自动恢复
+type levelReverter struct {
+	lock  sync.Mutex
+	timer timex.Timer
+	// 再次调整时通知等待上一个定时器的协程退出
+	stop    chan lang.PlaceholderType
+	active  bool
+	gen     uint64 // 每次调整递增，避免已过期的定时器恢复新的调整
+	base    uint32 // 调整前的级别
+	current uint32 // 调整后的级别
+}
+
+// PackageLevels returns a copy of the per-package level overrides.
+func PackageLevels() map[string]uint32 {
+	levels := packageLevels.Load()
+	if levels == nil {
+		return map[string]uint32{}
+	}
+
+	result := make(map[string]uint32, len(*levels))
+	for pkg, level := range *levels {
+		result[pkg] = level
+	}
+	return result
+}
+
+// RemovePackageLevel removes the level override of the given package.
+func RemovePackageLevel(pkg string) {
+	packageLevelsLock.Lock()
+	defer packageLevelsLock.Unlock()
+
+	levels := PackageLevels()
+	if _, ok := levels[pkg]; !ok {
+		return
+	}
+
+	delete(levels, pkg)
+	if len(levels) == 0 {
+		packageLevels.Store(nil)
+	} else {
+		packageLevels.Store(&levels)
+	}
+}
+
+// SetPackageLevel overrides the logging level of the given package and its sub packages,
+// pkg is the import path, like github.com/YunFy26/mini-zero/core/logc.
+func SetPackageLevel(pkg string, level uint32) {
+	packageLevelsLock.Lock()
+	defer packageLevelsLock.Unlock()
+
+	levels := PackageLevels()
+	levels[pkg] = level
+	packageLevels.Store(&levels)
+}
+
+// callerLevel 返回调用方所在包生效的日志级别，skip 为相对 callerLevel 的栈深度
+func callerLevel(skip int) uint32 {
+	levels := packageLevels.Load()
+	if levels == nil {
+		return atomic.LoadUint32(&logLevel)
+	}
+
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return atomic.LoadUint32(&logLevel)
+	}
+
+	return lookupPackageLevel(*levels, pcPackage(pc))
+}
+
+// pcLevel 返回 pc 所在包生效的日志级别
+func pcLevel(pc uintptr) uint32 {
+	levels := packageLevels.Load()
+	if levels == nil || pc == 0 {
+		return atomic.LoadUint32(&logLevel)
+	}
+
+	return lookupPackageLevel(*levels, pcPackage(pc))
+}
+
+// lookupPackageLevel 按路径逐级向上查找覆盖的级别，找不到时使用全局级别
+func lookupPackageLevel(levels map[string]uint32, pkg string) uint32 {
+	for len(pkg) > 0 {
+		if level, ok := levels[pkg]; ok {
+			return level
+		}
+
+		pos := strings.LastIndexByte(pkg, '/')
+		if pos < 0 {
+			break
+		}
+		pkg = pkg[:pos]
+	}
+
+	return atomic.LoadUint32(&logLevel)
+}
+
+// pcPackage 返回 pc 所在函数的包路径
+func pcPackage(pc uintptr) string {
+	if pkg, ok := pcPackages.Load(pc); ok {
+		return pkg.(string)
+	}
+
+	var pkg string
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		pkg = packageOfFunc(fn.Name())
+	}
+	pcPackages.Store(pc, pkg)
+
+	return pkg
+}
+
+// packageOfFunc 从函数全名中解析包路径
+// 如 github.com/foo/bar.(*T).Method -> github.com/foo/bar
+func packageOfFunc(name string) string {
+	slash := strings.LastIndexByte(name, '/')
+	if dot := strings.IndexByte(name[slash+1:], '.'); dot >= 0 {
+		return name[:slash+1+dot]
+	}
+
+	return name
+}
+
+// changeLevel 设置全局日志级别，级别有变化时输出一条 Info 日志
+func changeLevel(level uint32, reason string) {
+	if old := atomic.SwapUint32(&logLevel, level); old != level {
+		announceLevel(old, level, reason)
+	}
+}
+
+// announceLevel 输出级别变更日志，不受当前级别限制
+func announceLevel(from, to uint32, reason string) {
+	writeInfo(fmt.Sprintf("log level changed from %s to %s by %s", levelName(from), levelName(to), reason))
+}
+
+// shift 将全局级别调整 delta 级，ttl 到期后恢复到第一次调整前的级别
+// 多次调整会叠加，并重新计时
+func (r *levelReverter) shift(delta int, ttl time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	current := atomic.LoadUint32(&logLevel)
+	if current == disableLevel {
+		return
+	}
+
+	next := int(current) + delta
+	if next < int(DebugLevel) {
+		next = int(DebugLevel)
+	} else if next > int(SevereLevel) {
+		next = int(SevereLevel)
+	}
+
+	if !r.active {
+		r.active = true
+		r.base = current
+	}
+	r.current = uint32(next)
+	changeLevel(r.current, "signal")
+
+	if r.timer != nil {
+		r.timer.Stop()
+		close(r.stop)
+	}
+	r.gen++
+	gen := r.gen
+	// 使用 clock 的定时器，测试中可以通过 FakeClock 推进时间
+	timer := clock.NewTimer(ttl)
+	stop := make(chan lang.PlaceholderType)
+	r.timer = timer
+	r.stop = stop
+	go func() {
+		select {
+		case <-timer.Chan():
+			r.revert(gen)
+		case <-stop:
+		}
+	}()
+}
+
+// revert 恢复调整前的级别，期间级别被其他方式修改过则不恢复
+func (r *levelReverter) revert(gen uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.active || gen != r.gen {
+		return
+	}
+
+	r.active = false
+	if atomic.CompareAndSwapUint32(&logLevel, r.current, r.base) && r.current != r.base {
+		announceLevel(r.current, r.base, "expiration")
+	}
+}
+
+// levelName 返回日志级别的名称
+func levelName(level uint32) string {
+	switch level {
+	case DebugLevel:
+		return levelDebug
+	case InfoLevel:
+		return levelInfo
+	case ErrorLevel:
+		return levelError
+	case SevereLevel:
+		return levelSevere
+	case disableLevel:
+		return "disabled"
+	default:
+		return fmt.Sprintf("level(%d)", level)
+	}
+}
+
+// parseLevel 解析日志级别名称，与 LogConf.Level 的取值一致
+func parseLevel(name string) (uint32, error) {
+	switch strings.ToLower(name) {
+	case levelDebug:
+		return DebugLevel, nil
+	case levelInfo:
+		return InfoLevel, nil
+	case levelError:
+		return ErrorLevel, nil
+	case levelSevere:
+		return SevereLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
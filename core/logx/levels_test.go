@@ -0,0 +1,187 @@
+package logx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testPackage = "github.com/YunFy26/mini-zero/core/logx"
+
+func resetPackageLevels(t *testing.T) {
+	t.Cleanup(func() {
+		packageLevels.Store(nil)
+	})
+}
+
+func TestPackageOfFunc(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"github.com/foo/bar.Func", "github.com/foo/bar"},
+		{"github.com/foo/bar.(*T).Method", "github.com/foo/bar"},
+		{"github.com/foo/bar.Func.func1", "github.com/foo/bar"},
+		{"github.com/foo/bar.v2.Func", "github.com/foo/bar"},
+		{"main.main", "main"},
+		{"runtime", "runtime"},
+	}
+
+	for _, test := range tests {
+		if got := packageOfFunc(test.name); got != test.want {
+			t.Errorf("packageOfFunc(%q) 期望 %q，实际为 %q", test.name, test.want, got)
+		}
+	}
+}
+
+func TestPackageLevels(t *testing.T) {
+	captureEntry(t)
+	resetPackageLevels(t)
+	w := writer.Load().(*mockWriter)
+	SetLevel(ErrorLevel)
+
+	Info("global")
+	if w.Contains("global") {
+		t.Fatal("全局级别为 error 时不应该输出 info")
+	}
+
+	// 覆盖上级路径，对子包生效
+	SetPackageLevel("github.com/YunFy26/mini-zero", DebugLevel)
+	Info("parent")
+	WithContext(nil).Debugf("rich %s", "logger")
+	if !w.Contains("parent") || !w.Contains("rich logger") {
+		t.Errorf("上级包的覆盖级别应该对子包生效: %s", w.String())
+	}
+
+	// 更精确的路径优先
+	SetPackageLevel(testPackage, SevereLevel)
+	w.Reset()
+	Error("exact")
+	if w.Contains("exact") {
+		t.Errorf("应该使用最精确的覆盖级别: %s", w.String())
+	}
+
+	RemovePackageLevel(testPackage)
+	RemovePackageLevel("github.com/YunFy26/mini-zero")
+	if packageLevels.Load() != nil {
+		t.Error("删除全部覆盖后应该回到无覆盖的快速路径")
+	}
+	Error("removed")
+	if !w.Contains("removed") {
+		t.Errorf("删除覆盖后应该使用全局级别: %s", w.String())
+	}
+}
+
+func TestPackageLevelsCallerSkip(t *testing.T) {
+	captureEntry(t)
+	resetPackageLevels(t)
+	w := writer.Load().(*mockWriter)
+	SetLevel(ErrorLevel)
+	SetPackageLevel("net/http", DebugLevel)
+
+	// 包装层跳过后的调用方是 net/http 中的 HandlerFunc.ServeHTTP
+	l := WithContext(nil).WithCallerSkip(1)
+	handler := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		l.Info("wrapped")
+	})
+	handler.ServeHTTP(nil, nil)
+	if !w.Contains("wrapped") {
+		t.Errorf("应该按跳过包装层后的调用方判断级别: %s", w.String())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	captureEntry(t)
+	resetPackageLevels(t)
+	w := writer.Load().(*mockWriter)
+	SetLevel(InfoLevel)
+	handler := LevelHandler()
+
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(method, "/", strings.NewReader(body)))
+		return resp
+	}
+
+	resp := serve(http.MethodGet, "")
+	if resp.Code != http.StatusOK || strings.TrimSpace(resp.Body.String()) != `{"level":"info","packages":{}}` {
+		t.Errorf("GET 返回错误: %d %s", resp.Code, resp.Body.String())
+	}
+
+	resp = serve(http.MethodPut, `{"level":"debug"}`)
+	if resp.Code != http.StatusOK || atomic.LoadUint32(&logLevel) != DebugLevel {
+		t.Errorf("PUT 全局级别失败: %d %s", resp.Code, resp.Body.String())
+	}
+	if !w.Contains("log level changed from info to debug by http") {
+		t.Errorf("修改级别时应该输出 Info 日志: %s", w.String())
+	}
+
+	resp = serve(http.MethodPut, `{"package":"github.com/a/b","level":"error"}`)
+	if !strings.Contains(resp.Body.String(), `"packages":{"github.com/a/b":"error"}`) {
+		t.Errorf("PUT 包级别失败: %s", resp.Body.String())
+	}
+
+	resp = serve(http.MethodPut, `{"package":"github.com/a/b"}`)
+	if !strings.Contains(resp.Body.String(), `"packages":{}`) {
+		t.Errorf("删除包级别失败: %s", resp.Body.String())
+	}
+
+	if resp = serve(http.MethodPut, `{"level":"verbose"}`); resp.Code != http.StatusBadRequest {
+		t.Errorf("未知级别应该返回 400，实际为 %d", resp.Code)
+	}
+	if resp = serve(http.MethodPut, `{`); resp.Code != http.StatusBadRequest {
+		t.Errorf("非法请求体应该返回 400，实际为 %d", resp.Code)
+	}
+	if resp = serve(http.MethodPost, ""); resp.Code != http.StatusMethodNotAllowed {
+		t.Errorf("不支持的方法应该返回 405，实际为 %d", resp.Code)
+	}
+}
+
+func TestLevelShift(t *testing.T) {
+	captureEntry(t)
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	w := writer.Load().(*mockWriter)
+	SetLevel(InfoLevel)
+	r := new(levelReverter)
+	reverted := func() bool {
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		return !r.active
+	}
+
+	r.shift(-1, 20*time.Second)
+	fake.Advance(10 * time.Second)
+	// 再次调整时重新计时
+	r.shift(-1, 20*time.Second)
+	if level := atomic.LoadUint32(&logLevel); level != DebugLevel {
+		t.Fatalf("期望调整为 debug，实际为 %s", levelName(level))
+	}
+	if !w.Contains("log level changed from info to debug by signal") {
+		t.Errorf("调整级别时应该输出 Info 日志: %s", w.String())
+	}
+
+	fake.Advance(19 * time.Second)
+	if reverted() {
+		t.Fatal("ttl 未到期时不应该恢复")
+	}
+	fake.Advance(time.Second)
+	waitFor(t, reverted)
+	if !w.Contains("log level changed from debug to info by expiration") {
+		t.Fatalf("ttl 到期后应该恢复原级别并输出 Info 日志: %s", w.String())
+	}
+	if level := atomic.LoadUint32(&logLevel); level != InfoLevel {
+		t.Fatalf("期望恢复为 info，实际为 %s", levelName(level))
+	}
+
+	// 期间级别被显式修改时不恢复
+	r.shift(1, 10*time.Second)
+	SetLevel(SevereLevel)
+	fake.Advance(10 * time.Second)
+	waitFor(t, reverted)
+	if level := atomic.LoadUint32(&logLevel); level != SevereLevel {
+		t.Errorf("显式修改的级别不应该被恢复，实际为 %s", levelName(level))
+	}
+}
@@ -0,0 +1,34 @@
+//go:build linux || darwin
+
+package logx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var levelSignalsOnce sync.Once
+
+// EnableLevelSignals makes SIGUSR1 increase and SIGUSR2 decrease the verbosity by one level,
+// the level reverts to the original one after ttl. Only the first call takes effect.
+func EnableLevelSignals(ttl time.Duration) {
+	levelSignalsOnce.Do(func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+		go func() {
+			for sig := range signals {
+				switch sig {
+				case syscall.SIGUSR1:
+					// 级别越低输出越多
+					levelShifter.shift(-1, ttl)
+				case syscall.SIGUSR2:
+					levelShifter.shift(1, ttl)
+				}
+			}
+		}()
+	})
+}
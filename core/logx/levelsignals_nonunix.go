@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package logx
+
+import "time"
+
+// EnableLevelSignals is not supported on this platform.
+func EnableLevelSignals(_ time.Duration) {
+}
@@ -0,0 +1,36 @@
+//go:build linux || darwin
+
+package logx
+
+import (
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestEnableLevelSignals(t *testing.T) {
+	captureEntry(t)
+	w := writer.Load().(*mockWriter)
+	SetLevel(InfoLevel)
+	EnableLevelSignals(time.Hour)
+
+	// 等待变更日志输出完成，避免后台协程与后续用例并发写日志
+	waitLevel := func(level uint32, announce string) {
+		deadline := time.Now().Add(time.Second)
+		for !w.Contains(announce) {
+			if time.Now().After(deadline) {
+				t.Fatalf("没有输出级别变更日志: %s", w.String())
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if actual := atomic.LoadUint32(&logLevel); actual != level {
+			t.Fatalf("期望级别为 %s，实际为 %s", levelName(level), levelName(actual))
+		}
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(DebugLevel, "from info to debug by signal")
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitLevel(InfoLevel, "from debug to info by signal")
+}
@@ -12,14 +12,21 @@ import (
 // 控制日志输出频率
 type limitedExecutor struct {
 	threshold time.Duration
+	// 相对 start 的上次执行时间
 	lastTime  *syncx.AtomicDuration
 	discarded uint32 // 记录被丢弃的操作次数
+	clock     timex.Clock
+	start     time.Time
 }
 
-func newLimitedExecutor(milliseconds int) *limitedExecutor {
+func newLimitedExecutor(milliseconds int, clock timex.Clock) *limitedExecutor {
+	threshold := time.Duration(milliseconds) * time.Millisecond
 	return &limitedExecutor{
-		threshold: time.Duration(milliseconds) * time.Millisecond,
+		threshold: threshold,
 		lastTime:  syncx.NewAtomicDuration(),
+		clock:     clock,
+		// 起点提前 threshold，保证第一次调用不会被丢弃
+		start: clock.Now().Add(-threshold - 1),
 	}
 }
 
@@ -28,14 +35,14 @@ func (le *limitedExecutor) logOrDiscard(execute func()) {
 		execute()
 		return
 	}
-	now := timex.Now()
+	now := le.clock.Since(le.start)
 	if now-le.lastTime.Load() <= le.threshold {
 		atomic.AddUint32(&le.discarded, 1)
 	} else {
 		le.lastTime.Set(now)
 		discarded := atomic.SwapUint32(&le.discarded, 0)
 		if discarded > 0 {
-			// Errorf("Discarded %d error messages", discarded)
+			Errorf("Discarded %d error messages", discarded)
 		}
 		execute()
 	}
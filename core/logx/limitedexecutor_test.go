@@ -0,0 +1,61 @@
+package logx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimitedExecutor(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	le := newLimitedExecutor(100, clock)
+	var executed int
+	execute := func() {
+		executed++
+	}
+
+	le.logOrDiscard(execute)
+	if executed != 1 {
+		t.Fatal("第一次调用应执行")
+	}
+
+	fake.Advance(100 * time.Millisecond)
+	le.logOrDiscard(execute)
+	le.logOrDiscard(execute)
+	if executed != 1 {
+		t.Errorf("间隔内的调用应丢弃, 实际执行 %d 次", executed)
+	}
+
+	fake.Advance(time.Millisecond)
+	le.logOrDiscard(execute)
+	if executed != 2 {
+		t.Errorf("超过间隔后应执行, 实际执行 %d 次", executed)
+	}
+	if !w.Contains("Discarded 2 error messages") {
+		t.Errorf("应输出丢弃的条数: %s", w.String())
+	}
+}
+
+func TestLessWriter(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	w := new(mockWriter)
+	lw := newLessWriter(&w.builder, 1000)
+	// 丢弃的条数通过全局写入器输出
+	old := writer.Swap(new(mockWriter))
+	defer writer.Store(old)
+
+	for i := 0; i < 3; i++ {
+		if n, err := lw.Write([]byte("stack\n")); n != 6 || err != nil {
+			t.Fatalf("Write 返回错误: %d, %v", n, err)
+		}
+	}
+	fake.Advance(2 * time.Second)
+	lw.Write([]byte("stack\n"))
+
+	if content := w.builder.String(); content != "stack\nstack\n" {
+		t.Errorf("限流错误: %q", content)
+	}
+}
@@ -0,0 +1,106 @@
+package logx
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"time"
+	"unicode/utf8"
+)
+
+// writeLogfmt 以 logfmt 格式写入日志，如：
+//
+//	@timestamp=2024-01-02T15:04:05.000Z level=info content="user login" user=alice
+func writeLogfmt(writer io.Writer, keys *systemKeys, level string, val any, fields []LogField) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	*buf = appendLogfmtEntry(*buf, keys, level, val, fields)
+	*buf = append(*buf, '\n')
+	if writer == nil {
+		log.Print(string(*buf))
+		return
+	}
+	if _, err := writer.Write(*buf); err != nil {
+		log.Println("failed to write log:", err)
+	}
+}
+
+func appendLogfmtEntry(b []byte, keys *systemKeys, level string, val any, fields []LogField) []byte {
+	b = appendLogfmtKey(b, keys.timestamp)
+	b = appendLogfmtString(b, clock.Now().Format(timeFormat))
+	b = append(b, ' ')
+	b = appendLogfmtKey(b, keys.level)
+	b = appendLogfmtString(b, level)
+	b = append(b, ' ')
+	b = appendLogfmtKey(b, keys.content)
+	b = appendLogfmtValue(b, val)
+
+	for _, field := range dedupFields(fields) {
+		b = append(b, ' ')
+		b = appendLogfmtKey(b, fieldKey(keys, field.Key))
+		b = appendLogfmtValue(b, maskField(field.Key, field.Value))
+	}
+
+	return b
+}
+
+// appendLogfmtKey 键名中不允许出现空白、等号、引号和控制字符，替换为下划线
+func appendLogfmtKey(b []byte, key string) []byte {
+	if len(key) == 0 {
+		b = append(b, '_')
+	}
+	for _, r := range key {
+		if needsLogfmtQuote(r) {
+			b = append(b, '_')
+		} else {
+			b = utf8.AppendRune(b, r)
+		}
+	}
+
+	return append(b, '=')
+}
+
+// appendLogfmtValue 标量直接输出，结构化的值编码为 JSON 后作为字符串输出
+func appendLogfmtValue(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(b, "null"...)
+	case string:
+		return appendLogfmtString(b, val)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return appendJsonValue(b, val)
+	case time.Duration:
+		return appendLogfmtString(b, val.String())
+	case time.Time:
+		return appendLogfmtString(b, val.Format(time.RFC3339Nano))
+	case error:
+		return appendLogfmtString(b, encodeError(val))
+	case json.Marshaler:
+		return appendLogfmtString(b, string(appendJsonValue(nil, val)))
+	case fmt.Stringer:
+		return appendLogfmtString(b, encodeStringer(val))
+	default:
+		return appendLogfmtString(b, string(appendJsonValue(nil, val)))
+	}
+}
+
+// appendLogfmtString 包含空白、等号、引号或控制字符的值加引号，转义规则与 JSON 字符串一致
+func appendLogfmtString(b []byte, s string) []byte {
+	if len(s) == 0 {
+		return append(b, `""`...)
+	}
+
+	for _, r := range s {
+		if needsLogfmtQuote(r) {
+			return appendJsonString(b, s)
+		}
+	}
+
+	return append(b, s...)
+}
+
+func needsLogfmtQuote(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f
+}
@@ -0,0 +1,54 @@
+package logx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAppendLogfmtEntry(t *testing.T) {
+	fields := []LogField{
+		Field("user", "alice"),
+		Field("id", 42),
+		Field("ratio", 0.5),
+		Field("ok", true),
+		Field("space", "has space"),
+		Field("equal", "a=b"),
+		Field("quote", `say "hi"`),
+		Field("newline", "a\nb"),
+		Field("empty", ""),
+		Field("nil", nil),
+		Field("err", errors.New("oops")),
+		Field("dur", 1500*time.Millisecond),
+		Field("map", map[string]int{"a": 1}),
+		Field("bad key=", "v"),
+		Field("password", maskedPassword("secret")),
+		Field("id", 43),
+	}
+
+	got := string(appendLogfmtEntry(nil, defaultSystemKeys, levelInfo, "user login", fields))
+	want := ` level=info content="user login" user=alice ratio=0.5 ok=true space="has space" ` +
+		`equal="a=b" quote="say \"hi\"" newline="a\nb" empty="" nil=null err=oops dur=1.5s ` +
+		`map="{\"a\":1}" bad_key_=v password=****** id=43`
+	if !strings.HasPrefix(got, "@timestamp=") || !strings.HasSuffix(got, want) {
+		t.Errorf("logfmt 输出错误:\n实际 %s\n期望后缀 %s", got, want)
+	}
+}
+
+func TestLogfmtOutput(t *testing.T) {
+	resetGlobalFields(t)
+	old := atomic.SwapUint32(&encoding, logfmtEncodingType)
+	defer atomic.StoreUint32(&encoding, old)
+	oldLen := atomic.SwapUint32(&maxContentLength, 5)
+	defer atomic.StoreUint32(&maxContentLength, oldLen)
+
+	var buf bytes.Buffer
+	output(&buf, levelError, "message too long", Field("k", "v"))
+	got := buf.String()
+	if !strings.HasSuffix(got, " level=error content=messa k=v truncated=true\n") {
+		t.Errorf("logfmt 应复用截断逻辑, 实际 %s", got)
+	}
+}
@@ -12,6 +12,12 @@ type Logger interface {
 	Debugv(any)
 	Debugw(string, ...LogField)
 
+	Error(...any)
+	Errorf(string, ...any)
+	Errorfn(func() any)
+	Errorv(any)
+	Errorw(string, ...LogField)
+
 	Info(...any)
 	Infof(string, ...any)
 	Infofn(func() any)
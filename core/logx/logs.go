@@ -3,12 +3,17 @@ package logx
 import (
 	"fmt"
 	"io"
+	"log"
+	"os"
+	"path"
 	"reflect"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
-const callerDepth = 5
+// 0: writeXxx 1: logx 的导出函数 2: 调用方
+const callerDepth = 2
 
 var (
 	timeFormat              = "2006-01-02T15:04:05.000Z07:00"
@@ -17,6 +22,7 @@ var (
 	disableStat      uint32
 	logLevel         uint32
 	options          logOptions
+	serviceName      string
 	writer           = new(atomicWriter)
 	setupOnce        sync.Once
 )
@@ -40,45 +46,13 @@ type (
 		maxBackups            int
 		maxSize               int
 		rotationRule          string
+		syncPolicy            string
+		syncInterval          time.Duration
+		syncEvery             int
 	}
 )
 
 // ============================= Log Writer ===============================
-// type (
-// 	Writer interface {
-// 		Alert(v any)                     // 警告日志
-// 		Close() error                    // 关闭写入器
-// 		Debug(v any, fields ...LogField) // 调试日志
-// 		Error(v any, fields ...LogField) // 错误日志
-// 		Info(v any, fields ...LogField)  // 信息日志
-// 		Severe(v any)                    // 严重日志
-// 		Slow(v any, fields ...LogField)  // 慢查询日志
-// 		Stack(v any)                     // 堆栈日志
-// 		Stat(v any, fields ...LogField)  // 统计日志
-// 	}
-
-// 	// 原子写入
-// 	atomicWriter struct {
-// 		writer Writer
-// 		lock   sync.RWMutex
-// 	}
-// 	// 组合写入
-// 	comboWriter struct {
-// 		writers []Writer
-// 	}
-// 	// 具体的日志写入器实现
-// 	concreteWriter struct {
-// 		infoLog   io.WriteCloser
-// 		errorLog  io.WriteCloser
-// 		severeLog io.WriteCloser
-// 		slowLog   io.WriteCloser
-// 		statLog   io.WriteCloser
-// 		stackLog  io.Writer
-// 	}
-
-//	nopWriter struct{}
-//
-// )
 
 // AddWriter adds a log writer, supporting multiple writers writing simultaneously
 func AddWriter(w Writer) {
@@ -113,6 +87,477 @@ func SetWriter(w Writer) {
 	}
 }
 
+// SetUp sets up the logx.
+// If already set up, return nil.
+// Multiple services in one process may call SetUp, only the first call takes effect.
+func SetUp(c LogConf) (err error) {
+	setupOnce.Do(func() {
+		setupLogLevel(c)
+
+		if !c.Stat {
+			DisableStat()
+		}
+
+		serviceName = c.ServiceName
+		if len(c.TimeFormat) > 0 {
+			timeFormat = c.TimeFormat
+		}
+
+		if len(c.FileTimeFormat) > 0 {
+			fileTimeFormat = c.FileTimeFormat
+		}
+
+		setupFieldKeys(c.FieldKeys)
+		setupSampling(c.Sampling)
+		if err = setupLoggerLevels(c.LoggerLevels); err != nil {
+			return
+		}
+		if err = setupMasking(c.Masking); err != nil {
+			return
+		}
+		if err = setupCaller(c.Caller, c.Stack); err != nil {
+			return
+		}
+		if err = setupStatReport(c); err != nil {
+			return
+		}
+
+		atomic.StoreUint32(&maxContentLength, c.MaxContentLength)
+
+		switch c.Encoding {
+		case plainEncoding:
+			atomic.StoreUint32(&encoding, plainEncodingType)
+		case logfmtEncoding:
+			atomic.StoreUint32(&encoding, logfmtEncodingType)
+		case prettyEncoding:
+			atomic.StoreUint32(&encoding, prettyEncodingType)
+		default:
+			atomic.StoreUint32(&encoding, jsonEncodingType)
+		}
+
+		switch c.Mode {
+		case fileMode:
+			err = setupWithFiles(c)
+		case volumeMode:
+			err = setupWithVolume(c)
+		default:
+			setupWithConsole(c)
+		}
+	})
+
+	return
+}
+
+// MustSetup sets up logging with given config c. It exits on error.
+func MustSetup(c LogConf) {
+	Must(SetUp(c))
+}
+
+// Must checks if err is nil, otherwise logs the error at fatal level and exits like Fatal.
+// It panics instead of exiting if ExitOnFatal is false, because the caller can't go on.
+func Must(err error) {
+	if err == nil {
+		return
+	}
+
+	msg := fmt.Sprintf("%+v", err)
+	log.Print(msg)
+	fatal(msg, nil)
+
+	panic(msg)
+}
+
+// Close closes the logging.
+func Close() error {
+	// 先输出采样汇总，再关闭写入器
+	if s := activeSampler.Swap(nil); s != nil {
+		s.stop()
+	}
+	if r := activeStatReporter.Swap(nil); r != nil {
+		r.stop()
+	}
+
+	if w := writer.Swap(nil); w != nil {
+		return w.Close()
+	}
+
+	return nil
+}
+
+// Disable disables the logging.
+func Disable() {
+	atomic.StoreUint32(&logLevel, disableLevel)
+	writer.Store(nopWriter{})
+}
+
+// DisableStat disables the stat logs.
+func DisableStat() {
+	atomic.StoreUint32(&disableStat, 1)
+}
+
+// SetLevel sets the logging level. It can be used to suppress some logs.
+func SetLevel(level uint32) {
+	atomic.StoreUint32(&logLevel, level)
+}
+
+func setupLogLevel(c LogConf) {
+	switch c.Level {
+	case levelDebug:
+		SetLevel(DebugLevel)
+	case levelInfo:
+		SetLevel(InfoLevel)
+	case levelError:
+		SetLevel(ErrorLevel)
+	case levelSevere:
+		SetLevel(SevereLevel)
+	}
+}
+
+// setupFieldKeys 根据配置构建新的键名集合并原子替换，未配置的键使用默认值
+func setupFieldKeys(c fieldKeyConf) {
+	keys := *defaultSystemKeys
+	if len(c.CallerKey) > 0 {
+		keys.caller = c.CallerKey
+	}
+	if len(c.ContentKey) > 0 {
+		keys.content = c.ContentKey
+	}
+	if len(c.DurationKey) > 0 {
+		keys.duration = c.DurationKey
+	}
+	if len(c.FunctionKey) > 0 {
+		keys.function = c.FunctionKey
+	}
+	if len(c.LevelKey) > 0 {
+		keys.level = c.LevelKey
+	}
+	if len(c.LoggerKey) > 0 {
+		keys.logger = c.LoggerKey
+	}
+	if len(c.SpanKey) > 0 {
+		keys.span = c.SpanKey
+	}
+	if len(c.TimestampKey) > 0 {
+		keys.timestamp = c.TimestampKey
+	}
+	if len(c.TraceKey) > 0 {
+		keys.trace = c.TraceKey
+	}
+	if len(c.TruncatedKey) > 0 {
+		keys.truncated = c.TruncatedKey
+	}
+	fieldKeys.Store(&keys)
+}
+
+// loadFieldKeys returns the system keys currently in effect.
+func loadFieldKeys() *systemKeys {
+	if keys, ok := fieldKeys.Load().(*systemKeys); ok {
+		return keys
+	}
+
+	return defaultSystemKeys
+}
+
+func setupWithConsole(c LogConf) {
+	handleOptions([]LogOption{WithCoolDownMillis(c.StackCooldownMillis)})
+	SetWriter(newConsoleWriter())
+}
+
+func setupWithFiles(c LogConf) error {
+	w, err := newFileWriter(c)
+	if err != nil {
+		return err
+	}
+
+	SetWriter(w)
+	return nil
+}
+
+// setupWithVolume 在 k8s 中多个 pod 可能挂载同一个卷，日志路径中加入服务名和主机名加以区分
+func setupWithVolume(c LogConf) error {
+	if len(c.ServiceName) == 0 {
+		return ErrLogServiceNameNotSet
+	}
+
+	c.Path = path.Join(c.Path, c.ServiceName, hostname())
+	return setupWithFiles(c)
+}
+
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+
+	return name
+}
+
+// ============================= Log Functions ===============================
+
+// Alert alerts v in alert level, and the message is written to error log.
+func Alert(v string) {
+	getWriter().Alert(v)
+}
+
+// Debug writes v into access log.
+func Debug(v ...any) {
+	if shallLog(DebugLevel) {
+		if shallSample(levelDebug, sampleKeyOfArgs(v)) {
+			writeDebug(fmt.Sprint(v...))
+		}
+	}
+}
+
+// Debugf writes v with format into access log.
+func Debugf(format string, v ...any) {
+	if shallLog(DebugLevel) && shallSample(levelDebug, format) {
+		writeDebug(fmt.Sprintf(format, v...))
+	}
+}
+
+// Debugfn writes function result into access log if debug level enabled.
+// This is useful when the function is expensive to call and debug level disabled.
+func Debugfn(fn func() any) {
+	if shallLog(DebugLevel) {
+		if val := fn(); shallSample(levelDebug, sampleKeyOf(val)) {
+			writeDebug(val)
+		}
+	}
+}
+
+// Debugv writes v into access log with json content.
+func Debugv(v any) {
+	if shallLog(DebugLevel) && shallSample(levelDebug, sampleKeyOf(v)) {
+		writeDebug(v)
+	}
+}
+
+// Debugw writes msg along with fields into access log.
+func Debugw(msg string, fields ...LogField) {
+	if shallLog(DebugLevel) && shallSample(levelDebug, msg) {
+		writeDebug(msg, fields...)
+	}
+}
+
+// Error writes v into error log.
+func Error(v ...any) {
+	if shallLog(ErrorLevel) {
+		if shallSample(levelError, sampleKeyOfArgs(v)) {
+			writeError(fmt.Sprint(v...))
+		}
+	}
+}
+
+// Errorf writes v with format into error log.
+func Errorf(format string, v ...any) {
+	if shallLog(ErrorLevel) && shallSample(levelError, format) {
+		writeError(fmt.Errorf(format, v...).Error())
+	}
+}
+
+// Errorfn writes function result into error log.
+func Errorfn(fn func() any) {
+	if shallLog(ErrorLevel) {
+		if val := fn(); shallSample(levelError, sampleKeyOf(val)) {
+			writeError(val)
+		}
+	}
+}
+
+// ErrorStack writes v along with call stack into error log.
+func ErrorStack(v ...any) {
+	if shallLog(ErrorLevel) {
+		// there is newline in stack string
+		writeStack(fmt.Sprint(v...))
+	}
+}
+
+// ErrorStackf writes v along with call stack in format into error log.
+func ErrorStackf(format string, v ...any) {
+	if shallLog(ErrorLevel) {
+		// there is newline in stack string
+		writeStack(fmt.Sprintf(format, v...))
+	}
+}
+
+// Errorv writes v into error log with json content.
+// No call stack attached, because not elegant to pack the messages.
+func Errorv(v any) {
+	if shallLog(ErrorLevel) && shallSample(levelError, sampleKeyOf(v)) {
+		writeError(v)
+	}
+}
+
+// Errorw writes msg along with fields into error log.
+func Errorw(msg string, fields ...LogField) {
+	if shallLog(ErrorLevel) && shallSample(levelError, msg) {
+		writeError(msg, fields...)
+	}
+}
+
+// Info writes v into access log.
+func Info(v ...any) {
+	if shallLog(InfoLevel) {
+		if shallSample(levelInfo, sampleKeyOfArgs(v)) {
+			writeInfo(fmt.Sprint(v...))
+		}
+	}
+}
+
+// Infof writes v with format into access log.
+func Infof(format string, v ...any) {
+	if shallLog(InfoLevel) && shallSample(levelInfo, format) {
+		writeInfo(fmt.Sprintf(format, v...))
+	}
+}
+
+// Infofn writes function result into access log.
+func Infofn(fn func() any) {
+	if shallLog(InfoLevel) {
+		if val := fn(); shallSample(levelInfo, sampleKeyOf(val)) {
+			writeInfo(val)
+		}
+	}
+}
+
+// Infov writes v into access log with json content.
+func Infov(v any) {
+	if shallLog(InfoLevel) && shallSample(levelInfo, sampleKeyOf(v)) {
+		writeInfo(v)
+	}
+}
+
+// Infow writes msg along with fields into access log.
+func Infow(msg string, fields ...LogField) {
+	if shallLog(InfoLevel) && shallSample(levelInfo, msg) {
+		writeInfo(msg, fields...)
+	}
+}
+
+// Severe writes v into severe log.
+func Severe(v ...any) {
+	if shallLog(SevereLevel) {
+		writeSevere(fmt.Sprint(v...))
+	}
+}
+
+// Severef writes v with format into severe log.
+func Severef(format string, v ...any) {
+	if shallLog(SevereLevel) {
+		writeSevere(fmt.Sprintf(format, v...))
+	}
+}
+
+// Slow writes v into slow log.
+func Slow(v ...any) {
+	if shallLog(ErrorLevel) {
+		if shallSample(levelSlow, sampleKeyOfArgs(v)) {
+			writeSlow(fmt.Sprint(v...))
+		}
+	}
+}
+
+// Slowf writes v with format into slow log.
+func Slowf(format string, v ...any) {
+	if shallLog(ErrorLevel) && shallSample(levelSlow, format) {
+		writeSlow(fmt.Sprintf(format, v...))
+	}
+}
+
+// Slowfn writes function result into slow log.
+func Slowfn(fn func() any) {
+	if shallLog(ErrorLevel) {
+		if val := fn(); shallSample(levelSlow, sampleKeyOf(val)) {
+			writeSlow(val)
+		}
+	}
+}
+
+// Slowv writes v into slow log with json content.
+func Slowv(v any) {
+	if shallLog(ErrorLevel) && shallSample(levelSlow, sampleKeyOf(v)) {
+		writeSlow(v)
+	}
+}
+
+// Sloww writes msg along with fields into slow log.
+func Sloww(msg string, fields ...LogField) {
+	if shallLog(ErrorLevel) && shallSample(levelSlow, msg) {
+		writeSlow(msg, fields...)
+	}
+}
+
+// Stat writes v into stat log.
+func Stat(v ...any) {
+	if shallLogStat() && shallLog(InfoLevel) {
+		writeStat(fmt.Sprint(v...))
+	}
+}
+
+// Statf writes v with format into stat log.
+func Statf(format string, v ...any) {
+	if shallLogStat() && shallLog(InfoLevel) {
+		writeStat(fmt.Sprintf(format, v...))
+	}
+}
+
+func shallLog(level uint32) bool {
+	// 0: callerLevel 1: shallLog 2: logx 的导出函数 3: 调用方
+	return callerLevel(3) <= level
+}
+
+func shallLogStat() bool {
+	return atomic.LoadUint32(&disableStat) == 0
+}
+
+func writeDebug(val any, fields ...LogField) {
+	getWriter().Debug(val, withCaller(levelDebug, fields)...)
+}
+
+func writeError(val any, fields ...LogField) {
+	getWriter().Error(val, withCaller(levelError, fields)...)
+}
+
+func writeInfo(val any, fields ...LogField) {
+	getWriter().Info(val, withCaller(levelInfo, fields)...)
+}
+
+func writeSevere(msg string) {
+	getWriter().Severe(withStack(msg))
+}
+
+func writeSlow(val any, fields ...LogField) {
+	getWriter().Slow(val, withCaller(levelSlow, fields)...)
+}
+
+func writeStack(msg string) {
+	getWriter().Stack(withStack(msg))
+}
+
+func writeStat(msg string) {
+	getWriter().Stat(msg, withCaller(levelStat, nil)...)
+}
+
+// withCaller 为包级函数追加调用位置，与调用位置同名的字段加上前缀，避免被覆盖
+// 不修改传入的切片
+func withCaller(level string, fields []LogField) []LogField {
+	if !activeCaller.Load().enabled(level) {
+		return fields
+	}
+
+	keys := loadFieldKeys()
+	all := make([]LogField, 0, len(fields)+2)
+	all = append(all, fields...)
+	for i := range all {
+		if all[i].Key == keys.caller || all[i].Key == keys.function {
+			all[i].Key = reservedFieldPrefix + all[i].Key
+		}
+	}
+
+	// 0: withCaller 1: writeXxx 2: logx 的导出函数 3: 调用方
+	return appendCaller(keys, all, level, callerDepth+1)
+}
+
 func Field(key string, value any) LogField {
 	return LogField{
 		Key:   key,
@@ -152,15 +597,6 @@ func encodeWithRecover(arg any, fn func() string) (ret string) {
 
 // ============================= Log Options ===============================
 
-//	logOptions struct {
-//		gzipEnabled           bool
-//		logStackCooldownMills int
-//		keepDays              int
-//		maxBackups            int
-//		maxSize               int
-//		rotationRule          string
-//	}
-//
 // WithCoolDownMillis sets the cooldown milliseconds for logging stack traces
 func WithCoolDownMillis(millis int) LogOption {
 	return func(options *logOptions) {
@@ -175,6 +611,16 @@ func WithKeepDays(days int) LogOption {
 	}
 }
 
+// WithFileSync sets when to fsync the log files, policy is one of never, interval, every
+// and severe, interval and every are used by the interval and every policies.
+func WithFileSync(policy string, interval time.Duration, every int) LogOption {
+	return func(options *logOptions) {
+		options.syncPolicy = policy
+		options.syncInterval = interval
+		options.syncEvery = every
+	}
+}
+
 // WithGzip enables gzip compression for log files
 func WithGzip() LogOption {
 	return func(options *logOptions) {
@@ -210,7 +656,7 @@ func handleOptions(opts []LogOption) {
 	}
 }
 
-func createOutput(path string) (io.WriteCloser, error) {
+func createOutput(path string, opts ...RotateOption) (io.WriteCloser, error) {
 	if len(path) == 0 {
 		return nil, ErrLogPathNotSet
 	}
@@ -224,5 +670,25 @@ func createOutput(path string) (io.WriteCloser, error) {
 		rule = DefaultRotateRule(path, backupFileDelimiter, options.keepDays, options.gzipEnabled)
 	}
 
-	return NewLogger(path, rule, options.gzipEnabled)
+	return NewLogger(path, rule, options.gzipEnabled, opts...)
+}
+
+// fileSyncOptions 按落盘策略返回日志文件的选项，severe 表示是否为严重错误日志文件
+func fileSyncOptions(severe bool) []RotateOption {
+	switch options.syncPolicy {
+	case syncInterval:
+		if options.syncInterval > 0 {
+			return []RotateOption{WithSyncInterval(options.syncInterval)}
+		}
+	case syncEvery:
+		if options.syncEvery > 0 {
+			return []RotateOption{WithSyncEvery(options.syncEvery)}
+		}
+	case syncSevere:
+		if severe {
+			return []RotateOption{WithSyncEvery(1)}
+		}
+	}
+
+	return nil
 }
@@ -1,8 +1,11 @@
 package logx
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -33,6 +36,12 @@ func (mw *mockWriter) Error(v any, fields ...LogField) {
 	output(&mw.builder, levelError, v, fields...)
 }
 
+func (mw *mockWriter) Fatal(v any, fields ...LogField) {
+	mw.lock.Lock()
+	defer mw.lock.Unlock()
+	output(&mw.builder, levelFatal, v, fields...)
+}
+
 func (mw *mockWriter) Info(v any, fields ...LogField) {
 	mw.lock.Lock()
 	defer mw.lock.Unlock()
@@ -179,3 +188,264 @@ func TestField(t *testing.T) {
 		})
 	}
 }
+
+// resetSetup 重置 SetUp 修改的全局状态，使每个测试可以重新调用 SetUp
+func resetSetup(t *testing.T) {
+	oldLevel := atomic.LoadUint32(&logLevel)
+	oldEncoding := atomic.LoadUint32(&encoding)
+	oldStat := atomic.LoadUint32(&disableStat)
+	oldMaxLen := atomic.LoadUint32(&maxContentLength)
+	oldTimeFormat := timeFormat
+	oldOptions := options
+	oldServiceName := serviceName
+	oldKeys := loadFieldKeys()
+	oldMasker := activeMasker.Load()
+	oldCaller := activeCaller.Load()
+	setupOnce = sync.Once{}
+	t.Cleanup(func() {
+		if w := Reset(); w != nil {
+			w.Close()
+		}
+		setupOnce = sync.Once{}
+		atomic.StoreUint32(&logLevel, oldLevel)
+		atomic.StoreUint32(&encoding, oldEncoding)
+		atomic.StoreUint32(&disableStat, oldStat)
+		atomic.StoreUint32(&maxContentLength, oldMaxLen)
+		timeFormat = oldTimeFormat
+		options = oldOptions
+		serviceName = oldServiceName
+		fieldKeys.Store(oldKeys)
+		setupSampling(samplingConf{})
+		setupStatReport(LogConf{})
+		loggerLevels.Store(nil)
+		activeMasker.Store(oldMasker)
+		activeCaller.Store(oldCaller)
+	})
+}
+
+func TestSetUp(t *testing.T) {
+	t.Run("console", func(t *testing.T) {
+		resetSetup(t)
+		err := SetUp(LogConf{
+			Mode:             "console",
+			Encoding:         plainEncoding,
+			Level:            levelError,
+			TimeFormat:       "2006-01-02",
+			MaxContentLength: 10,
+			Stat:             false,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if atomic.LoadUint32(&logLevel) != ErrorLevel {
+			t.Errorf("日志级别应为 ErrorLevel")
+		}
+		if atomic.LoadUint32(&encoding) != plainEncodingType {
+			t.Errorf("编码应为 plain")
+		}
+		if atomic.LoadUint32(&maxContentLength) != 10 {
+			t.Errorf("MaxContentLength 应为 10")
+		}
+		if shallLogStat() {
+			t.Errorf("Stat 为 false 时应禁用统计日志")
+		}
+		if timeFormat != "2006-01-02" {
+			t.Errorf("时间格式未生效: %s", timeFormat)
+		}
+		if _, ok := writer.Load().(*concreteWriter); !ok {
+			t.Errorf("console 模式应设置 concreteWriter")
+		}
+	})
+
+	t.Run("只生效一次", func(t *testing.T) {
+		resetSetup(t)
+		if err := SetUp(LogConf{Level: levelDebug}); err != nil {
+			t.Fatal(err)
+		}
+		if err := SetUp(LogConf{Level: levelSevere}); err != nil {
+			t.Fatal(err)
+		}
+		if atomic.LoadUint32(&logLevel) != DebugLevel {
+			t.Errorf("后续 SetUp 调用应被忽略")
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		resetSetup(t)
+		dir := t.TempDir()
+		if err := SetUp(LogConf{Mode: fileMode, Path: dir, Level: levelInfo, Stat: true}); err != nil {
+			t.Fatal(err)
+		}
+		Info("hello file")
+		Error("oops")
+		if err := Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, name := range []string{accessFilename, errorFilename, severeFilename, slowFilename, statFilename} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+				t.Errorf("应创建日志文件 %s: %v", name, err)
+			}
+		}
+		access, _ := os.ReadFile(filepath.Join(dir, accessFilename))
+		if !strings.Contains(string(access), "hello file") {
+			t.Errorf("access.log 内容错误: %s", access)
+		}
+		errLog, _ := os.ReadFile(filepath.Join(dir, errorFilename))
+		if !strings.Contains(string(errLog), "oops") {
+			t.Errorf("error.log 内容错误: %s", errLog)
+		}
+	})
+
+	t.Run("file 未设置路径", func(t *testing.T) {
+		resetSetup(t)
+		if err := SetUp(LogConf{Mode: fileMode}); !errors.Is(err, ErrLogPathNotSet) {
+			t.Errorf("期望 ErrLogPathNotSet, 实际: %v", err)
+		}
+	})
+
+	t.Run("volume", func(t *testing.T) {
+		resetSetup(t)
+		dir := t.TempDir()
+		if err := SetUp(LogConf{Mode: volumeMode, Path: dir, ServiceName: "svc"}); err != nil {
+			t.Fatal(err)
+		}
+		Close()
+		if _, err := os.Stat(filepath.Join(dir, "svc", hostname(), accessFilename)); err != nil {
+			t.Errorf("volume 模式应在路径中加入服务名和主机名: %v", err)
+		}
+	})
+
+	t.Run("volume 未设置服务名", func(t *testing.T) {
+		resetSetup(t)
+		if err := SetUp(LogConf{Mode: volumeMode, Path: t.TempDir()}); !errors.Is(err, ErrLogServiceNameNotSet) {
+			t.Errorf("期望 ErrLogServiceNameNotSet, 实际: %v", err)
+		}
+	})
+}
+
+func TestSetLevel(t *testing.T) {
+	originalLevel := atomic.LoadUint32(&logLevel)
+	defer atomic.StoreUint32(&logLevel, originalLevel)
+
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	SetLevel(ErrorLevel)
+	Info("info message")
+	Debug("debug message")
+	Error("error message")
+	if w.Contains("info message") || w.Contains("debug message") {
+		t.Errorf("低于 ErrorLevel 的日志不应输出: %s", w.String())
+	}
+	if !w.Contains("error message") {
+		t.Errorf("ErrorLevel 日志应输出: %s", w.String())
+	}
+}
+
+func TestMust(t *testing.T) {
+	ExitOnFatal.Set(false)
+	defer ExitOnFatal.Set(true)
+
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	Must(nil)
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("ExitOnFatal 为 false 时 Must 应 panic")
+		}
+		if !w.Contains("must error") || !w.Contains(`"level":"fatal"`) {
+			t.Errorf("Must 应记录 fatal 日志: %s", w.String())
+		}
+	}()
+	Must(errors.New("must error"))
+}
+
+func TestSetUpFieldKeys(t *testing.T) {
+	resetSetup(t)
+	err := SetUp(LogConf{
+		MaxContentLength: 3,
+		FieldKeys: fieldKeyConf{
+			ContentKey:   "message",
+			LevelKey:     "severity",
+			TimestampKey: "time",
+			CallerKey:    "source",
+			TruncatedKey: "cut",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := captureEntry(t)
+	WithContext(context.Background()).Info("hello")
+	entry := last()
+	for _, key := range []string{"message", "severity", "time", "source", "cut"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("应使用配置的键名 %s, 实际 %v", key, entry)
+		}
+	}
+	for _, key := range []string{defaultContentKey, defaultLevelKey, defaultTimestampKey, defaultCallerKey, defaultTruncatedKey} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("不应再使用默认键名 %s, 实际 %v", key, entry)
+		}
+	}
+	if entry["message"] != "hel" {
+		t.Errorf("内容应被截断, 实际 %v", entry["message"])
+	}
+
+	// 未配置的键保持默认值
+	if keys := loadFieldKeys(); keys.trace != defaultTraceKey || keys.span != defaultSpanKey {
+		t.Errorf("未配置的键名应保持默认值, 实际 %+v", keys)
+	}
+}
+
+func TestSetupFieldKeysConcurrently(t *testing.T) {
+	resetSetup(t)
+	originalLevel := atomic.LoadUint32(&logLevel)
+	defer atomic.StoreUint32(&logLevel, originalLevel)
+	SetLevel(InfoLevel)
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				Infow("concurrent", Field("j", j))
+			}
+		}()
+	}
+	for i := 0; i < 100; i++ {
+		setupFieldKeys(fieldKeyConf{LevelKey: fmt.Sprintf("level%d", i)})
+	}
+	wg.Wait()
+}
+
+func TestSetUpEncoding(t *testing.T) {
+	tests := map[string]uint32{
+		"":             jsonEncodingType,
+		"json":         jsonEncodingType,
+		plainEncoding:  plainEncodingType,
+		logfmtEncoding: logfmtEncodingType,
+		prettyEncoding: prettyEncodingType,
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			resetSetup(t)
+			if err := SetUp(LogConf{Encoding: name}); err != nil {
+				t.Fatal(err)
+			}
+			if got := atomic.LoadUint32(&encoding); got != want {
+				t.Errorf("编码 %q 期望 %d, 实际 %d", name, want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package logtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+const (
+	// 与 logx 输出的级别保持一致，Stack 按 error 记录
+	LevelAlert  = "alert"
+	LevelDebug  = "debug"
+	LevelError  = "error"
+	LevelFatal  = "fatal"
+	LevelInfo   = "info"
+	LevelSevere = "severe"
+	LevelSlow   = "slow"
+	LevelStat   = "stat"
+
+	// UpdateGoldenEnv is the environment variable to set for rewriting the golden files
+	// instead of comparing with them, like UPDATE_GOLDEN=1 go test ./...
+	UpdateGoldenEnv = "UPDATE_GOLDEN"
+
+	timestampKey = "@timestamp"
+	levelKey     = "level"
+	contentKey   = "content"
+	timeFormat   = "2006-01-02T15:04:05.000Z07:00"
+)
+
+type (
+	// Entry is a log entry captured by Writer.
+	Entry struct {
+		Time    time.Time
+		Level   string
+		Content any
+		Fields  []logx.LogField
+	}
+
+	// Option customizes a Writer.
+	Option func(w *Writer)
+
+	// Writer is a concurrency-safe logx.Writer that records the entries in memory.
+	// The global fields are merged by the writers of logx, so they are not captured.
+	Writer struct {
+		lock    sync.Mutex
+		entries []Entry
+		now     func() time.Time
+		ignored map[string]struct{}
+	}
+)
+
+// Field returns the value of the field with the given key, the last one wins if the key
+// appears more than once.
+func (e Entry) Field(key string) (any, bool) {
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if e.Fields[i].Key == key {
+			return e.Fields[i].Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Message returns the content as a string.
+func (e Entry) Message() string {
+	if s, ok := e.Content.(string); ok {
+		return s
+	}
+
+	return fmt.Sprint(e.Content)
+}
+
+// NewWriter returns a Writer.
+func NewWriter(opts ...Option) *Writer {
+	w := &Writer{
+		now:     time.Now,
+		ignored: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Install installs a new Writer as the logx writer for t, the previous writer is restored
+// when t finishes. Tests using Install can't run in parallel, because the writer is global.
+func Install(t testing.TB, opts ...Option) *Writer {
+	t.Helper()
+
+	w := NewWriter(opts...)
+	old := logx.Reset()
+	logx.SetWriter(w)
+	t.Cleanup(func() {
+		logx.Reset()
+		if old != nil {
+			logx.SetWriter(old)
+		}
+	})
+
+	return w
+}
+
+// WithClock makes the entries timestamped by clock, like a timex.FakeClock shared with
+// the code under test.
+func WithClock(clock timex.Clock) Option {
+	return func(w *Writer) {
+		w.now = clock.Now
+	}
+}
+
+// WithFixedTime makes the entries timestamped from start and step forward by step,
+// so that the rendered output is deterministic for golden files.
+func WithFixedTime(start time.Time, step time.Duration) Option {
+	return func(w *Writer) {
+		next := start
+		w.now = func() time.Time {
+			now := next
+			next = next.Add(step)
+			return now
+		}
+	}
+}
+
+// WithIgnoredFields omits the fields with the given keys from the rendered output,
+// like caller, which changes as the code changes.
+func WithIgnoredFields(keys ...string) Option {
+	return func(w *Writer) {
+		for _, key := range keys {
+			w.ignored[key] = struct{}{}
+		}
+	}
+}
+
+func (w *Writer) Alert(v any) {
+	w.record(LevelAlert, v, nil)
+}
+
+// AssertGolden compares the rendered output with the golden file, or rewrites the file
+// if the UpdateGoldenEnv environment variable is set.
+func (w *Writer) AssertGolden(t testing.TB, file string) {
+	t.Helper()
+
+	actual := w.Render()
+	if len(os.Getenv(UpdateGoldenEnv)) > 0 {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(actual), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	expect, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("failed to read golden file, set %s=1 to create it: %v", UpdateGoldenEnv, err)
+	}
+	if actual != string(expect) {
+		t.Errorf("output mismatches golden file %s\n--- expect\n%s--- actual\n%s", file, expect, actual)
+	}
+}
+
+func (w *Writer) Close() error {
+	return nil
+}
+
+// ContainsEntry reports whether there is an entry at the given level, with the content
+// containing the given text, and with all the given fields. An empty level matches any level.
+// The test fails if there is no such entry.
+func (w *Writer) ContainsEntry(t testing.TB, level, content string, fields ...logx.LogField) bool {
+	t.Helper()
+
+	for _, entry := range w.Entries() {
+		if matchEntry(entry, level, content, fields) {
+			return true
+		}
+	}
+
+	t.Errorf("no entry matches level: %q, content: %q, fields: %v\n%s", level, content, fields, w.Render())
+	return false
+}
+
+func (w *Writer) Debug(v any, fields ...logx.LogField) {
+	w.record(LevelDebug, v, fields)
+}
+
+// Entries returns a copy of the captured entries.
+func (w *Writer) Entries() []Entry {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return append([]Entry(nil), w.entries...)
+}
+
+func (w *Writer) Error(v any, fields ...logx.LogField) {
+	w.record(LevelError, v, fields)
+}
+
+func (w *Writer) Fatal(v any, fields ...logx.LogField) {
+	w.record(LevelFatal, v, fields)
+}
+
+func (w *Writer) Info(v any, fields ...logx.LogField) {
+	w.record(LevelInfo, v, fields)
+}
+
+// Len returns the number of the captured entries.
+func (w *Writer) Len() int {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return len(w.entries)
+}
+
+// NoErrors fails the test if there are entries at error level or above.
+func (w *Writer) NoErrors(t testing.TB) bool {
+	t.Helper()
+
+	var errs []string
+	for _, entry := range w.Entries() {
+		switch entry.Level {
+		case LevelAlert, LevelError, LevelFatal, LevelSevere:
+			errs = append(errs, renderEntry(entry, nil))
+		}
+	}
+	if len(errs) == 0 {
+		return true
+	}
+
+	t.Errorf("unexpected %d error entries:\n%s", len(errs), strings.Join(errs, ""))
+	return false
+}
+
+// Render renders the entries as JSON lines, one entry per line, the fields are in the
+// order they are written.
+func (w *Writer) Render() string {
+	var builder strings.Builder
+	for _, entry := range w.Entries() {
+		builder.WriteString(renderEntry(entry, w.ignored))
+	}
+
+	return builder.String()
+}
+
+// Reset drops the captured entries.
+func (w *Writer) Reset() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.entries = nil
+}
+
+func (w *Writer) Severe(v any) {
+	w.record(LevelSevere, v, nil)
+}
+
+func (w *Writer) Slow(v any, fields ...logx.LogField) {
+	w.record(LevelSlow, v, fields)
+}
+
+func (w *Writer) Stack(v any) {
+	w.record(LevelError, v, nil)
+}
+
+func (w *Writer) Stat(v any, fields ...logx.LogField) {
+	w.record(LevelStat, v, fields)
+}
+
+func (w *Writer) record(level string, v any, fields []logx.LogField) {
+	entry := Entry{
+		Level:   level,
+		Content: v,
+	}
+	// 调用方可能复用 fields 切片，需要复制
+	if len(fields) > 0 {
+		entry.Fields = append([]logx.LogField(nil), fields...)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	entry.Time = w.now()
+	w.entries = append(w.entries, entry)
+}
+
+func matchEntry(entry Entry, level, content string, fields []logx.LogField) bool {
+	if len(level) > 0 && entry.Level != level {
+		return false
+	}
+	if !strings.Contains(entry.Message(), content) {
+		return false
+	}
+
+	for _, field := range fields {
+		value, ok := entry.Field(field.Key)
+		if !ok || !reflect.DeepEqual(value, field.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// renderEntry 将一条日志编码为一行 JSON，系统字段在前，其他字段按写入顺序输出
+func renderEntry(entry Entry, ignored map[string]struct{}) string {
+	var buf bytes.Buffer
+	buf.WriteString(`{"` + timestampKey + `":`)
+	writeJsonValue(&buf, entry.Time.Format(timeFormat))
+	buf.WriteString(`,"` + levelKey + `":`)
+	writeJsonValue(&buf, entry.Level)
+	buf.WriteString(`,"` + contentKey + `":`)
+	writeJsonValue(&buf, entry.Content)
+	for _, field := range entry.Fields {
+		if _, ok := ignored[field.Key]; ok {
+			continue
+		}
+
+		buf.WriteByte(',')
+		writeJsonValue(&buf, field.Key)
+		buf.WriteByte(':')
+		writeJsonValue(&buf, field.Value)
+	}
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+// writeJsonValue 错误和 Stringer 按字符串输出，与 logx 一致
+func writeJsonValue(buf *bytes.Buffer, v any) {
+	switch val := v.(type) {
+	case error:
+		v = val.Error()
+	case time.Duration:
+		v = val.String()
+	case time.Time:
+		v = val.Format(timeFormat)
+	case json.Marshaler:
+	case fmt.Stringer:
+		v = val.String()
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprintf("%+v", v))
+	}
+	buf.Write(data)
+}
@@ -0,0 +1,188 @@
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+// fakeT 记录断言失败，不让外层测试失败
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) Helper() {}
+
+func TestInstall(t *testing.T) {
+	var w *Writer
+	t.Run("install", func(t *testing.T) {
+		w = Install(t)
+		logx.Infow("hello", logx.Field("count", 3))
+		logx.Errorf("failed: %s", "boom")
+
+		entries := w.Entries()
+		if len(entries) != 2 {
+			t.Fatalf("期望 2 条日志, 实际 %d", len(entries))
+		}
+		if entries[0].Level != LevelInfo || entries[0].Message() != "hello" {
+			t.Errorf("日志错误: %+v", entries[0])
+		}
+		if v, ok := entries[0].Field("count"); !ok || v != 3 {
+			t.Errorf("字段错误: %v", entries[0].Fields)
+		}
+		if _, ok := entries[0].Field("caller"); !ok {
+			t.Errorf("应包含调用位置: %v", entries[0].Fields)
+		}
+		w.ContainsEntry(t, LevelError, "boom")
+	})
+
+	// 测试结束后恢复原来的写入器
+	logx.Info("after")
+	if w.Len() != 2 {
+		t.Errorf("测试结束后不应继续写入: %d", w.Len())
+	}
+}
+
+func TestContainsEntry(t *testing.T) {
+	w := NewWriter()
+	w.Info("user login", logx.Field("user", "foo"), logx.Field("count", 2))
+	w.Slow(map[string]any{"sql": "select"})
+
+	tests := []struct {
+		name   string
+		level  string
+		text   string
+		fields []logx.LogField
+		expect bool
+	}{
+		{"content", LevelInfo, "login", nil, true},
+		{"any level", "", "select", nil, true},
+		{"fields", LevelInfo, "", []logx.LogField{logx.Field("user", "foo"), logx.Field("count", 2)}, true},
+		{"wrong level", LevelError, "login", nil, false},
+		{"wrong field value", LevelInfo, "", []logx.LogField{logx.Field("count", int64(2))}, false},
+		{"missing field", LevelInfo, "", []logx.LogField{logx.Field("trace", "x")}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ft := &fakeT{TB: t}
+			if actual := w.ContainsEntry(ft, test.level, test.text, test.fields...); actual != test.expect {
+				t.Errorf("期望 %v, 实际 %v", test.expect, actual)
+			}
+			if test.expect == (len(ft.errors) > 0) {
+				t.Errorf("失败信息错误: %v", ft.errors)
+			}
+		})
+	}
+}
+
+func TestNoErrors(t *testing.T) {
+	w := NewWriter()
+	w.Info("foo")
+	w.Stat("bar")
+	if !w.NoErrors(t) {
+		t.Error("没有错误日志时应通过")
+	}
+
+	w.Stack("stack")
+	w.Severe("severe")
+	ft := &fakeT{TB: t}
+	if w.NoErrors(ft) || len(ft.errors) != 1 || !strings.Contains(ft.errors[0], "2 error entries") {
+		t.Errorf("有错误日志时应失败: %v", ft.errors)
+	}
+
+	w.Reset()
+	if w.Len() != 0 || !w.NoErrors(t) {
+		t.Error("Reset 后应清空")
+	}
+}
+
+func TestWriterConcurrent(t *testing.T) {
+	w := NewWriter()
+	fields := []logx.LogField{logx.Field("n", 0)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				w.Info("foo", fields...)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 记录时复制了字段，修改原切片不影响已记录的日志
+	fields[0].Value = 1
+	if w.Len() != 1000 {
+		t.Errorf("期望 1000 条, 实际 %d", w.Len())
+	}
+	if v, _ := w.Entries()[0].Field("n"); v != 0 {
+		t.Errorf("字段不应被修改: %v", v)
+	}
+}
+
+func TestAssertGolden(t *testing.T) {
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	w := NewWriter(WithFixedTime(start, time.Second), WithIgnoredFields("caller"))
+	w.Info("hello", logx.Field("caller", "foo.go:1"), logx.Field("duration", time.Millisecond))
+	w.Error(errors.New("boom"), logx.Field("user", map[string]any{"name": "foo", "age": 3}))
+	w.Fatal("exit")
+
+	w.AssertGolden(t, filepath.Join("testdata", "golden.log"))
+
+	// 与 golden 文件不一致时失败
+	w.Stat("extra")
+	ft := &fakeT{TB: t}
+	w.AssertGolden(ft, filepath.Join("testdata", "golden.log"))
+	if len(ft.errors) != 1 || !strings.Contains(ft.errors[0], `"content":"extra"`) {
+		t.Errorf("不一致时应失败: %v", ft.errors)
+	}
+}
+
+func TestAssertGoldenUpdate(t *testing.T) {
+	t.Setenv(UpdateGoldenEnv, "1")
+	file := filepath.Join(t.TempDir(), "sub", "golden.log")
+
+	w := NewWriter(WithFixedTime(time.Unix(0, 0).UTC(), time.Millisecond))
+	w.Info("foo")
+	w.Info("bar")
+	w.AssertGolden(t, file)
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `{"@timestamp":"1970-01-01T00:00:00.000Z","level":"info","content":"foo"}
+{"@timestamp":"1970-01-01T00:00:00.001Z","level":"info","content":"bar"}
+`
+	if string(content) != expect {
+		t.Errorf("golden 文件内容错误: %s", content)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	clock := timex.NewFakeClock(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC))
+	w := NewWriter(WithClock(clock))
+	w.Info("foo")
+	clock.Advance(time.Minute)
+	w.Info("bar")
+
+	entries := w.Entries()
+	if !entries[0].Time.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) || entries[1].Time.Sub(entries[0].Time) != time.Minute {
+		t.Errorf("时间错误: %v, %v", entries[0].Time, entries[1].Time)
+	}
+}
@@ -0,0 +1,532 @@
+package logx
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultMaskReplacement = "******"
+	// 结构体标签 logx:"sensitive" 标记需要脱敏的字段
+	maskTagName  = "logx"
+	maskTagValue = "sensitive"
+	// 限制嵌套深度，循环引用由 maskSeen 处理
+	maxMaskDepth = 32
+)
+
+var (
+	// 当前生效的脱敏引擎，未配置时只处理 Sensitive 和结构体标签
+	activeMasker atomic.Pointer[masker]
+
+	sensitiveType = reflect.TypeOf((*Sensitive)(nil)).Elem()
+	// 类型是否可能包含 Sensitive 或带脱敏标签的字段，按类型缓存
+	taggedTypes sync.Map
+
+	// 内置规则的匹配顺序，jwt 中可能包含类似邮箱的片段，国际号码可能通过银行卡号校验，
+	// 因此 jwt 最先匹配，银行卡号最后匹配
+	builtinMaskRuleOrder = []string{"jwt", "email", "phone", "credit_card"}
+
+	// 内置的内容脱敏规则，对应 LogConf.Masking.Rules
+	builtinMaskRules = map[string]maskPattern{
+		"credit_card": {
+			re:       regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			validate: luhnValid,
+		},
+		"email": {
+			re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		},
+		"jwt": {
+			re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		},
+		"phone": {
+			// 中国大陆手机号，或 E.164 格式的国际号码
+			re: regexp.MustCompile(`(?:\+\d{1,3}[ -]?)?\b1[3-9]\d{9}\b|\+\d{8,15}\b`),
+		},
+	}
+)
+
+func init() {
+	activeMasker.Store(&masker{
+		replacement: defaultMaskReplacement,
+	})
+}
+
+type (
+	// masker 按字段名、内容正则和结构体标签对日志字段脱敏
+	// 只在有内容需要脱敏时才复制，原值不会被修改
+	masker struct {
+		keys        []string
+		patterns    []maskPattern
+		replacement string
+	}
+
+	maskPattern struct {
+		re *regexp.Regexp
+		// validate 用于排除误匹配，返回 false 时不替换
+		validate func(string) bool
+	}
+
+	// maskSeen 记录已处理的指针、map 和切片，共享的值只处理一次，循环引用时返回原值
+	maskSeen map[maskRef]*maskResult
+
+	maskRef struct {
+		typ reflect.Type
+		ptr uintptr
+		len int
+	}
+
+	maskResult struct {
+		value reflect.Value
+		ok    bool
+		done  bool
+	}
+)
+
+func newMasker(c maskingConf) (*masker, error) {
+	m := &masker{
+		keys:        c.Keys,
+		replacement: c.Replacement,
+	}
+	if len(m.replacement) == 0 {
+		m.replacement = defaultMaskReplacement
+	}
+
+	rules := make(map[string]bool, len(c.Rules))
+	for _, rule := range c.Rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if _, ok := builtinMaskRules[rule]; !ok {
+			return nil, fmt.Errorf("unknown masking rule %q", rule)
+		}
+		rules[rule] = true
+	}
+	for _, name := range builtinMaskRuleOrder {
+		if rules[name] {
+			m.patterns = append(m.patterns, builtinMaskRules[name])
+		}
+	}
+
+	for _, expr := range c.Patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid masking pattern %q: %w", expr, err)
+		}
+		m.patterns = append(m.patterns, maskPattern{re: re})
+	}
+
+	return m, nil
+}
+
+// maskField 对字段值脱敏，key 为字段名
+func maskField(key string, value any) any {
+	return activeMasker.Load().mask(key, value)
+}
+
+// setupMasking 按配置替换当前的脱敏引擎
+func setupMasking(c maskingConf) error {
+	m, err := newMasker(c)
+	if err != nil {
+		return err
+	}
+
+	activeMasker.Store(m)
+	return nil
+}
+
+// mask 返回脱敏后的值，没有需要脱敏的内容时返回原值
+func (m *masker) mask(key string, value any) any {
+	if m.isSensitiveKey(key) {
+		return m.replacement
+	}
+
+	// 顶层的 Sensitive 保持原有行为，直接使用 MaskSensitive 的结果
+	value = maskSensitive(value)
+	switch val := value.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Duration, time.Time:
+		return value
+	case string:
+		// 未修改时返回原值，避免重新装箱
+		if masked := m.maskString(val); masked != val {
+			return masked
+		}
+		return value
+	case error:
+		if len(m.patterns) == 0 {
+			return value
+		}
+		// 错误信息中可能包含敏感内容，统一转成字符串后脱敏
+		return m.maskString(encodeError(val))
+	}
+
+	// 没有字段名和内容规则时，只有 Sensitive 和带标签的字段需要处理，其他类型不必遍历
+	if len(m.keys) == 0 && len(m.patterns) == 0 && !mayBeTagged(reflect.TypeOf(value)) {
+		return value
+	}
+
+	if masked, ok := m.maskValue(reflect.ValueOf(value), 0, make(maskSeen)); ok {
+		return masked.Interface()
+	}
+
+	return value
+}
+
+// isSensitiveKey 判断字段名是否需要脱敏，不区分大小写
+func (m *masker) isSensitiveKey(key string) bool {
+	for _, k := range m.keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maskString 使用正则规则替换字符串中的敏感内容
+func (m *masker) maskString(s string) string {
+	for _, p := range m.patterns {
+		if !p.re.MatchString(s) {
+			continue
+		}
+
+		s = p.re.ReplaceAllStringFunc(s, func(match string) string {
+			if p.validate != nil && !p.validate(match) {
+				return match
+			}
+			return m.replacement
+		})
+	}
+
+	return s
+}
+
+// maskValue 递归处理 map、结构体、切片、数组、指针和接口，有修改时返回类型相同的副本
+func (m *masker) maskValue(v reflect.Value, depth int, seen maskSeen) (reflect.Value, bool) {
+	if depth > maxMaskDepth || !v.IsValid() || !v.CanInterface() {
+		return v, false
+	}
+
+	t := v.Type()
+	if isSensitiveValue(v) {
+		return fitValue(m.maskSensitiveValue(v, depth, seen), t), true
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if masked := m.maskString(s); masked != s {
+			return reflect.ValueOf(masked).Convert(t), true
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false
+		}
+		// 接口可以容纳任意类型，直接使用 MaskSensitive 的结果
+		if elem := v.Elem(); isSensitiveValue(elem) {
+			return fitValue(m.maskSensitiveValue(elem, depth+1, seen), t), true
+		}
+		if nv, ok := m.maskValue(v.Elem(), depth+1, seen); ok {
+			return fitValue(nv, t), true
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v, false
+		}
+		return seen.visit(v, func() (reflect.Value, bool) {
+			if nv, ok := m.maskValue(v.Elem(), depth+1, seen); ok {
+				ptr := reflect.New(t.Elem())
+				ptr.Elem().Set(nv)
+				return ptr, true
+			}
+			return v, false
+		})
+	case reflect.Struct:
+		return m.maskStruct(v, depth, seen)
+	case reflect.Map:
+		if v.IsNil() {
+			return v, false
+		}
+		return seen.visit(v, func() (reflect.Value, bool) {
+			return m.maskMap(v, depth, seen)
+		})
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return v, false
+		}
+		return seen.visit(v, func() (reflect.Value, bool) {
+			return m.maskList(v, depth, seen)
+		})
+	case reflect.Array:
+		return m.maskList(v, depth, seen)
+	}
+
+	return v, false
+}
+
+// maskSensitiveValue 返回 MaskSensitive 的结果，并继续对结果脱敏
+func (m *masker) maskSensitiveValue(v reflect.Value, depth int, seen maskSeen) reflect.Value {
+	masked := reflect.ValueOf(v.Interface().(Sensitive).MaskSensitive())
+	if nv, ok := m.maskValue(masked, depth+1, seen); ok {
+		return nv
+	}
+
+	return masked
+}
+
+func (m *masker) maskStruct(v reflect.Value, depth int, seen maskSeen) (reflect.Value, bool) {
+	t := v.Type()
+	var result reflect.Value
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		var nv reflect.Value
+		if field.Tag.Get(maskTagName) == maskTagValue || m.isSensitiveKey(structFieldKey(field)) {
+			if v.Field(i).IsZero() {
+				continue
+			}
+			nv = m.replacementOf(field.Type)
+		} else {
+			var ok bool
+			if nv, ok = m.maskValue(v.Field(i), depth+1, seen); !ok {
+				continue
+			}
+		}
+
+		if !result.IsValid() {
+			result = reflect.New(t).Elem()
+			result.Set(v)
+		}
+		result.Field(i).Set(nv)
+	}
+
+	if result.IsValid() {
+		return result, true
+	}
+	return v, false
+}
+
+func (m *masker) maskMap(v reflect.Value, depth int, seen maskSeen) (reflect.Value, bool) {
+	t := v.Type()
+	stringKey := t.Key().Kind() == reflect.String
+	var changed map[int]reflect.Value
+	keys := v.MapKeys()
+	for i, key := range keys {
+		var nv reflect.Value
+		if stringKey && m.isSensitiveKey(key.String()) {
+			nv = m.replacementOf(t.Elem())
+		} else {
+			var ok bool
+			if nv, ok = m.maskValue(v.MapIndex(key), depth+1, seen); !ok {
+				continue
+			}
+		}
+
+		if changed == nil {
+			changed = make(map[int]reflect.Value)
+		}
+		changed[i] = nv
+	}
+
+	if len(changed) == 0 {
+		return v, false
+	}
+
+	result := reflect.MakeMapWithSize(t, len(keys))
+	for i, key := range keys {
+		if nv, ok := changed[i]; ok {
+			result.SetMapIndex(key, nv)
+		} else {
+			result.SetMapIndex(key, v.MapIndex(key))
+		}
+	}
+
+	return result, true
+}
+
+func (m *masker) maskList(v reflect.Value, depth int, seen maskSeen) (reflect.Value, bool) {
+	if !mayContainSensitive(v.Type().Elem()) {
+		return v, false
+	}
+
+	var result reflect.Value
+	for i := 0; i < v.Len(); i++ {
+		nv, ok := m.maskValue(v.Index(i), depth+1, seen)
+		if !ok {
+			continue
+		}
+
+		if !result.IsValid() {
+			if v.Kind() == reflect.Slice {
+				result = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+				reflect.Copy(result, v)
+			} else {
+				result = reflect.New(v.Type()).Elem()
+				result.Set(v)
+			}
+		}
+		result.Index(i).Set(nv)
+	}
+
+	if result.IsValid() {
+		return result, true
+	}
+	return v, false
+}
+
+// visit 对同一个指针、map 或切片只调用一次 fn，之后返回相同的结果
+// 处理过程中再次遇到（循环引用）时返回原值，避免循环引用的值被反复遍历
+func (s maskSeen) visit(v reflect.Value, fn func() (reflect.Value, bool)) (reflect.Value, bool) {
+	ref := maskRef{typ: v.Type(), ptr: v.Pointer()}
+	if v.Kind() == reflect.Slice {
+		ref.len = v.Len()
+	}
+	if r, ok := s[ref]; ok {
+		if !r.done {
+			return v, false
+		}
+		return r.value, r.ok
+	}
+
+	r := new(maskResult)
+	s[ref] = r
+	r.value, r.ok = fn()
+	r.done = true
+	return r.value, r.ok
+}
+
+// replacementOf 返回类型 t 的脱敏值，字符串和接口类型使用替换内容，其他类型使用零值
+func (m *masker) replacementOf(t reflect.Type) reflect.Value {
+	replacement := reflect.ValueOf(m.replacement)
+	switch {
+	case replacement.Type().AssignableTo(t):
+		return replacement
+	case t.Kind() == reflect.String:
+		return replacement.Convert(t)
+	default:
+		return reflect.Zero(t)
+	}
+}
+
+// fitValue 将 v 转换为可赋值给类型 t 的值，无法转换时返回零值，避免泄露原值
+func fitValue(v reflect.Value, t reflect.Type) reflect.Value {
+	switch {
+	case !v.IsValid():
+		return reflect.Zero(t)
+	case v.Type().AssignableTo(t):
+		return v
+	case v.Type().ConvertibleTo(t):
+		return v.Convert(t)
+	default:
+		return reflect.Zero(t)
+	}
+}
+
+func isSensitiveValue(v reflect.Value) bool {
+	return v.CanInterface() && v.Type().Implements(sensitiveType) && !isNilValue(v)
+}
+
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	default:
+		return false
+	}
+}
+
+// mayBeTagged 判断类型 t 的值是否可能包含 Sensitive 或带脱敏标签的字段，接口类型无法确定，按可能处理
+func mayBeTagged(t reflect.Type) bool {
+	if tagged, ok := taggedTypes.Load(t); ok {
+		return tagged.(bool)
+	}
+
+	tagged := typeMayBeTagged(t, make(map[reflect.Type]bool))
+	taggedTypes.Store(t, tagged)
+	return tagged
+}
+
+func typeMayBeTagged(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t.Implements(sensitiveType) {
+		return true
+	}
+	// 递归类型中再次遇到的类型不会带来新的字段
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return typeMayBeTagged(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			if field.Tag.Get(maskTagName) == maskTagValue || typeMayBeTagged(field.Type, visiting) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// mayContainSensitive 判断元素类型是否可能包含需要脱敏的内容，用于跳过 []byte、[]int 等切片
+func mayContainSensitive(t reflect.Type) bool {
+	if t.Implements(sensitiveType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Interface, reflect.Pointer, reflect.Struct,
+		reflect.Map, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// structFieldKey 返回结构体字段在 JSON 中的键名
+func structFieldKey(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(tag, ","); len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// luhnValid 校验银行卡号，排除普通的长数字
+func luhnValid(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+
+	return n >= 13 && sum%10 == 0
+}
@@ -0,0 +1,280 @@
+package logx
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type maskUser struct {
+	Name     string `json:"name"`
+	Password string `json:"password" logx:"sensitive"`
+	Age      int    `logx:"sensitive"`
+	Email    string `json:"email"`
+	Token    string `json:"access_token"`
+	Profile  *maskProfile
+	Extra    any
+	secret   string
+}
+
+type maskProfile struct {
+	Phone string
+	Cards []string
+}
+
+type maskedCard string
+
+func (c maskedCard) MaskSensitive() any {
+	return maskedCard("****" + string(c[len(c)-4:]))
+}
+
+func mustMasker(t *testing.T, c maskingConf) *masker {
+	m, err := newMasker(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMaskerKeys(t *testing.T) {
+	m := mustMasker(t, maskingConf{Keys: []string{"password", "Authorization", "access_token"}})
+
+	if v := m.mask("PASSWORD", "secret"); v != defaultMaskReplacement {
+		t.Errorf("字段名应不区分大小写, 实际 %v", v)
+	}
+	if v := m.mask("name", "secret"); v != "secret" {
+		t.Errorf("其他字段不应脱敏, 实际 %v", v)
+	}
+
+	headers := map[string][]string{
+		"Authorization": {"Bearer abc"},
+		"Accept":        {"*/*"},
+	}
+	masked := m.mask("headers", headers).(map[string][]string)
+	if masked["Authorization"] != nil || masked["Accept"][0] != "*/*" {
+		t.Errorf("嵌套 map 的键应脱敏, 非字符串类型使用零值, 实际 %v", masked)
+	}
+	if headers["Authorization"][0] != "Bearer abc" {
+		t.Error("不应修改原值")
+	}
+
+	nested := map[string]any{"auth": map[string]any{"authorization": "Bearer abc", "user": "foo"}}
+	out, _ := json.Marshal(m.mask("req", nested))
+	if string(out) != `{"auth":{"authorization":"******","user":"foo"}}` {
+		t.Errorf("多层嵌套的 map 应脱敏, 实际 %s", out)
+	}
+
+	// 结构体字段优先使用 json 标签名匹配
+	user := maskUser{Name: "foo", Token: "abc"}
+	if v := m.mask("user", user).(maskUser); v.Token != defaultMaskReplacement || v.Name != "foo" {
+		t.Errorf("结构体字段应按 json 标签名脱敏, 实际 %+v", v)
+	}
+}
+
+func TestMaskerTags(t *testing.T) {
+	m := mustMasker(t, maskingConf{})
+
+	user := &maskUser{
+		Name:     "foo",
+		Password: "secret",
+		Age:      18,
+		Profile:  &maskProfile{Phone: "13812345678"},
+		secret:   "keep",
+	}
+	masked := m.mask("user", user).(*maskUser)
+	if masked == user {
+		t.Fatal("有脱敏内容时应返回副本")
+	}
+	if masked.Password != defaultMaskReplacement || masked.Age != 0 || masked.Name != "foo" {
+		t.Errorf("带标签的字段应脱敏, 实际 %+v", masked)
+	}
+	if masked.secret != "keep" || masked.Profile != user.Profile {
+		t.Errorf("未修改的字段应保持原值, 实际 %+v", masked)
+	}
+	if user.Password != "secret" || user.Age != 18 {
+		t.Error("不应修改原值")
+	}
+
+	// 没有需要脱敏的内容时返回原值
+	plain := maskProfile{Phone: "13812345678"}
+	if v := m.mask("profile", plain).(maskProfile); v.Phone != plain.Phone {
+		t.Errorf("未配置规则时不应脱敏, 实际 %+v", v)
+	}
+
+	// 切片和接口中的结构体同样生效
+	list := []any{maskUser{Password: "secret"}, "foo"}
+	out, _ := json.Marshal(m.mask("list", list))
+	if !strings.Contains(string(out), `"password":"******"`) || strings.Contains(string(out), "secret") {
+		t.Errorf("切片中的结构体应脱敏, 实际 %s", out)
+	}
+}
+
+func TestMaskerRules(t *testing.T) {
+	m := mustMasker(t, maskingConf{
+		Rules:       []string{"email", "credit_card", "phone", "jwt"},
+		Patterns:    []string{`sk-[a-z0-9]{8}`},
+		Replacement: "[REDACTED]",
+	})
+
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"mail foo.bar@example.com now", "mail [REDACTED] now"},
+		{"card 4111 1111 1111 1111 paid", "card [REDACTED] paid"},
+		{"card 4111-1111-1111-1112 paid", "card 4111-1111-1111-1112 paid"},
+		{"order 1234567890123", "order 1234567890123"},
+		{"call 13812345678", "call [REDACTED]"},
+		{"call +8613812345678", "call [REDACTED]"},
+		{"call +14155552671", "call [REDACTED]"},
+		{"token eyJhbGciOiJIUzI1NiJ9.eyJzdWIiOiIxIn0.abc_-123", "token [REDACTED]"},
+		{"key sk-abcd1234", "key [REDACTED]"},
+		{"nothing here", "nothing here"},
+	}
+
+	for _, test := range tests {
+		if got := m.mask("msg", test.input); got != test.want {
+			t.Errorf("mask(%q) 期望 %q, 实际 %q", test.input, test.want, got)
+		}
+	}
+
+	// 递归处理嵌套的字符串
+	user := maskUser{Email: "foo@example.com", Profile: &maskProfile{Cards: []string{"4111111111111111"}}}
+	masked := m.mask("user", user).(maskUser)
+	if masked.Email != "[REDACTED]" || masked.Profile.Cards[0] != "[REDACTED]" {
+		t.Errorf("嵌套的字符串应脱敏, 实际 %+v %+v", masked, masked.Profile)
+	}
+	if user.Profile.Cards[0] != "4111111111111111" {
+		t.Error("不应修改原值")
+	}
+
+	// 错误信息转成字符串后脱敏
+	if v := m.mask("err", errors.New("bad user foo@example.com")); v != "bad user [REDACTED]" {
+		t.Errorf("错误信息应脱敏, 实际 %v", v)
+	}
+}
+
+func TestMaskerSensitive(t *testing.T) {
+	m := mustMasker(t, maskingConf{})
+
+	if v := m.mask("card", maskedCard("4111111111111111")); v != maskedCard("****1111") {
+		t.Errorf("顶层 Sensitive 应使用 MaskSensitive, 实际 %v", v)
+	}
+
+	// 嵌套的 Sensitive 同样生效
+	cards := map[string]maskedCard{"visa": "4111111111111111"}
+	if v := m.mask("cards", cards).(map[string]maskedCard); v["visa"] != "****1111" {
+		t.Errorf("嵌套的 Sensitive 应脱敏, 实际 %v", v)
+	}
+	if v := m.mask("user", maskUser{Extra: maskedPassword("secret")}).(maskUser); v.Extra != "******" {
+		t.Errorf("接口字段中的 Sensitive 应脱敏, 实际 %v", v.Extra)
+	}
+}
+
+type maskNode struct {
+	Name                 string
+	Secret               string `logx:"sensitive"`
+	Prev, Next, Up, Down *maskNode
+}
+
+func TestMaskerCycle(t *testing.T) {
+	a := &maskNode{Name: "a", Secret: "x"}
+	b := &maskNode{Name: "foo@example.com", Secret: "y"}
+	a.Prev, a.Next, a.Up, a.Down = b, b, b, b
+	b.Prev, b.Next, b.Up, b.Down = a, a, a, a
+
+	for _, m := range []*masker{mustMasker(t, maskingConf{}), mustMasker(t, maskingConf{Rules: []string{"email"}})} {
+		done := make(chan any, 1)
+		go func() {
+			done <- m.mask("node", a)
+		}()
+
+		select {
+		case v := <-done:
+			node := v.(*maskNode)
+			if node.Secret != "******" || node.Next.Secret != "******" {
+				t.Errorf("循环引用中带标签的字段应脱敏, 实际 %v, %v", node.Secret, node.Next.Secret)
+			}
+			// 共享的值只处理一次，各处引用同一个副本
+			if node.Next != node.Up || node.Next == b {
+				t.Error("共享的指针应使用同一个脱敏后的副本")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("循环引用的值应很快返回")
+		}
+	}
+	if a.Secret != "x" || b.Secret != "y" {
+		t.Error("不应修改原值")
+	}
+}
+
+func TestMaskerSkipUntagged(t *testing.T) {
+	m := mustMasker(t, maskingConf{})
+	if mayBeTagged(reflect.TypeOf(maskProfile{})) {
+		t.Error("没有标签和 Sensitive 的类型不需要遍历")
+	}
+	if !mayBeTagged(reflect.TypeOf([]*maskNode{})) || !mayBeTagged(reflect.TypeOf(map[string]any{})) {
+		t.Error("带标签的类型和接口类型需要遍历")
+	}
+
+	profile := &maskProfile{Phone: "13812345678"}
+	if v := m.mask("profile", profile); v != profile {
+		t.Errorf("没有需要脱敏的内容时应返回原值, 实际 %v", v)
+	}
+}
+
+func TestNewMaskerError(t *testing.T) {
+	if _, err := newMasker(maskingConf{Rules: []string{"ssn"}}); err == nil {
+		t.Error("未知的规则应返回错误")
+	}
+	if _, err := newMasker(maskingConf{Patterns: []string{"("}}); err == nil {
+		t.Error("非法的正则应返回错误")
+	}
+
+	resetSetup(t)
+	if err := SetUp(LogConf{Masking: maskingConf{Rules: []string{"ssn"}}}); err == nil {
+		t.Error("SetUp 应返回脱敏配置错误")
+	}
+}
+
+func TestSetUpMasking(t *testing.T) {
+	resetSetup(t)
+	err := SetUp(LogConf{
+		Masking: maskingConf{
+			Keys:  []string{"password"},
+			Rules: []string{"email"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := captureEntry(t)
+	Infow("login foo@example.com", Field("password", "secret"),
+		Field("user", map[string]any{"password": "secret", "name": "foo"}))
+	entry := last()
+	if entry[defaultContentKey] != "login ******" {
+		t.Errorf("内容应按规则脱敏, 实际 %v", entry[defaultContentKey])
+	}
+	if entry["password"] != "******" {
+		t.Errorf("字段应按字段名脱敏, 实际 %v", entry["password"])
+	}
+	if user := entry["user"].(map[string]any); user["password"] != "******" || user["name"] != "foo" {
+		t.Errorf("嵌套字段应脱敏, 实际 %v", user)
+	}
+
+	// resetSetup 会恢复编码格式
+	w := writer.Load().(*mockWriter)
+	for _, enc := range []uint32{plainEncodingType, logfmtEncodingType, prettyEncodingType} {
+		atomic.StoreUint32(&encoding, enc)
+		w.Reset()
+		Infow("login", Field("password", "secret"))
+		if w.Contains("secret") {
+			t.Errorf("编码 %d 应脱敏, 实际 %s", enc, w.String())
+		}
+	}
+}
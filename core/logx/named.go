@@ -0,0 +1,161 @@
+package logx
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 匹配所有命名 Logger 的规则名称
+const wildcardLoggerName = "*"
+
+var (
+	// 按名称覆盖的日志级别，nil 表示没有配置
+	// 只在修改时整体替换，读路径无锁
+	loggerLevels     atomic.Pointer[loggerLevelTable]
+	loggerLevelsLock sync.Mutex
+)
+
+type (
+	// loggerLevelTable 命名 Logger 的级别规则，resolved 缓存每个名称的解析结果
+	loggerLevelTable struct {
+		rules    map[string]uint32
+		resolved sync.Map
+	}
+
+	resolvedLevel struct {
+		level uint32
+		ok    bool
+	}
+)
+
+// Named returns a Logger with the given name, the name is written as a field,
+// and the effective level is resolved from the logger level rules.
+// Names are hierarchical and separated by dots, like cache.redis.
+func Named(name string) Logger {
+	return &richLogger{
+		name: name,
+	}
+}
+
+// LoggerLevels returns a copy of the logger level rules.
+func LoggerLevels() map[string]uint32 {
+	table := loggerLevels.Load()
+	if table == nil {
+		return map[string]uint32{}
+	}
+
+	result := make(map[string]uint32, len(table.rules))
+	for name, level := range table.rules {
+		result[name] = level
+	}
+	return result
+}
+
+// RemoveLoggerLevel removes the level rule of the given logger name.
+func RemoveLoggerLevel(name string) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	rules := LoggerLevels()
+	if _, ok := rules[name]; !ok {
+		return
+	}
+
+	delete(rules, name)
+	storeLoggerLevels(rules)
+}
+
+// SetLoggerLevel sets the level of the given logger name and its descendants,
+// use * to set the level of all named loggers.
+func SetLoggerLevel(name string, level uint32) {
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+
+	rules := LoggerLevels()
+	rules[name] = level
+	storeLoggerLevels(rules)
+}
+
+// loggerLevel 返回命名 Logger 生效的级别，没有匹配的规则时返回 false
+func loggerLevel(name string) (uint32, bool) {
+	table := loggerLevels.Load()
+	if table == nil {
+		return 0, false
+	}
+
+	if val, ok := table.resolved.Load(name); ok {
+		resolved := val.(resolvedLevel)
+		return resolved.level, resolved.ok
+	}
+
+	level, ok := table.lookup(name)
+	table.resolved.Store(name, resolvedLevel{level: level, ok: ok})
+	return level, ok
+}
+
+// lookup 按名称逐级向上查找，最后匹配 *
+func (t *loggerLevelTable) lookup(name string) (uint32, bool) {
+	for len(name) > 0 {
+		if level, ok := t.rules[name]; ok {
+			return level, true
+		}
+
+		pos := strings.LastIndexByte(name, '.')
+		if pos < 0 {
+			break
+		}
+		name = name[:pos]
+	}
+
+	level, ok := t.rules[wildcardLoggerName]
+	return level, ok
+}
+
+// parseLoggerLevels 解析 "名称=级别" 格式的规则
+func parseLoggerLevels(rules []string) (map[string]uint32, error) {
+	result := make(map[string]uint32, len(rules))
+	for _, rule := range rules {
+		name, levelName, ok := strings.Cut(rule, "=")
+		name = strings.TrimSpace(name)
+		if !ok || len(name) == 0 {
+			return nil, fmt.Errorf("invalid logger level rule %q, should be name=level", rule)
+		}
+
+		level, err := parseLevel(strings.TrimSpace(levelName))
+		if err != nil {
+			return nil, fmt.Errorf("invalid logger level rule %q: %w", rule, err)
+		}
+
+		result[name] = level
+	}
+
+	return result, nil
+}
+
+// setupLoggerLevels 使用配置的规则整体替换当前规则
+func setupLoggerLevels(rules []string) error {
+	parsed, err := parseLoggerLevels(rules)
+	if err != nil {
+		return err
+	}
+
+	loggerLevelsLock.Lock()
+	defer loggerLevelsLock.Unlock()
+	storeLoggerLevels(parsed)
+
+	return nil
+}
+
+// storeLoggerLevels 用新的规则替换当前规则，解析缓存随旧表一起丢弃
+func storeLoggerLevels(rules map[string]uint32) {
+	if len(rules) == 0 {
+		loggerLevels.Store(nil)
+		return
+	}
+
+	loggerLevels.Store(&loggerLevelTable{
+		rules: rules,
+	})
+}
@@ -0,0 +1,162 @@
+package logx
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func resetLoggerLevels(t *testing.T) {
+	t.Cleanup(func() {
+		loggerLevels.Store(nil)
+	})
+}
+
+func TestNamedField(t *testing.T) {
+	last := captureEntry(t)
+
+	Named("cache").Info("hello")
+	if entry := last(); entry[defaultLoggerKey] != "cache" {
+		t.Errorf("应输出 Logger 名称, 实际 %v", entry)
+	}
+
+	// With* 返回的副本保留名称
+	Named("cache").WithContext(context.Background()).WithFields(Field("foo", "bar")).Info("hello")
+	if entry := last(); entry[defaultLoggerKey] != "cache" || entry["foo"] != "bar" {
+		t.Errorf("副本应保留名称, 实际 %v", entry)
+	}
+
+	// 用户字段不能覆盖名称
+	Named("cache").Infow("hello", Field(defaultLoggerKey, "user"))
+	if entry := last(); entry[defaultLoggerKey] != "cache" || entry[reservedFieldPrefix+defaultLoggerKey] != "user" {
+		t.Errorf("同名用户字段应被重命名, 实际 %v", entry)
+	}
+
+	// 未命名的 Logger 不输出名称
+	WithContext(context.Background()).Info("hello")
+	if entry := last(); entry[defaultLoggerKey] != nil {
+		t.Errorf("未命名的 Logger 不应输出名称, 实际 %v", entry)
+	}
+}
+
+func TestNamedLevels(t *testing.T) {
+	captureEntry(t)
+	resetLoggerLevels(t)
+	w := writer.Load().(*mockWriter)
+	SetLevel(InfoLevel)
+
+	if err := setupLoggerLevels([]string{"cache.redis = debug", "*=error"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		log    func()
+		output bool
+	}{
+		{"子名称继承上级规则", func() { Named("cache.redis.pool").Debug("pool") }, true},
+		{"精确匹配", func() { Named("cache.redis").Debug("redis") }, true},
+		{"上级不受下级规则影响", func() { Named("cache").Info("cache") }, false},
+		{"通配规则", func() { Named("db").Info("db") }, false},
+		{"通配规则允许更高级别", func() { Named("db").Error("db error") }, true},
+		{"未命名使用全局级别", func() { Info("global") }, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w.Reset()
+			test.log()
+			if output := len(w.String()) > 0; output != test.output {
+				t.Errorf("期望输出 %t, 实际 %s", test.output, w.String())
+			}
+		})
+	}
+
+	// 没有通配规则时使用全局级别
+	RemoveLoggerLevel(wildcardLoggerName)
+	w.Reset()
+	Named("db").Info("db")
+	if !w.Contains("db") {
+		t.Errorf("没有匹配规则时应使用全局级别: %s", w.String())
+	}
+}
+
+func TestSetLoggerLevel(t *testing.T) {
+	captureEntry(t)
+	resetLoggerLevels(t)
+	w := writer.Load().(*mockWriter)
+	SetLevel(InfoLevel)
+
+	l := Named("cache")
+	l.Debug("before")
+	SetLoggerLevel("cache", DebugLevel)
+	l.Debug("after")
+	if w.Contains("before") || !w.Contains("after") {
+		t.Errorf("修改规则后应立即生效: %s", w.String())
+	}
+
+	if levels := LoggerLevels(); len(levels) != 1 || levels["cache"] != DebugLevel {
+		t.Errorf("规则不正确: %v", levels)
+	}
+
+	RemoveLoggerLevel("cache")
+	if loggerLevels.Load() != nil {
+		t.Error("删除全部规则后应回到无规则的快速路径")
+	}
+	w.Reset()
+	l.Debug("removed")
+	if w.Contains("removed") {
+		t.Errorf("删除规则后应使用全局级别: %s", w.String())
+	}
+}
+
+func TestParseLoggerLevels(t *testing.T) {
+	for _, rules := range [][]string{{"cache"}, {"=debug"}, {"cache=verbose"}} {
+		if _, err := parseLoggerLevels(rules); err == nil {
+			t.Errorf("规则 %v 应解析失败", rules)
+		}
+	}
+
+	resetSetup(t)
+	err := SetUp(LogConf{LoggerLevels: []string{"cache=verbose"}})
+	if err == nil || !strings.Contains(err.Error(), "cache=verbose") {
+		t.Errorf("SetUp 应返回规则错误, 实际 %v", err)
+	}
+}
+
+func TestSetUpLoggerLevels(t *testing.T) {
+	resetSetup(t)
+	err := SetUp(LogConf{
+		Level:        levelInfo,
+		LoggerLevels: []string{"cache=debug"},
+		FieldKeys:    fieldKeyConf{LoggerKey: "module"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last := captureEntry(t)
+	atomic.StoreUint32(&logLevel, InfoLevel)
+	Named("cache.redis").Debug("hello")
+	if entry := last(); entry["module"] != "cache.redis" {
+		t.Errorf("应使用配置的级别和键名, 实际 %v", entry)
+	}
+}
+
+func BenchmarkNamedShallLog(b *testing.B) {
+	b.Cleanup(func() {
+		loggerLevels.Store(nil)
+	})
+	if err := setupLoggerLevels([]string{"cache.redis=debug", "*=info"}); err != nil {
+		b.Fatal(err)
+	}
+
+	l := Named("cache.redis.pool").(*richLogger)
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.shallLog(DebugLevel)
+		}
+	})
+}
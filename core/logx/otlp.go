@@ -0,0 +1,343 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OTel 日志数据模型的严重级别，https://opentelemetry.io/docs/specs/otel/logs/data-model/#field-severitynumber
+	otlpSeverityDebug  = 5
+	otlpSeverityInfo   = 9
+	otlpSeverityWarn   = 13
+	otlpSeverityError  = 17
+	otlpSeverityError2 = 18
+	otlpSeverityError3 = 19
+	otlpSeverityFatal  = 21
+
+	otlpServiceNameKey = "service.name"
+	otlpScopeName      = "github.com/YunFy26/mini-zero/core/logx"
+	otlpTraceIdLen     = 32
+	otlpSpanIdLen      = 16
+	// 内部使用的 HTTP 格式，不通过 WithShipHTTPFormat 暴露
+	shipOTLP = "otlp"
+)
+
+// NewOTLPWriter returns a ShipWriter that exports entries to an OTLP/HTTP endpoint with
+// the JSON encoding, such as http://localhost:4318/v1/logs.
+// Entries are mapped to the OpenTelemetry log data model, the level is mapped to the
+// severity, the content to the body, the trace and span fields to trace_id and span_id,
+// and the other fields to attributes. The service.name resource attribute defaults to
+// the ServiceName of LogConf.
+// All the ShipOption except the protocol specific ones can be used, like WithShipGzip,
+// WithShipHeaders and WithShipSpool.
+func NewOTLPWriter(endpoint string, opts ...ShipOption) (*ShipWriter, error) {
+	options := newShipOptions(opts)
+	options.httpFormat = shipOTLP
+
+	return newShipWriter(newHTTPTransport(endpoint, options), appendOTLPRecord, options), nil
+}
+
+// WithOTLPResource adds resource attributes to the exported logs, like deployment.environment,
+// the service.name can also be overridden here.
+func WithOTLPResource(attrs map[string]string) ShipOption {
+	return func(opts *shipOptions) {
+		opts.resource = attrs
+	}
+}
+
+// appendOTLPRecord 将一条日志编码为 OTLP/JSON 的 LogRecord
+func appendOTLPRecord(b []byte, keys *systemKeys, level string, val any, fields []LogField) []byte {
+	now := strconv.FormatInt(clock.Now().UnixNano(), 10)
+	b = append(b, `{"timeUnixNano":"`...)
+	b = append(b, now...)
+	b = append(b, `","observedTimeUnixNano":"`...)
+	b = append(b, now...)
+	b = append(b, `","severityNumber":`...)
+	b = strconv.AppendInt(b, int64(otlpSeverity(level)), 10)
+	b = append(b, `,"severityText":`...)
+	b = appendJsonString(b, level)
+	b = append(b, `,"body":`...)
+	b = appendOTLPValue(b, val)
+
+	var traceId, spanId string
+	b = append(b, `,"attributes":[`...)
+	var n int
+	for _, field := range dedupFields(fields) {
+		value := maskField(field.Key, field.Value)
+		// 合法的 trace 和 span 写入 LogRecord 的 traceId 和 spanId，其他的作为普通属性
+		if id, ok := value.(string); ok {
+			if field.Key == keys.trace && isOTLPId(id, otlpTraceIdLen) {
+				traceId = id
+				continue
+			}
+			if field.Key == keys.span && isOTLPId(id, otlpSpanIdLen) {
+				spanId = id
+				continue
+			}
+		}
+
+		if n > 0 {
+			b = append(b, ',')
+		}
+		n++
+		b = appendOTLPKeyValue(b, field.Key, value)
+	}
+	b = append(b, ']')
+
+	if len(traceId) > 0 {
+		b = append(b, `,"traceId":"`...)
+		b = append(b, strings.ToLower(traceId)...)
+		b = append(b, '"')
+	}
+	if len(spanId) > 0 {
+		b = append(b, `,"spanId":"`...)
+		b = append(b, strings.ToLower(spanId)...)
+		b = append(b, '"')
+	}
+
+	return append(b, '}')
+}
+
+// appendOTLPRequest 将一批 LogRecord 组装为 ExportLogsServiceRequest
+func appendOTLPRequest(b, resource []byte, records [][]byte) []byte {
+	b = append(b, `{"resourceLogs":[{"resource":{"attributes":`...)
+	b = append(b, resource...)
+	b = append(b, `},"scopeLogs":[{"scope":{"name":"`...)
+	b = append(b, otlpScopeName...)
+	b = append(b, `"},"logRecords":[`...)
+	for i, record := range records {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, bytes.TrimSuffix(record, []byte{'\n'})...)
+	}
+
+	return append(b, "]}]}]}"...)
+}
+
+// appendOTLPResource 编码资源属性，service.name 默认使用配置的服务名
+func appendOTLPResource(b []byte, attrs map[string]string) []byte {
+	all := make(map[string]string, len(attrs)+1)
+	if len(serviceName) > 0 {
+		all[otlpServiceNameKey] = serviceName
+	} else {
+		all[otlpServiceNameKey] = "unknown_service:" + filepath.Base(os.Args[0])
+	}
+	for k, v := range attrs {
+		all[k] = v
+	}
+
+	keys := make([]string, 0, len(all))
+	for k := range all {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b = append(b, '[')
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		b = appendOTLPKeyValue(b, k, all[k])
+	}
+
+	return append(b, ']')
+}
+
+func appendOTLPKeyValue(b []byte, key string, value any) []byte {
+	b = append(b, `{"key":`...)
+	b = appendJsonString(b, key)
+	b = append(b, `,"value":`...)
+	b = appendOTLPValue(b, value)
+	return append(b, '}')
+}
+
+// appendOTLPValue 将值编码为 AnyValue，结构化的值先编码为 JSON 再转换为 kvlist 或 array
+func appendOTLPValue(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(b, "{}"...)
+	case string:
+		return appendOTLPString(b, val)
+	case bool:
+		b = append(b, `{"boolValue":`...)
+		b = strconv.AppendBool(b, val)
+		return append(b, '}')
+	case int:
+		return appendOTLPInt(b, int64(val))
+	case int8:
+		return appendOTLPInt(b, int64(val))
+	case int16:
+		return appendOTLPInt(b, int64(val))
+	case int32:
+		return appendOTLPInt(b, int64(val))
+	case int64:
+		return appendOTLPInt(b, val)
+	case uint:
+		return appendOTLPUint(b, uint64(val))
+	case uint8:
+		return appendOTLPInt(b, int64(val))
+	case uint16:
+		return appendOTLPInt(b, int64(val))
+	case uint32:
+		return appendOTLPInt(b, int64(val))
+	case uint64:
+		return appendOTLPUint(b, val)
+	case float32:
+		return appendOTLPDouble(b, float64(val))
+	case float64:
+		return appendOTLPDouble(b, val)
+	case time.Duration, time.Time, error:
+		return appendOTLPString(b, string(appendTextValue(nil, val)))
+	case json.Marshaler:
+		return appendOTLPJson(b, val)
+	case fmt.Stringer:
+		return appendOTLPString(b, encodeStringer(val))
+	default:
+		return appendOTLPJson(b, val)
+	}
+}
+
+func appendOTLPDouble(b []byte, f float64) []byte {
+	b = append(b, `{"doubleValue":`...)
+	switch {
+	case math.IsNaN(f):
+		b = append(b, `"NaN"`...)
+	case math.IsInf(f, 1):
+		b = append(b, `"Infinity"`...)
+	case math.IsInf(f, -1):
+		b = append(b, `"-Infinity"`...)
+	default:
+		b = strconv.AppendFloat(b, f, 'g', -1, 64)
+	}
+
+	return append(b, '}')
+}
+
+// appendOTLPInt 追加 intValue，按 proto3 JSON 的规则 int64 编码为字符串
+func appendOTLPInt(b []byte, n int64) []byte {
+	b = append(b, `{"intValue":"`...)
+	b = strconv.AppendInt(b, n, 10)
+	return append(b, `"}`...)
+}
+
+// appendOTLPJson 通过 JSON 编码转换结构化的值，保证与其他编码方式的输出一致
+func appendOTLPJson(b []byte, v any) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(appendJsonValue(nil, v)))
+	decoder.UseNumber()
+	var decoded any
+	if err := decoder.Decode(&decoded); err != nil {
+		return appendOTLPString(b, fmt.Sprintf("%+v", v))
+	}
+
+	return appendOTLPJsonValue(b, decoded)
+}
+
+func appendOTLPJsonValue(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return appendOTLPInt(b, n)
+		}
+		f, _ := val.Float64()
+		return appendOTLPDouble(b, f)
+	case []any:
+		b = append(b, `{"arrayValue":{"values":[`...)
+		for i, item := range val {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = appendOTLPJsonValue(b, item)
+		}
+		return append(b, "]}}"...)
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		b = append(b, `{"kvlistValue":{"values":[`...)
+		for i, k := range keys {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, `{"key":`...)
+			b = appendJsonString(b, k)
+			b = append(b, `,"value":`...)
+			b = appendOTLPJsonValue(b, val[k])
+			b = append(b, '}')
+		}
+		return append(b, "]}}"...)
+	default:
+		// nil、string、bool
+		return appendOTLPValue(b, val)
+	}
+}
+
+func appendOTLPString(b []byte, s string) []byte {
+	b = append(b, `{"stringValue":`...)
+	b = appendJsonString(b, s)
+	return append(b, '}')
+}
+
+// appendOTLPUint 超出 int64 范围的值使用 doubleValue
+func appendOTLPUint(b []byte, n uint64) []byte {
+	if n > math.MaxInt64 {
+		return appendOTLPDouble(b, float64(n))
+	}
+
+	return appendOTLPInt(b, int64(n))
+}
+
+// isOTLPId 判断是否为指定长度的十六进制 id，不区分大小写，全零的 id 无效
+func isOTLPId(id string, size int) bool {
+	if len(id) != size {
+		return false
+	}
+
+	var nonZero bool
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c == '0':
+		case c >= '1' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+			nonZero = true
+		default:
+			return false
+		}
+	}
+
+	return nonZero
+}
+
+// otlpSeverity 将 logx 的级别映射为 OTel 的严重级别
+func otlpSeverity(level string) int {
+	switch level {
+	case levelDebug:
+		return otlpSeverityDebug
+	case levelInfo, levelStat:
+		return otlpSeverityInfo
+	case levelSlow:
+		return otlpSeverityWarn
+	case levelError:
+		return otlpSeverityError
+	case levelAlert:
+		return otlpSeverityError2
+	case levelSevere:
+		return otlpSeverityError3
+	case levelFatal:
+		return otlpSeverityFatal
+	default:
+		return otlpSeverityInfo
+	}
+}
@@ -0,0 +1,278 @@
+package logx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type (
+	otlpAnyValue struct {
+		StringValue *string `json:"stringValue"`
+		BoolValue   *bool   `json:"boolValue"`
+		IntValue    *string `json:"intValue"`
+		DoubleValue any     `json:"doubleValue"`
+		ArrayValue  *struct {
+			Values []otlpAnyValue `json:"values"`
+		} `json:"arrayValue"`
+		KvlistValue *struct {
+			Values []otlpKeyValue `json:"values"`
+		} `json:"kvlistValue"`
+	}
+
+	otlpKeyValue struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	otlpLogRecord struct {
+		TimeUnixNano         string         `json:"timeUnixNano"`
+		ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+		SeverityNumber       int            `json:"severityNumber"`
+		SeverityText         string         `json:"severityText"`
+		Body                 otlpAnyValue   `json:"body"`
+		Attributes           []otlpKeyValue `json:"attributes"`
+		TraceId              string         `json:"traceId"`
+		SpanId               string         `json:"spanId"`
+	}
+
+	otlpRequest struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []otlpKeyValue `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope struct {
+					Name string `json:"name"`
+				} `json:"scope"`
+				LogRecords []otlpLogRecord `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+)
+
+func (kvs otlpKeyValue) String() string {
+	return kvs.Key + "=" + kvs.Value.String()
+}
+
+// String 以紧凑形式输出 AnyValue，便于断言
+func (v otlpAnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		if *v.BoolValue {
+			return "true"
+		}
+		return "false"
+	case v.IntValue != nil:
+		return "int:" + *v.IntValue
+	case v.DoubleValue != nil:
+		data, _ := json.Marshal(v.DoubleValue)
+		return "double:" + strings.Trim(string(data), `"`)
+	case v.ArrayValue != nil:
+		var items []string
+		for _, item := range v.ArrayValue.Values {
+			items = append(items, item.String())
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	case v.KvlistValue != nil:
+		var items []string
+		for _, item := range v.KvlistValue.Values {
+			items = append(items, item.String())
+		}
+		return "{" + strings.Join(items, ",") + "}"
+	default:
+		return "<empty>"
+	}
+}
+
+func attributesOf(record otlpLogRecord) map[string]string {
+	result := make(map[string]string)
+	for _, attr := range record.Attributes {
+		result[attr.Key] = attr.Value.String()
+	}
+	return result
+}
+
+func exportOTLP(t *testing.T, write func(w *ShipWriter), opts ...ShipOption) otlpRequest {
+	t.Helper()
+	c := newShipCollector(t)
+	w, err := NewOTLPWriter(c.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(w)
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(c.bodies) != 1 {
+		t.Fatalf("期望 1 次请求, 实际 %d", len(c.bodies))
+	}
+	if contentType := c.headers[0].Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type 错误: %s", contentType)
+	}
+
+	var req otlpRequest
+	if err = json.Unmarshal([]byte(c.bodies[0]), &req); err != nil {
+		t.Fatalf("不是合法的 JSON: %v, %s", err, c.bodies[0])
+	}
+	if len(req.ResourceLogs) != 1 || len(req.ResourceLogs[0].ScopeLogs) != 1 {
+		t.Fatalf("请求结构错误: %s", c.bodies[0])
+	}
+
+	return req
+}
+
+func TestOTLPWriter(t *testing.T) {
+	resetSetup(t)
+	resetGlobalFields(t)
+	serviceName = "user-service"
+
+	req := exportOTLP(t, func(w *ShipWriter) {
+		w.Info("hello", Field("count", 3), Field("ratio", 0.5), Field("ok", true))
+		w.Error(map[string]any{"code": 500})
+		w.Slow("slow")
+	}, WithOTLPResource(map[string]string{"deployment.environment": "test"}), WithShipGzip())
+
+	resource := req.ResourceLogs[0].Resource.Attributes
+	if len(resource) != 2 || resource[0].String() != "deployment.environment=test" ||
+		resource[1].String() != "service.name=user-service" {
+		t.Errorf("资源属性错误: %v", resource)
+	}
+
+	scope := req.ResourceLogs[0].ScopeLogs[0]
+	if scope.Scope.Name != otlpScopeName || len(scope.LogRecords) != 3 {
+		t.Fatalf("scope 错误: %+v", scope)
+	}
+
+	info := scope.LogRecords[0]
+	if info.SeverityNumber != otlpSeverityInfo || info.SeverityText != levelInfo || info.Body.String() != "hello" {
+		t.Errorf("info 日志错误: %+v", info)
+	}
+	nanos, err := strconv.ParseInt(info.TimeUnixNano, 10, 64)
+	if err != nil || time.Since(time.Unix(0, nanos)) > time.Minute || info.TimeUnixNano != info.ObservedTimeUnixNano {
+		t.Errorf("时间错误: %+v", info)
+	}
+	attrs := attributesOf(info)
+	if attrs["count"] != "int:3" || attrs["ratio"] != "double:0.5" || attrs["ok"] != "true" {
+		t.Errorf("属性错误: %v", attrs)
+	}
+
+	if e := scope.LogRecords[1]; e.SeverityNumber != otlpSeverityError || e.Body.String() != "{code=int:500}" {
+		t.Errorf("error 日志错误: %+v", e)
+	}
+	if slow := scope.LogRecords[2]; slow.SeverityNumber != otlpSeverityWarn || slow.SeverityText != levelSlow {
+		t.Errorf("slow 日志错误: %+v", slow)
+	}
+}
+
+func TestOTLPWriterTrace(t *testing.T) {
+	resetGlobalFields(t)
+	const (
+		traceId = "4BF92F3577B34DA6A3CE929D0E0E4736"
+		spanId  = "00f067aa0ba902b7"
+	)
+
+	req := exportOTLP(t, func(w *ShipWriter) {
+		old := writer.Swap(w)
+		defer writer.Store(old)
+
+		WithContext(ContextWithTrace(context.Background(), traceId, spanId)).Infow("traced")
+		// 不合法的 id 作为普通属性
+		WithContext(ContextWithTrace(context.Background(), "abc", strings.Repeat("0", 16))).Infow("invalid")
+	})
+
+	records := req.ResourceLogs[0].ScopeLogs[0].LogRecords
+	if len(records) != 2 {
+		t.Fatalf("期望 2 条日志, 实际 %d", len(records))
+	}
+	if records[0].TraceId != strings.ToLower(traceId) || records[0].SpanId != spanId {
+		t.Errorf("trace 错误: %+v", records[0])
+	}
+	if attrs := attributesOf(records[0]); len(attrs[defaultTraceKey]) > 0 || len(attrs[defaultSpanKey]) > 0 {
+		t.Errorf("合法的 trace 不应作为属性: %v", attrs)
+	}
+
+	if len(records[1].TraceId) > 0 || len(records[1].SpanId) > 0 {
+		t.Errorf("不合法的 id 不应写入 traceId: %+v", records[1])
+	}
+	if attrs := attributesOf(records[1]); attrs[defaultTraceKey] != "abc" {
+		t.Errorf("属性错误: %v", attrs)
+	}
+}
+
+func TestOTLPWriterDefaultServiceName(t *testing.T) {
+	resetSetup(t)
+	serviceName = ""
+
+	req := exportOTLP(t, func(w *ShipWriter) {
+		w.Info("foo")
+	})
+	resource := req.ResourceLogs[0].Resource.Attributes
+	if len(resource) != 1 || !strings.HasPrefix(resource[0].String(), "service.name=unknown_service:") {
+		t.Errorf("默认服务名错误: %v", resource)
+	}
+}
+
+func TestAppendOTLPValue(t *testing.T) {
+	type user struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+
+	tests := []struct {
+		name   string
+		value  any
+		expect string
+	}{
+		{"nil", nil, "<empty>"},
+		{"string", "foo", "foo"},
+		{"int64", int64(-1), "int:-1"},
+		{"uint64 overflow", uint64(math.MaxUint64), "double:18446744073709552000"},
+		{"nan", math.NaN(), "double:NaN"},
+		{"inf", math.Inf(-1), "double:-Infinity"},
+		{"duration", time.Second, "1s"},
+		{"error", errors.New("failed"), "failed"},
+		{"struct", user{Name: "foo", Tags: []string{"a", "b"}}, "{name=foo,tags=[a,b]}"},
+		{"slice", []int{1, 2}, "[int:1,int:2]"},
+		{"float in json", map[string]float64{"x": 1.5}, "{x=double:1.5}"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v otlpAnyValue
+			data := appendOTLPValue(nil, test.value)
+			if err := json.Unmarshal(data, &v); err != nil {
+				t.Fatalf("不是合法的 JSON: %s", data)
+			}
+			if v.String() != test.expect {
+				t.Errorf("期望 %s, 实际 %s", test.expect, v.String())
+			}
+		})
+	}
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	tests := map[string]int{
+		levelDebug:  otlpSeverityDebug,
+		levelInfo:   otlpSeverityInfo,
+		levelStat:   otlpSeverityInfo,
+		levelSlow:   otlpSeverityWarn,
+		levelError:  otlpSeverityError,
+		levelAlert:  otlpSeverityError2,
+		levelSevere: otlpSeverityError3,
+		levelFatal:  otlpSeverityFatal,
+	}
+	for level, expect := range tests {
+		if actual := otlpSeverity(level); actual != expect {
+			t.Errorf("%s 期望 %d, 实际 %d", level, expect, actual)
+		}
+	}
+}
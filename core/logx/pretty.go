@@ -0,0 +1,148 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YunFy26/mini-zero/core/color"
+)
+
+const (
+	// 级别列宽度，按最长的 severe 对齐
+	prettyLevelWidth = 6
+	// 有字段时内容列的最小宽度，使字段对齐
+	prettyContentWidth = 40
+	prettyIndent       = "  "
+)
+
+// 相对时间的起点，pretty 编码输出自进程启动以来经过的时间，与时间戳一样取自 clock
+var prettyStartTime = clock.Now()
+
+// writePretty 以便于阅读的格式写入日志，仅用于本地开发
+// 每行依次为：自进程启动以来的相对时间、着色的级别、内容、标量字段，结构化字段在后续行缩进展示
+func writePretty(writer io.Writer, keys *systemKeys, level string, val any, fields []LogField) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	*buf = appendPrettyEntry(*buf, keys, clock.Since(prettyStartTime), level, val, fields)
+	if writer == nil {
+		log.Print(string(*buf))
+		return
+	}
+	if _, err := writer.Write(*buf); err != nil {
+		log.Println("failed to write log:", err)
+	}
+}
+
+func appendPrettyEntry(b []byte, keys *systemKeys, elapsed time.Duration, level string, val any,
+	fields []LogField) []byte {
+	timestamp := fmt.Sprintf("+%9.3fs", elapsed.Seconds())
+	padded := fmt.Sprintf("%-*s", prettyLevelWidth, strings.ToUpper(level))
+	// 续行与内容列对齐，颜色控制符不占宽度
+	indent := strings.Repeat(" ", len(timestamp)+len(padded)+2)
+
+	b = append(b, timestamp...)
+	b = append(b, ' ')
+	if colour := levelColor(level); colour != color.NoColor {
+		b = append(b, color.WithColor(padded, colour)...)
+	} else {
+		b = append(b, padded...)
+	}
+	b = append(b, ' ')
+
+	var inline, blocks []LogField
+	for _, field := range dedupFields(fields) {
+		field.Value = maskField(field.Key, field.Value)
+		field.Key = fieldKey(keys, field.Key)
+		if isStructured(field.Value) {
+			blocks = append(blocks, field)
+		} else {
+			inline = append(inline, field)
+		}
+	}
+
+	content, structured := prettyContent(val)
+	multiline := structured || strings.Contains(content, "\n")
+	if structured {
+		b = appendIndentedJson(b, []byte(content), indent)
+	} else {
+		// 多行内容（如堆栈）的续行同样缩进
+		b = append(b, strings.ReplaceAll(content, "\n", "\n"+indent)...)
+	}
+
+	if len(inline) > 0 {
+		if multiline {
+			b = append(b, '\n')
+			b = append(b, indent...)
+		} else if n := utf8.RuneCountInString(content); n < prettyContentWidth {
+			b = append(b, strings.Repeat(" ", prettyContentWidth-n)...)
+		}
+		for i, field := range inline {
+			if i > 0 || !multiline {
+				b = append(b, ' ')
+			}
+			b = appendPrettyKey(b, field.Key)
+			b = appendLogfmtValue(b, field.Value)
+		}
+	}
+
+	for _, field := range blocks {
+		b = append(b, '\n')
+		b = append(b, indent...)
+		b = appendPrettyKey(b, field.Key)
+		b = appendIndentedJson(b, appendJsonValue(nil, field.Value), indent)
+	}
+
+	return append(b, '\n')
+}
+
+func appendPrettyKey(b []byte, key string) []byte {
+	b = append(b, color.WithColor(key, color.FgCyan)...)
+	return append(b, '=')
+}
+
+// appendIndentedJson 多行输出 JSON，续行按 indent 缩进
+func appendIndentedJson(b, content []byte, indent string) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, content, indent, prettyIndent); err != nil {
+		return append(b, content...)
+	}
+
+	return append(b, buf.Bytes()...)
+}
+
+// isStructured 判断值编码为 JSON 后是否为非空的对象或数组
+func isStructured(v any) bool {
+	switch v.(type) {
+	case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64,
+		float32, float64, time.Duration, time.Time, error:
+		return false
+	}
+
+	content := appendJsonValue(nil, v)
+	return len(content) > 2 && (content[0] == '{' || content[0] == '[')
+}
+
+// prettyContent 返回内容的文本形式，结构化的内容返回 JSON
+func prettyContent(val any) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, false
+	case error:
+		return encodeError(v), false
+	case fmt.Stringer:
+		return encodeStringer(v), false
+	}
+
+	if isStructured(val) {
+		return string(appendJsonValue(nil, val)), true
+	}
+
+	return string(appendLogfmtValue(nil, val)), false
+}
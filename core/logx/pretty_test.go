@@ -0,0 +1,80 @@
+package logx
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAppendPrettyEntry(t *testing.T) {
+	t.Run("对齐和结构化字段", func(t *testing.T) {
+		got := string(appendPrettyEntry(nil, defaultSystemKeys, 1234*time.Millisecond, levelInfo, "user login", []LogField{
+			Field("user", "alice"),
+			Field("req", map[string]any{"id": 1}),
+			Field("msg", "has space"),
+			Field("password", maskedPassword("secret")),
+		}))
+		want := "+    1.234s INFO   user login                               user=alice msg=\"has space\" password=******\n" +
+			"                   req={\n" +
+			"                     \"id\": 1\n" +
+			"                   }\n"
+		if got != want {
+			t.Errorf("pretty 输出错误:\n实际\n%s\n期望\n%s", got, want)
+		}
+	})
+
+	t.Run("多行内容", func(t *testing.T) {
+		got := string(appendPrettyEntry(nil, defaultSystemKeys, time.Second, levelSevere, errors.New("boom\nstack"),
+			[]LogField{Field("k", "v")}))
+		want := "+    1.000s SEVERE boom\n" +
+			"                   stack\n" +
+			"                   k=v\n"
+		if got != want {
+			t.Errorf("pretty 多行内容错误:\n实际\n%s\n期望\n%s", got, want)
+		}
+	})
+
+	t.Run("结构化内容", func(t *testing.T) {
+		got := string(appendPrettyEntry(nil, defaultSystemKeys, time.Second, levelDebug, map[string]int{"a": 1}, nil))
+		want := "+    1.000s DEBUG  {\n" +
+			"                     \"a\": 1\n" +
+			"                   }\n"
+		if got != want {
+			t.Errorf("pretty 结构化内容错误:\n实际\n%s\n期望\n%s", got, want)
+		}
+	})
+}
+
+func TestPrettyOutput(t *testing.T) {
+	resetGlobalFields(t)
+	old := atomic.SwapUint32(&encoding, prettyEncodingType)
+	defer atomic.StoreUint32(&encoding, old)
+	oldLen := atomic.SwapUint32(&maxContentLength, 5)
+	defer atomic.StoreUint32(&maxContentLength, oldLen)
+
+	var buf bytes.Buffer
+	output(&buf, levelInfo, "message too long")
+	if got := buf.String(); !strings.Contains(got, "INFO   messa") || !strings.Contains(got, "truncated=true") {
+		t.Errorf("pretty 应复用截断逻辑, 实际 %s", got)
+	}
+}
+
+func TestPrettyElapsed(t *testing.T) {
+	resetGlobalFields(t)
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	oldStart := prettyStartTime
+	prettyStartTime = fake.Now()
+	defer func() {
+		prettyStartTime = oldStart
+	}()
+
+	var buf bytes.Buffer
+	fake.Advance(1500 * time.Millisecond)
+	writePretty(&buf, defaultSystemKeys, levelInfo, "hello", nil)
+	if got := buf.String(); !strings.HasPrefix(got, "+    1.500s ") {
+		t.Errorf("相对时间应取自 clock, 实际 %q", got)
+	}
+}
@@ -0,0 +1,367 @@
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx"
+)
+
+const (
+	defaultContentKey   = "content"
+	defaultLevelKey     = "level"
+	defaultTimestampKey = "@timestamp"
+	defaultTraceKey     = "trace"
+	// 与 logx 的默认时间格式保持一致
+	defaultTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+	nilAngleString    = "<nil>"
+	plainEncodingSep  = '\t'
+)
+
+var (
+	// 输出到终端的纯文本日志中，级别带有颜色
+	colorPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	// 与 logx 一致，plain 编码内容中的控制字符转义
+	plainContentEscaper = strings.NewReplacer("\t", `\t`, "\r", `\r`, "\n", `\n`)
+)
+
+type (
+	// Entry is a log entry read from the log files.
+	// The lines that are not written by logx, like the stack traces, have no Time and Level,
+	// and the whole line as Content.
+	Entry struct {
+		Time    time.Time
+		Level   string
+		Content any
+		Fields  []logx.LogField
+		Raw     string
+	}
+
+	// FieldKeys are the keys of the system fields, the same as logx.LogConf.FieldKeys.
+	// The empty keys are defaulted to the ones of logx.
+	FieldKeys struct {
+		Content   string
+		Level     string
+		Timestamp string
+		Trace     string
+	}
+
+	// parser 解析 json、plain 和 logfmt 编码的日志
+	parser struct {
+		keys   FieldKeys
+		layout string
+	}
+)
+
+// AppendPlain appends the entry to b in the plain encoding of logx, with the timestamp
+// formatted by layout. The lines that are not written by logx are appended as is.
+func (e Entry) AppendPlain(b []byte, layout string) []byte {
+	if e.Time.IsZero() && len(e.Level) == 0 {
+		return append(b, e.Raw...)
+	}
+
+	b = e.Time.AppendFormat(b, layout)
+	b = append(b, plainEncodingSep)
+	b = append(b, e.Level...)
+	b = append(b, plainEncodingSep)
+	b = append(b, escapePlainContent(formatValue(e.Content))...)
+	for _, field := range e.Fields {
+		b = append(b, plainEncodingSep)
+		b = append(b, quotePlain(field.Key)...)
+		b = append(b, '=')
+		b = append(b, quotePlain(formatValue(field.Value))...)
+	}
+
+	return b
+}
+
+// Field returns the value of the field with the given key, the last one wins if the key
+// appears more than once.
+func (e Entry) Field(key string) (any, bool) {
+	for i := len(e.Fields) - 1; i >= 0; i-- {
+		if e.Fields[i].Key == key {
+			return e.Fields[i].Value, true
+		}
+	}
+
+	return nil, false
+}
+
+// Message returns the content as a string.
+func (e Entry) Message() string {
+	return formatValue(e.Content)
+}
+
+func (keys FieldKeys) withDefaults() FieldKeys {
+	if len(keys.Content) == 0 {
+		keys.Content = defaultContentKey
+	}
+	if len(keys.Level) == 0 {
+		keys.Level = defaultLevelKey
+	}
+	if len(keys.Timestamp) == 0 {
+		keys.Timestamp = defaultTimestampKey
+	}
+	if len(keys.Trace) == 0 {
+		keys.Trace = defaultTraceKey
+	}
+
+	return keys
+}
+
+// parse 按行首判断编码，无法解析的行整行作为内容
+func (p parser) parse(line string) Entry {
+	var entry Entry
+	var ok bool
+	switch {
+	case strings.HasPrefix(line, "{"):
+		entry, ok = p.parseJson(line)
+	case strings.HasPrefix(line, p.keys.Timestamp+"="):
+		entry, ok = p.parseLogfmt(line)
+	default:
+		entry, ok = p.parsePlain(line)
+	}
+	if !ok {
+		return Entry{
+			Content: line,
+			Raw:     line,
+		}
+	}
+
+	entry.Raw = line
+	return entry
+}
+
+// parseJson 逐个读取键值，保持字段的写入顺序
+func (p parser) parseJson(line string) (Entry, bool) {
+	decoder := json.NewDecoder(strings.NewReader(line))
+	decoder.UseNumber()
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return Entry{}, false
+	}
+
+	var entry Entry
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return Entry{}, false
+		}
+		key, ok := token.(string)
+		if !ok {
+			return Entry{}, false
+		}
+
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return Entry{}, false
+		}
+		p.setField(&entry, key, value)
+	}
+
+	return entry, true
+}
+
+// parseLogfmt 解析 logfmt 编码的日志，带引号的值按 JSON 字符串转义
+func (p parser) parseLogfmt(line string) (Entry, bool) {
+	var entry Entry
+	for s := line; len(s) > 0; s = strings.TrimLeft(s, " ") {
+		index := strings.IndexByte(s, '=')
+		if index <= 0 {
+			return Entry{}, false
+		}
+		key := s[:index]
+		s = s[index+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			quoted, ok := quotedPrefix(s)
+			if !ok {
+				return Entry{}, false
+			}
+			if err := json.Unmarshal([]byte(quoted), &value); err != nil {
+				return Entry{}, false
+			}
+			s = s[len(quoted):]
+		} else if end := strings.IndexByte(s, ' '); end >= 0 {
+			value = s[:end]
+			s = s[end:]
+		} else {
+			value = s
+			s = ""
+		}
+		p.setField(&entry, key, value)
+	}
+
+	return entry, !entry.Time.IsZero()
+}
+
+// parsePlain 解析 plain 编码的日志：时间、级别、内容和 key=value 字段以制表符分隔
+// 内容中的制表符和换行已转义为 \t 和 \n，与原样输出的反斜杠无法区分，因此保留转义后的形式
+func (p parser) parsePlain(line string) (Entry, bool) {
+	parts := strings.Split(line, string(plainEncodingSep))
+	if len(parts) < 3 {
+		return Entry{}, false
+	}
+
+	t, ok := p.parseTime(parts[0])
+	if !ok {
+		return Entry{}, false
+	}
+
+	entry := Entry{
+		Time:  t,
+		Level: strings.TrimSpace(colorPattern.ReplaceAllString(parts[1], "")),
+	}
+	content := parts[2]
+	for _, item := range parts[3:] {
+		key, value, ok := splitPlainField(item)
+		if !ok {
+			// 旧版本的日志内容中的制表符未转义，字段之前无法解析的部分归入内容
+			if len(entry.Fields) == 0 {
+				content += string(plainEncodingSep) + item
+			}
+			continue
+		}
+
+		entry.Fields = append(entry.Fields, logx.LogField{
+			Key:   key,
+			Value: value,
+		})
+	}
+	entry.Content = content
+
+	return entry, true
+}
+
+func (p parser) parseTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(p.layout, s); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// setField 系统字段设置到 Entry 上，时间无法解析时作为普通字段保留
+func (p parser) setField(entry *Entry, key string, value any) {
+	switch key {
+	case p.keys.Timestamp:
+		if s, ok := value.(string); ok {
+			if t, ok := p.parseTime(s); ok {
+				entry.Time = t
+				return
+			}
+		}
+	case p.keys.Level:
+		entry.Level = formatValue(value)
+		return
+	case p.keys.Content:
+		entry.Content = value
+		return
+	}
+
+	entry.Fields = append(entry.Fields, logx.LogField{
+		Key:   key,
+		Value: value,
+	})
+}
+
+// formatValue 与 logx 的 plain 编码一致，结构化的值编码为 JSON
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return nilAngleString
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case map[string]any, []any:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprintf("%+v", val)
+		}
+		return string(data)
+	default:
+		return fmt.Sprintf("%+v", val)
+	}
+}
+
+// escapePlainContent 与 logx 一致，将内容中的制表符、回车和换行转义
+func escapePlainContent(s string) string {
+	if !strings.ContainsAny(s, "\t\n\r") {
+		return s
+	}
+
+	return plainContentEscaper.Replace(s)
+}
+
+// quotePlain 与 logx 一致，包含分隔符、换行、等号或引号的字段值加引号并转义
+func quotePlain(s string) string {
+	if len(s) == 0 {
+		return `""`
+	}
+	if strings.ContainsAny(s, "\t\n\r=\"") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// quotedPrefix 返回 s 开头以双引号包围的部分，包含引号
+func quotedPrefix(s string) (string, bool) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return s[:i+1], true
+		}
+	}
+
+	return "", false
+}
+
+// splitPlainField 解析 quotePlain 编码的 key=value
+func splitPlainField(item string) (key, value string, ok bool) {
+	rest := item
+	if strings.HasPrefix(rest, `"`) {
+		quoted, ok := quotedPrefix(rest)
+		if !ok {
+			return "", "", false
+		}
+		if key, ok = unquote(quoted); !ok {
+			return "", "", false
+		}
+		rest = rest[len(quoted):]
+	} else {
+		index := strings.IndexByte(rest, '=')
+		if index <= 0 {
+			return "", "", false
+		}
+		key = rest[:index]
+		rest = rest[index:]
+	}
+
+	if !strings.HasPrefix(rest, "=") {
+		return "", "", false
+	}
+	value = rest[1:]
+	if strings.HasPrefix(value, `"`) {
+		if value, ok = unquote(value); !ok {
+			return "", "", false
+		}
+	}
+
+	return key, value, true
+}
+
+func unquote(s string) (string, bool) {
+	v, err := strconv.Unquote(s)
+	return v, err == nil
+}
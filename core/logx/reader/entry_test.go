@@ -0,0 +1,143 @@
+package reader
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx"
+)
+
+var testParser = parser{
+	keys:   FieldKeys{}.withDefaults(),
+	layout: defaultTimeFormat,
+}
+
+func TestParseJson(t *testing.T) {
+	line := `{"@timestamp":"2024-01-02T03:04:05.678Z","level":"error","content":"db down","trace":"abc","status":500,"user":{"name":"alice"}}`
+	entry := testParser.parse(line)
+
+	if !entry.Time.Equal(time.Date(2024, 1, 2, 3, 4, 5, 678000000, time.UTC)) {
+		t.Errorf("时间错误: %v", entry.Time)
+	}
+	if entry.Level != "error" || entry.Message() != "db down" || entry.Raw != line {
+		t.Errorf("解析错误: %+v", entry)
+	}
+	if len(entry.Fields) != 3 || entry.Fields[0].Key != "trace" || entry.Fields[2].Key != "user" {
+		t.Errorf("字段顺序错误: %v", entry.Fields)
+	}
+	if status, _ := entry.Field("status"); status != json.Number("500") {
+		t.Errorf("数字应保持原样: %#v", status)
+	}
+}
+
+func TestParsePlain(t *testing.T) {
+	line := "2024-01-02T03:04:05.678+08:00\t\x1b[32m info \x1b[0m\thello\tworld\ttrace=abc\t\"a=b\"=\"x\\ty\"\tcaller=main.go:10"
+	entry := testParser.parse(line)
+
+	if entry.Time.IsZero() || entry.Level != "info" {
+		t.Errorf("解析错误: %+v", entry)
+	}
+	if entry.Message() != "hello\tworld" {
+		t.Errorf("内容中的制表符应保留: %q", entry.Message())
+	}
+	if v, ok := entry.Field("a=b"); !ok || v != "x\ty" {
+		t.Errorf("带引号的字段解析错误: %v", entry.Fields)
+	}
+	if v, _ := entry.Field("caller"); v != "main.go:10" {
+		t.Errorf("字段解析错误: %v", entry.Fields)
+	}
+}
+
+func TestParsePlainEscapedContent(t *testing.T) {
+	line := "2024-01-02T03:04:05.678Z\terror\tfailed key=value\\n\\tat main.go:10\ttrace=abc"
+	entry := testParser.parse(line)
+
+	// 内容保留转义后的形式，等号不影响内容的解析
+	if entry.Message() != `failed key=value\n\tat main.go:10` {
+		t.Errorf("内容解析错误: %q", entry.Message())
+	}
+	if v, _ := entry.Field("trace"); v != "abc" {
+		t.Errorf("字段解析错误: %v", entry.Fields)
+	}
+	if actual := string(entry.AppendPlain(nil, defaultTimeFormat)); actual != line {
+		t.Errorf("解析后重新编码应保持一致:\n%s\n%s", actual, line)
+	}
+
+	// 其他格式的内容中的换行输出为 plain 时转义
+	entry.Content = "a\nb"
+	if actual := string(entry.AppendPlain(nil, defaultTimeFormat)); !strings.Contains(actual, "\ta\\nb\t") {
+		t.Errorf("内容中的换行应转义: %q", actual)
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	line := `@timestamp=2024-01-02T03:04:05.678Z level=info content="user \"alice\" login" duration=1.5ms`
+	entry := testParser.parse(line)
+
+	if entry.Time.IsZero() || entry.Level != "info" || entry.Message() != `user "alice" login` {
+		t.Errorf("解析错误: %+v", entry)
+	}
+	if v, _ := entry.Field("duration"); v != "1.5ms" {
+		t.Errorf("字段解析错误: %v", entry.Fields)
+	}
+}
+
+func TestParseUnknown(t *testing.T) {
+	for _, line := range []string{"goroutine 1 [running]:", "{broken", "2024-01-02\tinfo"} {
+		entry := testParser.parse(line)
+		if !entry.Time.IsZero() || len(entry.Level) > 0 || entry.Raw != line || entry.Message() != line {
+			t.Errorf("无法解析的行应整行作为内容: %+v", entry)
+		}
+	}
+}
+
+func TestParseFieldKeys(t *testing.T) {
+	p := parser{
+		keys:   FieldKeys{Timestamp: "ts", Level: "lvl", Content: "msg"}.withDefaults(),
+		layout: time.RFC3339,
+	}
+	entry := p.parse(`{"ts":"2024-01-02T03:04:05Z","lvl":"info","msg":"hi","trace":"abc"}`)
+
+	if entry.Time.IsZero() || entry.Level != "info" || entry.Message() != "hi" {
+		t.Errorf("自定义键名解析错误: %+v", entry)
+	}
+	if p.keys.Trace != defaultTraceKey {
+		t.Errorf("未设置的键名应使用默认值: %s", p.keys.Trace)
+	}
+}
+
+func TestAppendPlain(t *testing.T) {
+	entry := Entry{
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Level:   "info",
+		Content: "hello",
+		Fields: []logx.LogField{
+			{Key: "status", Value: json.Number("200")},
+			{Key: "user", Value: map[string]any{"name": "alice"}},
+			{Key: "a=b", Value: "x y"},
+			{Key: "empty", Value: ""},
+			{Key: "nil", Value: nil},
+		},
+	}
+
+	expect := "2024-01-02T03:04:05.000Z\tinfo\thello\tstatus=200\tuser=\"{\\\"name\\\":\\\"alice\\\"}\"\t\"a=b\"=x y\tempty=\"\"\tnil=<nil>"
+	if actual := string(entry.AppendPlain(nil, defaultTimeFormat)); actual != expect {
+		t.Errorf("plain 编码错误:\n%s\n%s", actual, expect)
+	}
+
+	raw := Entry{Raw: "goroutine 1 [running]:"}
+	if actual := string(raw.AppendPlain(nil, defaultTimeFormat)); actual != raw.Raw {
+		t.Errorf("无法解析的行应原样输出: %s", actual)
+	}
+}
+
+func TestPlainRoundTrip(t *testing.T) {
+	line := "2024-01-02T03:04:05.000Z\terror\tfailed\t\"k\\\"ey\"=\"a\\nb\"\tcount=3"
+	entry := testParser.parse(line)
+
+	if actual := string(entry.AppendPlain(nil, defaultTimeFormat)); actual != line {
+		t.Errorf("解析后重新编码应保持一致:\n%s\n%s", actual, line)
+	}
+}
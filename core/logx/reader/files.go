@@ -0,0 +1,152 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 与 logx 中轮转的备份文件保持一致
+	defaultDelimiter = "-"
+	gzipExt          = ".gz"
+	// 旧的备份文件名中的时间只精确到秒，且日志在写入前就取了时间，按时间跳过文件时留出余量
+	skewTolerance = time.Minute
+)
+
+// logFile 日志文件或备份文件，start 为备份文件名中的时间，即该文件开始写入的时间
+// seq 为同一时刻轮转的备份文件的序号
+type logFile struct {
+	name  string
+	start time.Time
+	seq   int
+	live  bool
+}
+
+// listFiles 按时间顺序返回 filename 的备份文件和 filename 本身，支持两种轮转规则的备份文件：
+//   - 按天轮转：access.log-2024-01-02
+//   - 按大小轮转：access-2024-01-02T15:04:05.000+08:00.log，同一时刻轮转的备份文件带有序号，
+//     如 access-2024-01-02T15:04:05.000+08:00.1.log
+//
+// 两者都可能以 .gz 压缩，压缩过程中未压缩的文件和压缩文件同时存在，此时使用未压缩的文件
+func listFiles(filename, delimiter string) ([]logFile, error) {
+	dir := filepath.Dir(filename)
+	base := filepath.Base(filename)
+	ext := filepath.Ext(base)
+	prefix := base[:len(base)-len(ext)]
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	backups := make(map[string]logFile)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		plain := strings.TrimSuffix(name, gzipExt)
+		start, seq, ok := parseBackupTime(plain, base, prefix, ext, delimiter)
+		if !ok {
+			continue
+		}
+		if _, ok := backups[plain]; ok && name != plain {
+			continue
+		}
+
+		backups[plain] = logFile{
+			name:  filepath.Join(dir, name),
+			start: start,
+			seq:   seq,
+		}
+	}
+
+	files := make([]logFile, 0, len(backups)+1)
+	for _, file := range backups {
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].start.Equal(files[j].start) {
+			if files[i].seq != files[j].seq {
+				return files[i].seq < files[j].seq
+			}
+			return files[i].name < files[j].name
+		}
+		return files[i].start.Before(files[j].start)
+	})
+
+	if _, err := os.Stat(filename); err == nil {
+		files = append(files, logFile{
+			name: filename,
+			live: true,
+		})
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// parseBackupTime 解析备份文件名中的时间和序号，不是 filename 的备份文件时返回 false
+func parseBackupTime(name, base, prefix, ext, delimiter string) (time.Time, int, bool) {
+	if strings.HasPrefix(name, base+delimiter) {
+		if t, ok := parseFileTime(name[len(base)+len(delimiter):]); ok {
+			return t, 0, true
+		}
+	}
+
+	if len(ext) > 0 && strings.HasPrefix(name, prefix+delimiter) && strings.HasSuffix(name, ext) &&
+		len(name) > len(prefix)+len(delimiter)+len(ext) {
+		s := name[len(prefix)+len(delimiter) : len(name)-len(ext)]
+		if t, ok := parseFileTime(s); ok {
+			return t, 0, true
+		}
+
+		// 同一时刻轮转的备份文件，时间后带有序号
+		index := strings.LastIndexByte(s, '.')
+		if index < 0 {
+			return time.Time{}, 0, false
+		}
+		seq, err := strconv.Atoi(s[index+1:])
+		if err != nil || seq <= 0 {
+			return time.Time{}, 0, false
+		}
+		if t, ok := parseFileTime(s[:index]); ok {
+			return t, seq, true
+		}
+	}
+
+	return time.Time{}, 0, false
+}
+
+// parseFileTime 解析备份文件名中的时间，RFC3339 兼容精确到秒和毫秒的时间
+func parseFileTime(s string) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation(time.DateOnly, s, time.Local); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
+
+// skipBefore 去掉不包含 since 之后日志的备份文件
+// 备份文件中的日志都早于下一个备份文件的开始时间，最后一个备份文件的结束时间未知，不能跳过
+func skipBefore(files []logFile, since time.Time) []logFile {
+	if since.IsZero() {
+		return files
+	}
+
+	var i int
+	for i+1 < len(files) && !files[i+1].live && files[i+1].start.Add(skewTolerance).Before(since) {
+		i++
+	}
+
+	return files[i:]
+}
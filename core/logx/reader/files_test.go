@@ -0,0 +1,136 @@
+package reader
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func fileNames(files []logFile) []string {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filepath.Base(file.name))
+	}
+	return names
+}
+
+func TestListFilesDaily(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "access.log", "access.log-2024-01-03", "access.log-2024-01-01.gz",
+		"access.log-2024-01-02", "access.log-foo", "error.log-2024-01-01")
+
+	files, err := listFiles(filepath.Join(dir, "access.log"), defaultDelimiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"access.log-2024-01-01.gz", "access.log-2024-01-02", "access.log-2024-01-03", "access.log"}
+	if names := fileNames(files); !reflect.DeepEqual(names, expect) {
+		t.Errorf("文件顺序错误: %v", names)
+	}
+	if !files[3].live || files[2].live {
+		t.Error("只有最后一个是日志文件")
+	}
+}
+
+func TestListFilesSizeLimit(t *testing.T) {
+	dir := t.TempDir()
+	// 时区不同时按时间而不是文件名排序
+	writeFiles(t, dir, "access-2024-01-02T10:00:00+08:00.log.gz", "access-2024-01-02T01:00:00Z.log",
+		"access-2024-01-02T08:00:00+08:00.log", "access-bad.log")
+
+	files, err := listFiles(filepath.Join(dir, "access.log"), defaultDelimiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"access-2024-01-02T08:00:00+08:00.log", "access-2024-01-02T01:00:00Z.log",
+		"access-2024-01-02T10:00:00+08:00.log.gz"}
+	if names := fileNames(files); !reflect.DeepEqual(names, expect) {
+		t.Errorf("文件顺序错误: %v", names)
+	}
+}
+
+func TestListFilesSameTime(t *testing.T) {
+	dir := t.TempDir()
+	// 同一时刻轮转的备份文件按序号排序
+	writeFiles(t, dir, "access-2024-01-02T10:00:00.000Z.10.log", "access-2024-01-02T10:00:00.000Z.2.log.gz",
+		"access-2024-01-02T10:00:00.000Z.log", "access-2024-01-02T09:59:59Z.log", "access-2024-01-02T10:00:00.000Z.x.log")
+
+	files, err := listFiles(filepath.Join(dir, "access.log"), defaultDelimiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []string{"access-2024-01-02T09:59:59Z.log", "access-2024-01-02T10:00:00.000Z.log",
+		"access-2024-01-02T10:00:00.000Z.2.log.gz", "access-2024-01-02T10:00:00.000Z.10.log"}
+	if names := fileNames(files); !reflect.DeepEqual(names, expect) {
+		t.Errorf("文件顺序错误: %v", names)
+	}
+}
+
+func TestListFilesCompressing(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "access.log-2024-01-01", "access.log-2024-01-01.gz")
+
+	files, err := listFiles(filepath.Join(dir, "access.log"), defaultDelimiter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := fileNames(files); !reflect.DeepEqual(names, []string{"access.log-2024-01-01"}) {
+		t.Errorf("压缩过程中应使用未压缩的文件: %v", names)
+	}
+}
+
+func TestListFilesDelimiter(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "access.log_2024-01-01", "access.log-2024-01-02")
+
+	files, err := listFiles(filepath.Join(dir, "access.log"), "_")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names := fileNames(files); !reflect.DeepEqual(names, []string{"access.log_2024-01-01"}) {
+		t.Errorf("分隔符错误: %v", names)
+	}
+}
+
+func TestSkipBefore(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 0, 0, 0, 0, time.Local)
+	}
+	files := []logFile{
+		{name: "a", start: day(1)},
+		{name: "b", start: day(2)},
+		{name: "c", start: day(3)},
+		{name: "live", live: true},
+	}
+
+	tests := []struct {
+		since  time.Time
+		expect []string
+	}{
+		{time.Time{}, []string{"a", "b", "c", "live"}},
+		{day(1).Add(time.Hour), []string{"a", "b", "c", "live"}},
+		{day(2).Add(time.Hour), []string{"b", "c", "live"}},
+		// 最后一个备份文件的结束时间未知
+		{day(10), []string{"c", "live"}},
+	}
+	for _, test := range tests {
+		if names := fileNames(skipBefore(files, test.since)); !reflect.DeepEqual(names, test.expect) {
+			t.Errorf("since %v 的结果错误: %v", test.since, names)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package reader
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 按长度从长到短排列，保证 >= 不会被解析为 >
+var exprOps = []string{"!=", "!~", ">=", "<=", "=", "~", ">", "<"}
+
+type (
+	// Filter selects the entries, the zero value matches all the entries.
+	Filter struct {
+		// Levels are the levels to match, empty means all the levels.
+		Levels []string
+		// Since is the inclusive lower bound of the time, zero means no bound.
+		Since time.Time
+		// Until is the exclusive upper bound of the time, zero means no bound.
+		Until time.Time
+		// TraceId is the trace id to match, empty means any trace id.
+		TraceId string
+		// Exprs are the field expressions to match, all of them must be matched.
+		Exprs []Expr
+	}
+
+	// Expr is a field expression parsed by ParseExpr.
+	Expr struct {
+		Key   string
+		Op    string
+		Value string
+		re    *regexp.Regexp
+	}
+)
+
+// ParseExpr parses a field expression, which is a key alone to match the entries having
+// the field, or a key followed by an operator and a value:
+//   - = and != compare the values, numerically if both are numbers
+//   - ~ and !~ match the value with a regular expression
+//   - >, >=, < and <= compare numbers, durations like 100ms, or strings
+//
+// The content and level keys refer to the content and level of the entries.
+func ParseExpr(s string) (Expr, error) {
+	index := strings.IndexAny(s, "!=~<>")
+	if index < 0 {
+		if len(strings.TrimSpace(s)) == 0 {
+			return Expr{}, errors.New("empty expression")
+		}
+		return Expr{Key: strings.TrimSpace(s)}, nil
+	}
+
+	expr := Expr{Key: strings.TrimSpace(s[:index])}
+	if len(expr.Key) == 0 {
+		return Expr{}, fmt.Errorf("missing key in expression: %q", s)
+	}
+	for _, op := range exprOps {
+		if strings.HasPrefix(s[index:], op) {
+			expr.Op = op
+			break
+		}
+	}
+	if len(expr.Op) == 0 {
+		return Expr{}, fmt.Errorf("invalid operator in expression: %q", s)
+	}
+
+	expr.Value = strings.TrimSpace(s[index+len(expr.Op):])
+	if expr.Op == "~" || expr.Op == "!~" {
+		re, err := regexp.Compile(expr.Value)
+		if err != nil {
+			return Expr{}, fmt.Errorf("invalid regular expression in %q: %w", s, err)
+		}
+		expr.re = re
+	}
+
+	return expr, nil
+}
+
+func (e Expr) String() string {
+	return e.Key + e.Op + e.Value
+}
+
+// match 字段不存在时，只有 != 和 !~ 匹配
+func (e Expr) match(value string, ok bool) bool {
+	if len(e.Op) == 0 {
+		return ok
+	}
+	if !ok {
+		return e.Op == "!=" || e.Op == "!~"
+	}
+
+	switch e.Op {
+	case "=":
+		return compare(value, e.Value) == 0
+	case "!=":
+		return compare(value, e.Value) != 0
+	case "~":
+		return e.re.MatchString(value)
+	case "!~":
+		return !e.re.MatchString(value)
+	case ">":
+		return compare(value, e.Value) > 0
+	case ">=":
+		return compare(value, e.Value) >= 0
+	case "<":
+		return compare(value, e.Value) < 0
+	case "<=":
+		return compare(value, e.Value) <= 0
+	default:
+		return false
+	}
+}
+
+// match 系统字段以 keys 中的键名引用
+func (f Filter) match(entry Entry, keys FieldKeys) bool {
+	if len(f.Levels) > 0 && !containsLevel(f.Levels, entry.Level) {
+		return false
+	}
+	if !f.Since.IsZero() && (entry.Time.IsZero() || entry.Time.Before(f.Since)) {
+		return false
+	}
+	if !f.Until.IsZero() && (entry.Time.IsZero() || !entry.Time.Before(f.Until)) {
+		return false
+	}
+	if len(f.TraceId) > 0 {
+		if trace, ok := entry.Field(keys.Trace); !ok || formatValue(trace) != f.TraceId {
+			return false
+		}
+	}
+
+	for _, expr := range f.Exprs {
+		if !expr.match(fieldValue(entry, keys, expr.Key)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compare 两者都是数字或时长时按数值比较，否则按字符串比较
+func compare(a, b string) int {
+	if x, err := strconv.ParseFloat(a, 64); err == nil {
+		if y, err := strconv.ParseFloat(b, 64); err == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+	if x, err := time.ParseDuration(a); err == nil {
+		if y, err := time.ParseDuration(b); err == nil {
+			return cmp.Compare(x, y)
+		}
+	}
+
+	return strings.Compare(a, b)
+}
+
+func containsLevel(levels []string, level string) bool {
+	for _, l := range levels {
+		if strings.EqualFold(l, level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fieldValue(entry Entry, keys FieldKeys, key string) (string, bool) {
+	switch key {
+	case keys.Content:
+		if entry.Content == nil {
+			return "", false
+		}
+		return entry.Message(), true
+	case keys.Level:
+		return entry.Level, len(entry.Level) > 0
+	}
+
+	value, ok := entry.Field(key)
+	if !ok {
+		return "", false
+	}
+
+	return formatValue(value), true
+}
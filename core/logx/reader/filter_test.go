@@ -0,0 +1,121 @@
+package reader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx"
+)
+
+func TestParseExpr(t *testing.T) {
+	tests := []struct {
+		input string
+		key   string
+		op    string
+		value string
+	}{
+		{"status=500", "status", "=", "500"},
+		{"status != 500", "status", "!=", "500"},
+		{"duration>=100ms", "duration", ">=", "100ms"},
+		{"duration<1s", "duration", "<", "1s"},
+		{"content~timeout|refused", "content", "~", "timeout|refused"},
+		{"path!~^/health", "path", "!~", "^/health"},
+		{"user", "user", "", ""},
+	}
+	for _, test := range tests {
+		expr, err := ParseExpr(test.input)
+		if err != nil {
+			t.Errorf("解析 %q 失败: %v", test.input, err)
+			continue
+		}
+		if expr.Key != test.key || expr.Op != test.op || expr.Value != test.value {
+			t.Errorf("解析 %q 错误: %+v", test.input, expr)
+		}
+	}
+
+	for _, input := range []string{"", "=500", "!user", "content~("} {
+		if _, err := ParseExpr(input); err == nil {
+			t.Errorf("解析 %q 应返回错误", input)
+		}
+	}
+}
+
+func TestExprMatch(t *testing.T) {
+	entry := Entry{
+		Level:   "error",
+		Content: "dial tcp: connection refused",
+		Fields: []logx.LogField{
+			{Key: "status", Value: json.Number("503")},
+			{Key: "duration", Value: "1.5s"},
+			{Key: "path", Value: "/api/users"},
+		},
+	}
+	keys := FieldKeys{}.withDefaults()
+
+	tests := []struct {
+		expr   string
+		expect bool
+	}{
+		{"status=503", true},
+		{"status=503.0", true},
+		{"status!=503", false},
+		{"status>=500", true},
+		{"status<500", false},
+		{"duration>500ms", true},
+		{"duration<=1s", false},
+		{"path~^/api/", true},
+		{"path!~^/api/", false},
+		{"content~refused", true},
+		{"level=error", true},
+		{"path", true},
+		{"user", false},
+		{"user=alice", false},
+		{"user!=alice", true},
+	}
+	for _, test := range tests {
+		expr, err := ParseExpr(test.expr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := expr.match(fieldValue(entry, keys, expr.Key)); actual != test.expect {
+			t.Errorf("%s 的匹配结果错误: %t", test.expr, actual)
+		}
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entry := Entry{
+		Time:    now,
+		Level:   "severe",
+		Content: "panic",
+		Fields:  []logx.LogField{{Key: "trace", Value: "abc"}},
+	}
+	keys := FieldKeys{}.withDefaults()
+
+	tests := []struct {
+		name   string
+		filter Filter
+		expect bool
+	}{
+		{"zero", Filter{}, true},
+		{"level", Filter{Levels: []string{"error", "SEVERE"}}, true},
+		{"other level", Filter{Levels: []string{"info"}}, false},
+		{"since", Filter{Since: now}, true},
+		{"after since", Filter{Since: now.Add(time.Second)}, false},
+		{"until", Filter{Until: now}, false},
+		{"before until", Filter{Until: now.Add(time.Second)}, true},
+		{"trace", Filter{TraceId: "abc"}, true},
+		{"other trace", Filter{TraceId: "abd"}, false},
+	}
+	for _, test := range tests {
+		if actual := test.filter.match(entry, keys); actual != test.expect {
+			t.Errorf("%s 的匹配结果错误: %t", test.name, actual)
+		}
+	}
+
+	if (Filter{Since: now}).match(Entry{Raw: "goroutine 1"}, keys) {
+		t.Error("没有时间的行不应匹配时间范围")
+	}
+}
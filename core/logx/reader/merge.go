@@ -0,0 +1,79 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/YunFy26/mini-zero/core/errorx"
+)
+
+type (
+	// Source is a stream of entries, like a Reader.
+	Source interface {
+		// Next returns the next entry, or io.EOF at the end.
+		Next(ctx context.Context) (Entry, error)
+		// Close closes the Source.
+		Close() error
+	}
+
+	mergedSource struct {
+		sources []Source
+		heads   []*Entry
+		done    []bool
+	}
+)
+
+// Merge merges the entries of the sources in chronological order, like the access and
+// error logs of a service. The sources must end with io.EOF, so the following Readers
+// can't be merged, because the merged source needs to peek at every source.
+func Merge(sources ...Source) Source {
+	return &mergedSource{
+		sources: sources,
+		heads:   make([]*Entry, len(sources)),
+		done:    make([]bool, len(sources)),
+	}
+}
+
+func (m *mergedSource) Close() error {
+	var be errorx.BatchError
+	for _, source := range m.sources {
+		be.Add(source.Close())
+	}
+
+	return be.Err()
+}
+
+// Next 时间相同时按 sources 的顺序返回，无法解析时间的行与之前的日志一起返回
+func (m *mergedSource) Next(ctx context.Context) (Entry, error) {
+	next := -1
+	for i, source := range m.sources {
+		if m.done[i] {
+			continue
+		}
+
+		if m.heads[i] == nil {
+			entry, err := source.Next(ctx)
+			if errors.Is(err, io.EOF) {
+				m.done[i] = true
+				continue
+			}
+			if err != nil {
+				return Entry{}, err
+			}
+			m.heads[i] = &entry
+		}
+
+		if next < 0 || m.heads[i].Time.Before(m.heads[next].Time) {
+			next = i
+		}
+	}
+
+	if next < 0 {
+		return Entry{}, io.EOF
+	}
+
+	entry := *m.heads[next]
+	m.heads[next] = nil
+	return entry, nil
+}
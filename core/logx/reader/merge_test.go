@@ -0,0 +1,73 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// errorSource 读取一条日志后返回错误
+type errorSource struct {
+	entries []Entry
+	err     error
+	closed  bool
+}
+
+func (s *errorSource) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *errorSource) Next(_ context.Context) (Entry, error) {
+	if len(s.entries) == 0 {
+		return Entry{}, s.err
+	}
+
+	entry := s.entries[0]
+	s.entries = s.entries[1:]
+	return entry, nil
+}
+
+func TestMerge(t *testing.T) {
+	dir := t.TempDir()
+	access := filepath.Join(dir, "access.log")
+	errorLog := filepath.Join(dir, "error.log")
+	writeFile(t, access+"-2024-01-01", jsonLine("2024-01-01T10:00:00.000Z", "info", "a1"))
+	writeFile(t, access, jsonLine("2024-01-02T10:00:00.000Z", "info", "a2")+
+		jsonLine("2024-01-02T12:00:00.000Z", "info", "a3"))
+	writeFile(t, errorLog, jsonLine("2024-01-01T11:00:00.000Z", "error", "e1")+
+		"goroutine 1 [running]:\n"+
+		jsonLine("2024-01-02T11:00:00.000Z", "error", "e2"))
+
+	var sources []Source
+	for _, file := range []string{access, errorLog} {
+		r, err := Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, r)
+	}
+	source := Merge(sources...)
+	defer source.Close()
+
+	expect := []string{"a1", "e1", "goroutine 1 [running]:", "a2", "e2", "a3"}
+	if contents := readAll(t, source); !reflect.DeepEqual(contents, expect) {
+		t.Errorf("合并结果错误: %v", contents)
+	}
+}
+
+func TestMergeError(t *testing.T) {
+	errDummy := errors.New("dummy")
+	a := &errorSource{err: errDummy}
+	b := &errorSource{err: errDummy}
+	source := Merge(a, b)
+
+	if _, err := source.Next(context.Background()); !errors.Is(err, errDummy) {
+		t.Errorf("应返回读取的错误: %v", err)
+	}
+	if err := source.Close(); err != nil || !a.closed || !b.closed {
+		t.Error("应关闭所有的 Source")
+	}
+}
@@ -0,0 +1,353 @@
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/errorx"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+const defaultPollInterval = time.Second
+
+type (
+	// Option customizes a Reader.
+	Option func(r *Reader)
+
+	// Reader reads the entries of a log file written by logx, including its rotated
+	// backups, in chronological order.
+	Reader struct {
+		filename     string
+		delimiter    string
+		filter       Filter
+		parser       parser
+		follow       bool
+		pollInterval time.Duration
+		clock        timex.Clock
+
+		// 尚未读取的备份文件
+		files []logFile
+		// 当前读取的文件，读取日志文件时 live 非空
+		file   io.Closer
+		reader *bufio.Reader
+		live   *os.File
+		// live 中已读取的字节数和未读完的半行
+		offset  int64
+		pending []byte
+		// 日志文件已被轮转，读完 live 后切换到新的日志文件
+		rotated bool
+	}
+
+	// gzipFile 关闭时同时关闭解压器和文件
+	gzipFile struct {
+		*gzip.Reader
+		file *os.File
+	}
+)
+
+// Open returns a Reader of the given log file, filename is the one configured for logx,
+// like logs/access.log, its backups are found by the rotation naming of logx.
+func Open(filename string, opts ...Option) (*Reader, error) {
+	r := &Reader{
+		filename:     filename,
+		delimiter:    defaultDelimiter,
+		pollInterval: defaultPollInterval,
+		clock:        timex.RealClock(),
+		parser: parser{
+			keys:   FieldKeys{}.withDefaults(),
+			layout: defaultTimeFormat,
+		},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	files, err := listFiles(filename, r.delimiter)
+	if err != nil {
+		return nil, err
+	}
+	r.files = skipBefore(files, r.filter.Since)
+
+	// 先打开日志文件，读取备份文件期间发生轮转时，仍能读到被轮转的日志
+	if n := len(r.files); n > 0 && r.files[n-1].live {
+		if r.live, err = os.Open(filename); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		r.files = r.files[:n-1]
+	}
+
+	return r, nil
+}
+
+// WithDelimiter sets the delimiter in the backup file names, defaults to "-" as logx.
+func WithDelimiter(delimiter string) Option {
+	return func(r *Reader) {
+		r.delimiter = delimiter
+	}
+}
+
+// WithFieldKeys sets the keys of the system fields, for the logs written with
+// logx.LogConf.FieldKeys customized.
+func WithFieldKeys(keys FieldKeys) Option {
+	return func(r *Reader) {
+		r.parser.keys = keys.withDefaults()
+	}
+}
+
+// WithFilter makes the Reader only return the entries matching f.
+func WithFilter(f Filter) Option {
+	return func(r *Reader) {
+		r.filter = f
+	}
+}
+
+// WithFollow makes the Reader wait for the new entries at the end of the log file like
+// tail -F, the log file is checked every interval for new entries and rotation.
+func WithFollow(interval time.Duration) Option {
+	return func(r *Reader) {
+		r.follow = true
+		if interval > 0 {
+			r.pollInterval = interval
+		}
+	}
+}
+
+// WithTimeFormat sets the time format of the timestamps, for the logs written with
+// logx.LogConf.TimeFormat customized.
+func WithTimeFormat(layout string) Option {
+	return func(r *Reader) {
+		r.parser.layout = layout
+	}
+}
+
+// Close closes the Reader.
+func (r *Reader) Close() error {
+	var be errorx.BatchError
+	if r.file != nil && r.file != io.Closer(r.live) {
+		be.Add(r.file.Close())
+	}
+	if r.live != nil {
+		be.Add(r.live.Close())
+	}
+	r.file = nil
+	r.live = nil
+	r.files = nil
+
+	return be.Err()
+}
+
+// Next returns the next entry matching the filter. It returns io.EOF at the end of the
+// log file, or waits for the new entries until ctx is done if following.
+func (r *Reader) Next(ctx context.Context) (Entry, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Entry{}, err
+		}
+
+		line, err := r.readLine()
+		if err == nil {
+			entry := r.parser.parse(line)
+			if r.filter.match(entry, r.parser.keys) {
+				return entry, nil
+			}
+			continue
+		}
+		if !errors.Is(err, io.EOF) {
+			return Entry{}, err
+		}
+
+		if err = r.advance(ctx); err != nil {
+			return Entry{}, err
+		}
+	}
+}
+
+// advance 当前文件读完后，切换到下一个文件，或者等待日志文件写入和轮转
+func (r *Reader) advance(ctx context.Context) error {
+	if r.file != nil && r.file != io.Closer(r.live) {
+		err := r.file.Close()
+		r.file = nil
+		r.reader = nil
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(r.files) > 0 {
+		file := r.files[0]
+		r.files = r.files[1:]
+		return r.openBackup(file.name)
+	}
+
+	if r.live != nil && r.file == nil {
+		r.file = r.live
+		r.reader = bufio.NewReader(r.live)
+		return nil
+	}
+
+	if !r.follow {
+		return io.EOF
+	}
+
+	if r.rotated {
+		// 新的日志文件可能还未创建，此时等待后再试
+		if ok, err := r.reopen(); err != nil || ok {
+			return err
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-r.clock.After(r.pollInterval):
+	}
+
+	return r.check()
+}
+
+// check 检查日志文件是否被创建、轮转或截断
+func (r *Reader) check() error {
+	info, err := os.Stat(r.filename)
+	if err != nil {
+		// 轮转时日志文件会短暂不存在
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if r.live == nil {
+		_, err = r.reopen()
+		return err
+	}
+
+	current, err := r.live.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !os.SameFile(current, info) {
+		// 轮转前的写入已经完成，先读完旧文件剩余的内容
+		r.rotated = true
+	} else if info.Size() < r.offset {
+		if _, err = r.live.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		r.reader.Reset(r.live)
+		r.offset = 0
+		r.pending = nil
+	}
+
+	return nil
+}
+
+func (r *Reader) openBackup(name string) error {
+	fp, err := os.Open(name)
+	if os.IsNotExist(err) && !isGzip(name) {
+		// 列出文件后备份文件已被压缩
+		name += gzipExt
+		fp, err = os.Open(name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !isGzip(name) {
+		r.file = fp
+		r.reader = bufio.NewReader(fp)
+		return nil
+	}
+
+	gr, err := gzip.NewReader(fp)
+	if err != nil {
+		fp.Close()
+		return err
+	}
+
+	r.file = gzipFile{
+		Reader: gr,
+		file:   fp,
+	}
+	r.reader = bufio.NewReader(gr)
+	return nil
+}
+
+// readLine 返回去掉换行符的一行，跳过空行
+// 日志文件的最后一行可能正在写入，跟随时等待写完再返回
+func (r *Reader) readLine() (string, error) {
+	for {
+		if r.reader == nil {
+			return "", io.EOF
+		}
+
+		line, err := r.reader.ReadBytes('\n')
+		if r.file == io.Closer(r.live) {
+			r.offset += int64(len(line))
+		}
+		if len(r.pending) > 0 {
+			line = append(r.pending, line...)
+			r.pending = nil
+		}
+
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return "", err
+			}
+			if len(line) == 0 {
+				return "", io.EOF
+			}
+			if r.follow && r.file == io.Closer(r.live) && !r.rotated {
+				r.pending = line
+				return "", io.EOF
+			}
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 {
+			return string(line), nil
+		}
+		if err != nil {
+			return "", io.EOF
+		}
+	}
+}
+
+// reopen 切换到新的日志文件，从头开始读取，返回日志文件是否存在
+func (r *Reader) reopen() (bool, error) {
+	fp, err := os.Open(r.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if r.live != nil {
+		r.live.Close()
+	}
+	r.live = fp
+	r.file = fp
+	r.reader = bufio.NewReader(fp)
+	r.offset = 0
+	r.pending = nil
+	r.rotated = false
+
+	return true, nil
+}
+
+func (f gzipFile) Close() error {
+	var be errorx.BatchError
+	be.Add(f.Reader.Close(), f.file.Close())
+	return be.Err()
+}
+
+func isGzip(name string) bool {
+	return strings.HasSuffix(name, gzipExt)
+}
@@ -0,0 +1,341 @@
+package reader
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/logx"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+func jsonLine(ts, level, content string) string {
+	return fmt.Sprintf(`{"@timestamp":%q,"level":%q,"content":%q}`, ts, level, content) + "\n"
+}
+
+func writeFile(t *testing.T, name, content string) {
+	if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func appendFile(t *testing.T, name, content string) {
+	fp, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	if _, err = fp.WriteString(content); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeGzip(t *testing.T, name, content string) {
+	fp, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fp.Close()
+
+	w := gzip.NewWriter(fp)
+	if _, err = w.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readAll(t *testing.T, source Source) []string {
+	var contents []string
+	for {
+		entry, err := source.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			return contents
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, entry.Message())
+	}
+}
+
+func TestReaderBackups(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	writeGzip(t, filename+"-2024-01-01.gz", jsonLine("2024-01-01T10:00:00.000Z", "info", "a"))
+	writeFile(t, filename+"-2024-01-02", jsonLine("2024-01-02T10:00:00.000Z", "info", "b")+"\n")
+	writeFile(t, filename, jsonLine("2024-01-03T10:00:00.000Z", "info", "c")+
+		strings.TrimSuffix(jsonLine("2024-01-03T11:00:00.000Z", "info", "d"), "\n"))
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if contents := readAll(t, r); !reflect.DeepEqual(contents, []string{"a", "b", "c", "d"}) {
+		t.Errorf("读取结果错误: %v", contents)
+	}
+	if _, err = r.Next(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("读完后应返回 io.EOF: %v", err)
+	}
+}
+
+func TestReaderLogxOutput(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger, err := logx.NewLogger(filename, logx.DefaultRotateRule(filename, "-", 1, false), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := logx.NewWriter(logger)
+	w.Info("hello", logx.Field("trace", "abc"), logx.Field("status", 200))
+	w.Error("failed", logx.Field("trace", "abd"))
+	// NewWriter 不关闭底层的输出
+	if err = logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(filename, WithFilter(Filter{TraceId: "abc"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	entry, err := r.Next(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Time.IsZero() || entry.Level != "info" || entry.Message() != "hello" {
+		t.Errorf("解析 logx 的日志错误: %+v", entry)
+	}
+	if status, _ := entry.Field("status"); formatValue(status) != "200" {
+		t.Errorf("字段解析错误: %v", entry.Fields)
+	}
+	if _, err = r.Next(context.Background()); !errors.Is(err, io.EOF) {
+		t.Errorf("应只有一条日志匹配: %v", err)
+	}
+}
+
+func TestReaderFilter(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	writeFile(t, filename+"-2024-01-01", jsonLine("2024-01-01T10:00:00.000Z", "error", "old"))
+	writeFile(t, filename+"-2024-01-02", jsonLine("2024-01-02T10:00:00.000Z", "error", "a")+
+		jsonLine("2024-01-02T11:00:00.000Z", "info", "b"))
+	writeFile(t, filename, jsonLine("2024-01-03T10:00:00.000Z", "error", "c"))
+
+	r, err := Open(filename, WithFilter(Filter{
+		Levels: []string{"error"},
+		Since:  time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if len(r.files) != 2 {
+		t.Errorf("应跳过 since 之前的备份文件: %v", r.files)
+	}
+	if contents := readAll(t, r); !reflect.DeepEqual(contents, []string{"a", "c"}) {
+		t.Errorf("过滤结果错误: %v", contents)
+	}
+}
+
+func TestReaderCompressedAfterListing(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	writeFile(t, filename+"-2024-01-01", jsonLine("2024-01-01T10:00:00.000Z", "info", "a"))
+
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// 列出文件后备份文件被压缩
+	writeGzip(t, filename+"-2024-01-01.gz", jsonLine("2024-01-01T10:00:00.000Z", "info", "a"))
+	if err = os.Remove(filename + "-2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+
+	if contents := readAll(t, r); !reflect.DeepEqual(contents, []string{"a"}) {
+		t.Errorf("应读取压缩后的文件: %v", contents)
+	}
+}
+
+func TestReaderNotExist(t *testing.T) {
+	r, err := Open(filepath.Join(t.TempDir(), "access.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	if contents := readAll(t, r); len(contents) != 0 {
+		t.Errorf("不存在的日志应没有内容: %v", contents)
+	}
+
+	if _, err = Open(filepath.Join(t.TempDir(), "none", "access.log")); err == nil {
+		t.Error("目录不存在时应返回错误")
+	}
+}
+
+// follower 在后台读取跟随的日志，通过假时钟控制轮询
+type follower struct {
+	t       *testing.T
+	clock   *timex.FakeClock
+	entries chan Entry
+	errs    chan error
+	cancel  context.CancelFunc
+}
+
+func newFollower(t *testing.T, filename string) *follower {
+	r, err := Open(filename, WithFollow(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &follower{
+		t:       t,
+		clock:   timex.NewFakeClock(time.Now()),
+		entries: make(chan Entry),
+		errs:    make(chan error, 1),
+		cancel:  cancel,
+	}
+	r.clock = f.clock
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			entry, err := r.Next(ctx)
+			if err != nil {
+				f.errs <- err
+				return
+			}
+			f.entries <- entry
+		}
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+		r.Close()
+	})
+
+	return f
+}
+
+// idle 等待读取到文件末尾后触发一次轮询，并等待这次轮询处理完
+// 只能在不会读取到新日志时调用，否则后台读取阻塞在 entries 上
+func (f *follower) idle() {
+	f.clock.BlockUntil(1)
+	f.clock.Advance(time.Second)
+	f.clock.BlockUntil(1)
+}
+
+// expect 等待读取到 content，等待期间不断触发轮询
+func (f *follower) expect(content string) {
+	f.t.Helper()
+
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case entry := <-f.entries:
+			if entry.Message() != content {
+				f.t.Errorf("期望 %q，实际 %q", content, entry.Message())
+			}
+			return
+		case err := <-f.errs:
+			f.t.Fatalf("期望 %q，读取出错: %v", content, err)
+		case <-timeout:
+			f.t.Fatalf("期望 %q，等待超时", content)
+		case <-time.After(10 * time.Millisecond):
+			f.clock.Advance(time.Second)
+		}
+	}
+}
+
+func TestReaderFollow(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	writeFile(t, filename, jsonLine("2024-01-01T10:00:00.000Z", "info", "a"))
+
+	f := newFollower(t, filename)
+	f.expect("a")
+
+	appendFile(t, filename, jsonLine("2024-01-01T10:00:01.000Z", "info", "b"))
+	f.expect("b")
+
+	// 正在写入的半行等待写完再返回
+	line := jsonLine("2024-01-01T10:00:02.000Z", "info", "c")
+	appendFile(t, filename, line[:10])
+	f.idle()
+	appendFile(t, filename, line[10:])
+	f.expect("c")
+}
+
+func TestReaderFollowRotation(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	writeFile(t, filename, jsonLine("2024-01-01T10:00:00.000Z", "info", "a"))
+
+	f := newFollower(t, filename)
+	f.expect("a")
+
+	// 轮转前写入的日志在轮转后读取
+	appendFile(t, filename, jsonLine("2024-01-01T10:00:01.000Z", "info", "b"))
+	if err := os.Rename(filename, filename+"-2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	f.expect("b")
+	// 新的日志文件还未创建时继续等待
+	f.idle()
+	writeFile(t, filename, jsonLine("2024-01-02T00:00:00.000Z", "info", "c"))
+	f.expect("c")
+}
+
+func TestReaderFollowTruncate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	writeFile(t, filename, jsonLine("2024-01-01T10:00:00.000Z", "info", "a")+
+		jsonLine("2024-01-01T10:00:01.000Z", "info", "b"))
+
+	f := newFollower(t, filename)
+	f.expect("a")
+	f.expect("b")
+
+	writeFile(t, filename, jsonLine("2024-01-01T10:00:02.000Z", "info", "c"))
+	f.expect("c")
+}
+
+func TestReaderFollowCreate(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	f := newFollower(t, filename)
+
+	f.idle()
+	writeFile(t, filename, jsonLine("2024-01-01T10:00:00.000Z", "info", "a"))
+	f.expect("a")
+}
+
+func TestReaderFollowCancel(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	f := newFollower(t, filename)
+
+	f.clock.BlockUntil(1)
+	f.cancel()
+	select {
+	case err := <-f.errs:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("取消后应返回 context.Canceled: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("取消后应立即返回")
+	}
+}
@@ -0,0 +1,283 @@
+package logx
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+// WithCallerSkip returns a Logger with given caller skip.
+func WithCallerSkip(skip int) Logger {
+	if skip <= 0 {
+		return new(richLogger)
+	}
+
+	return &richLogger{
+		callerSkip: skip,
+	}
+}
+
+// WithContext sets ctx to log, for keeping tracing information.
+func WithContext(ctx context.Context) Logger {
+	return &richLogger{
+		ctx: ctx,
+	}
+}
+
+// WithDuration returns a Logger with given duration.
+func WithDuration(d time.Duration) Logger {
+	return &richLogger{
+		duration: timex.ReprOfDuration(d),
+	}
+}
+
+// richLogger 携带 context、字段和调用深度的 Logger 实现
+// With* 方法返回新的副本，不会修改原有的 Logger
+type richLogger struct {
+	ctx        context.Context
+	callerSkip int
+	duration   string
+	fields     []LogField
+	name       string
+}
+
+func (l *richLogger) Debug(v ...any) {
+	if l.shallLog(DebugLevel) {
+		if shallSample(levelDebug, sampleKeyOfArgs(v)) {
+			l.debug(fmt.Sprint(v...))
+		}
+	}
+}
+
+func (l *richLogger) Debugf(format string, v ...any) {
+	if l.shallLog(DebugLevel) && shallSample(levelDebug, format) {
+		l.debug(fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *richLogger) Debugfn(fn func() any) {
+	if l.shallLog(DebugLevel) {
+		if val := fn(); shallSample(levelDebug, sampleKeyOf(val)) {
+			l.debug(val)
+		}
+	}
+}
+
+func (l *richLogger) Debugv(v any) {
+	if l.shallLog(DebugLevel) && shallSample(levelDebug, sampleKeyOf(v)) {
+		l.debug(v)
+	}
+}
+
+func (l *richLogger) Debugw(msg string, fields ...LogField) {
+	if l.shallLog(DebugLevel) && shallSample(levelDebug, msg) {
+		l.debug(msg, fields...)
+	}
+}
+
+func (l *richLogger) Error(v ...any) {
+	if l.shallLog(ErrorLevel) {
+		if shallSample(levelError, sampleKeyOfArgs(v)) {
+			l.err(fmt.Sprint(v...))
+		}
+	}
+}
+
+func (l *richLogger) Errorf(format string, v ...any) {
+	if l.shallLog(ErrorLevel) && shallSample(levelError, format) {
+		l.err(fmt.Errorf(format, v...).Error())
+	}
+}
+
+func (l *richLogger) Errorfn(fn func() any) {
+	if l.shallLog(ErrorLevel) {
+		if val := fn(); shallSample(levelError, sampleKeyOf(val)) {
+			l.err(val)
+		}
+	}
+}
+
+func (l *richLogger) Errorv(v any) {
+	if l.shallLog(ErrorLevel) && shallSample(levelError, sampleKeyOf(v)) {
+		l.err(v)
+	}
+}
+
+func (l *richLogger) Errorw(msg string, fields ...LogField) {
+	if l.shallLog(ErrorLevel) && shallSample(levelError, msg) {
+		l.err(msg, fields...)
+	}
+}
+
+func (l *richLogger) Info(v ...any) {
+	if l.shallLog(InfoLevel) {
+		if shallSample(levelInfo, sampleKeyOfArgs(v)) {
+			l.info(fmt.Sprint(v...))
+		}
+	}
+}
+
+func (l *richLogger) Infof(format string, v ...any) {
+	if l.shallLog(InfoLevel) && shallSample(levelInfo, format) {
+		l.info(fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *richLogger) Infofn(fn func() any) {
+	if l.shallLog(InfoLevel) {
+		if val := fn(); shallSample(levelInfo, sampleKeyOf(val)) {
+			l.info(val)
+		}
+	}
+}
+
+func (l *richLogger) Infov(v any) {
+	if l.shallLog(InfoLevel) && shallSample(levelInfo, sampleKeyOf(v)) {
+		l.info(v)
+	}
+}
+
+func (l *richLogger) Infow(msg string, fields ...LogField) {
+	if l.shallLog(InfoLevel) && shallSample(levelInfo, msg) {
+		l.info(msg, fields...)
+	}
+}
+
+func (l *richLogger) Slow(v ...any) {
+	if l.shallLog(ErrorLevel) {
+		if shallSample(levelSlow, sampleKeyOfArgs(v)) {
+			l.slow(fmt.Sprint(v...))
+		}
+	}
+}
+
+func (l *richLogger) Slowf(format string, v ...any) {
+	if l.shallLog(ErrorLevel) && shallSample(levelSlow, format) {
+		l.slow(fmt.Sprintf(format, v...))
+	}
+}
+
+func (l *richLogger) Slowfn(fn func() any) {
+	if l.shallLog(ErrorLevel) {
+		if val := fn(); shallSample(levelSlow, sampleKeyOf(val)) {
+			l.slow(val)
+		}
+	}
+}
+
+func (l *richLogger) Slowv(v any) {
+	if l.shallLog(ErrorLevel) && shallSample(levelSlow, sampleKeyOf(v)) {
+		l.slow(v)
+	}
+}
+
+func (l *richLogger) Sloww(msg string, fields ...LogField) {
+	if l.shallLog(ErrorLevel) && shallSample(levelSlow, msg) {
+		l.slow(msg, fields...)
+	}
+}
+
+func (l *richLogger) WithCallerSkip(skip int) Logger {
+	if skip <= 0 {
+		return l
+	}
+
+	nl := l.clone()
+	nl.callerSkip = skip
+	return nl
+}
+
+func (l *richLogger) WithContext(ctx context.Context) Logger {
+	nl := l.clone()
+	nl.ctx = ctx
+	return nl
+}
+
+func (l *richLogger) WithDuration(duration time.Duration) Logger {
+	nl := l.clone()
+	nl.duration = timex.ReprOfDuration(duration)
+	return nl
+}
+
+func (l *richLogger) WithFields(fields ...LogField) Logger {
+	nl := l.clone()
+	nl.fields = append(nl.fields, fields...)
+	return nl
+}
+
+// buildFields 按优先级合并字段：调用处字段 > Logger 字段 > context 字段，全局字段在 output 中合并
+// 后出现的字段在输出时覆盖同名的前序字段，系统字段最后追加且不会被用户字段覆盖
+func (l *richLogger) buildFields(level string, fields ...LogField) []LogField {
+	keys := loadFieldKeys()
+	all := make([]LogField, 0, len(l.fields)+len(fields)+4)
+
+	if l.ctx != nil {
+		if arr, ok := l.ctx.Value(fieldsKey{}).([]LogField); ok {
+			all = append(all, arr...)
+		}
+	}
+
+	all = append(all, l.fields...)
+	all = append(all, fields...)
+	all = protectReservedFields(keys, all)
+
+	// 0: buildFields 1: debug、info 等 2: richLogger 的导出方法 3: 调用方
+	all = appendCaller(keys, all, level, callerDepth+1+l.callerSkip)
+	if len(l.name) > 0 {
+		all = append(all, Field(keys.logger, l.name))
+	}
+	if len(l.duration) > 0 {
+		all = append(all, Field(keys.duration, l.duration))
+	}
+
+	if l.ctx != nil {
+		if traceId := traceIdFromContext(l.ctx); len(traceId) > 0 {
+			all = append(all, Field(keys.trace, traceId))
+		}
+		if spanId := spanIdFromContext(l.ctx); len(spanId) > 0 {
+			all = append(all, Field(keys.span, spanId))
+		}
+	}
+
+	return all
+}
+
+func (l *richLogger) clone() *richLogger {
+	return &richLogger{
+		ctx:        l.ctx,
+		callerSkip: l.callerSkip,
+		duration:   l.duration,
+		fields:     append([]LogField(nil), l.fields...),
+		name:       l.name,
+	}
+}
+
+func (l *richLogger) debug(v any, fields ...LogField) {
+	getWriter().Debug(v, l.buildFields(levelDebug, fields...)...)
+}
+
+func (l *richLogger) err(v any, fields ...LogField) {
+	getWriter().Error(v, l.buildFields(levelError, fields...)...)
+}
+
+func (l *richLogger) info(v any, fields ...LogField) {
+	getWriter().Info(v, l.buildFields(levelInfo, fields...)...)
+}
+
+// shallLog 命名 Logger 优先使用名称匹配的级别，否则按调用方所在包判断，callerSkip 用于跳过包装层
+func (l *richLogger) shallLog(level uint32) bool {
+	if len(l.name) > 0 {
+		if named, ok := loggerLevel(l.name); ok {
+			return named <= level
+		}
+	}
+
+	// 0: callerLevel 1: shallLog 2: richLogger 的导出方法 3: 调用方
+	return callerLevel(3+l.callerSkip) <= level
+}
+
+func (l *richLogger) slow(v any, fields ...LogField) {
+	getWriter().Slow(v, l.buildFields(levelSlow, fields...)...)
+}
@@ -0,0 +1,156 @@
+package logx
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// captureEntry 安装 mockWriter，返回解析最后一条 JSON 日志的函数
+func captureEntry(t *testing.T) func() map[string]any {
+	originalLevel := atomic.LoadUint32(&logLevel)
+	atomic.StoreUint32(&logLevel, DebugLevel)
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	t.Cleanup(func() {
+		writer.Store(old)
+		atomic.StoreUint32(&logLevel, originalLevel)
+	})
+
+	return func() map[string]any {
+		lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &entry); err != nil {
+			t.Fatalf("日志不是合法的 JSON: %s", w.String())
+		}
+		w.Reset()
+		return entry
+	}
+}
+
+func TestRichLoggerLevels(t *testing.T) {
+	last := captureEntry(t)
+	l := WithContext(context.Background())
+
+	tests := []struct {
+		name  string
+		level string
+		log   func()
+	}{
+		{"Debug", levelDebug, func() { l.Debug("foo", "bar") }},
+		{"Debugf", levelDebug, func() { l.Debugf("%s%s", "foo", "bar") }},
+		{"Debugfn", levelDebug, func() { l.Debugfn(func() any { return "foobar" }) }},
+		{"Debugv", levelDebug, func() { l.Debugv("foobar") }},
+		{"Debugw", levelDebug, func() { l.Debugw("foobar") }},
+		{"Error", levelError, func() { l.Error("foo", "bar") }},
+		{"Errorf", levelError, func() { l.Errorf("%s%s", "foo", "bar") }},
+		{"Errorfn", levelError, func() { l.Errorfn(func() any { return "foobar" }) }},
+		{"Errorv", levelError, func() { l.Errorv("foobar") }},
+		{"Errorw", levelError, func() { l.Errorw("foobar") }},
+		{"Info", levelInfo, func() { l.Info("foo", "bar") }},
+		{"Infof", levelInfo, func() { l.Infof("%s%s", "foo", "bar") }},
+		{"Infofn", levelInfo, func() { l.Infofn(func() any { return "foobar" }) }},
+		{"Infov", levelInfo, func() { l.Infov("foobar") }},
+		{"Infow", levelInfo, func() { l.Infow("foobar") }},
+		{"Slow", levelSlow, func() { l.Slow("foo", "bar") }},
+		{"Slowf", levelSlow, func() { l.Slowf("%s%s", "foo", "bar") }},
+		{"Slowfn", levelSlow, func() { l.Slowfn(func() any { return "foobar" }) }},
+		{"Slowv", levelSlow, func() { l.Slowv("foobar") }},
+		{"Sloww", levelSlow, func() { l.Sloww("foobar") }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.log()
+			entry := last()
+			if entry[defaultLevelKey] != test.level {
+				t.Errorf("期望级别 %s, 实际 %v", test.level, entry[defaultLevelKey])
+			}
+			if entry[defaultContentKey] != "foobar" {
+				t.Errorf("期望内容 foobar, 实际 %v", entry[defaultContentKey])
+			}
+		})
+	}
+}
+
+func TestRichLoggerFields(t *testing.T) {
+	last := captureEntry(t)
+
+	ctx := ContextWithFields(context.Background(), Field("ctx", "c"), Field("shared", "ctx"))
+	ctx = ContextWithTrace(ctx, "trace-id", "span-id")
+	WithContext(ctx).WithDuration(1500*time.Microsecond).WithFields(Field("module", "l")).
+		Infow("hello", Field("shared", "call"))
+
+	entry := last()
+	want := map[string]any{
+		defaultTraceKey:    "trace-id",
+		defaultSpanKey:     "span-id",
+		defaultDurationKey: "1.5ms",
+		"ctx":              "c",
+		"module":           "l",
+		"shared":           "call",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("字段 %s 期望 %v, 实际 %v", k, v, entry[k])
+		}
+	}
+	if caller, ok := entry[defaultCallerKey].(string); !ok || !strings.HasPrefix(caller, "logx/richlogger_test.go:") {
+		t.Errorf("caller 应指向调用处, 实际 %v", entry[defaultCallerKey])
+	}
+}
+
+func TestRichLoggerCallerSkip(t *testing.T) {
+	last := captureEntry(t)
+
+	logWithSkip := func() {
+		WithCallerSkip(1).Info("skip")
+	}
+	logWithSkip()
+	_, file, line, _ := runtime.Caller(0)
+	want := prettyCaller(file, line-1)
+
+	entry := last()
+	if entry[defaultCallerKey] != want {
+		t.Errorf("caller 期望 %s, 实际 %v", want, entry[defaultCallerKey])
+	}
+}
+
+func TestRichLoggerImmutable(t *testing.T) {
+	last := captureEntry(t)
+
+	base := WithContext(context.Background())
+	base.WithFields(Field("foo", "bar"))
+	base.WithDuration(time.Second)
+	base.Info("base")
+
+	entry := last()
+	if _, ok := entry["foo"]; ok {
+		t.Error("WithFields 不应修改原 Logger")
+	}
+	if _, ok := entry[defaultDurationKey]; ok {
+		t.Error("WithDuration 不应修改原 Logger")
+	}
+}
+
+func TestRichLoggerGlobalFields(t *testing.T) {
+	last := captureEntry(t)
+	old := globalFields.Load()
+	defer func() {
+		globalFields = atomic.Value{}
+		if old != nil {
+			globalFields.Store(old)
+		}
+	}()
+
+	AddGlobalFields(Field("service", "api"))
+	WithDuration(time.Millisecond).Info("global")
+
+	if entry := last(); entry["service"] != "api" {
+		t.Errorf("应包含全局字段, 实际 %v", entry)
+	}
+}
@@ -1,13 +1,22 @@
 package logx
 
 import (
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/YunFy26/mini-zero/core/lang"
+	"github.com/YunFy26/mini-zero/core/timex"
 )
 
 const (
@@ -17,11 +26,18 @@ const (
 	defaultFileMode = 0o600
 	gzipExt         = ".gz"
 	megaBytes       = 1 << 20
+	tmpExt          = ".tmp"
 )
 
 var (
 	ErrorLogFileClosed = errors.New("error: log file closed")
-	fileTimeFormat     = time.RFC3339
+	// 备份文件名精确到毫秒，减少同一时刻多次轮转的冲突
+	fileTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	// 日志文件落盘，测试中替换以统计落盘次数
+	syncFile = func(fp *os.File) error {
+		return fp.Sync()
+	}
 )
 
 type (
@@ -34,17 +50,36 @@ type (
 	}
 
 	// RotateLogger is a Logger that can rotate log files with given rules.
+	// Every Write is written as whole lines into a single file, so the lines are never torn
+	// across rotations, and all the accepted writes are written out before Close returns.
 	RotateLogger struct {
 		filename    string
 		backup      string
 		fp          *os.File
-		channel     chan []byte
+		channel     chan rotateEntry
 		done        chan lang.PlaceholderType
 		rule        RotateRule
 		compress    bool
 		waitGroup   sync.WaitGroup
 		closeOnce   sync.Once
 		currentSize int64
+		// closed 在 closeLock 的写锁中设置，Write 持有读锁写入缓冲区
+		// 保证关闭后后台不会再收到日志，关闭前收到的日志都被写入
+		closeLock sync.RWMutex
+		closed    bool
+		// 落盘策略，以及上次落盘后写入的次数
+		syncInterval time.Duration
+		syncEvery    int
+		unsynced     int
+	}
+
+	// RotateOption customizes a RotateLogger.
+	RotateOption func(l *RotateLogger)
+
+	// rotateEntry 一次写入的内容，written 非空时写入并落盘后关闭
+	rotateEntry struct {
+		data    []byte
+		written chan lang.PlaceholderType
 	}
 
 	// DailyRotateRule defines the daily rotation rule.
@@ -54,6 +89,7 @@ type (
 		delimiter   string
 		days        int
 		gzip        bool
+		clock       timex.Clock
 	}
 
 	// SizeLimitRotateRule defines the size limit rotation rule.
@@ -64,20 +100,33 @@ type (
 	}
 )
 
+// DefaultRotateRule returns the default rotation rule, currently DailyRotateRule.
+func DefaultRotateRule(filename, delimiter string, days int, gzip bool) RotateRule {
+	return &DailyRotateRule{
+		rotatedTime: getNowDate(clock),
+		filename:    filename,
+		delimiter:   delimiter,
+		days:        days,
+		gzip:        gzip,
+		clock:       clock,
+	}
+}
+
 // ==================== DailyRotateRule Methods =========================
 
 // BackupFileName returns the backup file name based on the current date.
 func (r *DailyRotateRule) BackupFileName() string {
-	return fmt.Sprintf("%s%s%s", r.filename, r.delimiter, getNowDate())
+	return fmt.Sprintf("%s%s%s", r.filename, r.delimiter, getNowDate(r.clock))
 }
 
 // MarkRotated updates the rotated time to the current date.
 func (r *DailyRotateRule) MarkRotated() {
-	r.rotatedTime = getNowDate()
+	r.rotatedTime = getNowDate(r.clock)
 }
 
+// OutdatedFiles returns the backup files that are older than the keep days.
 func (r *DailyRotateRule) OutdatedFiles() []string {
-	if r.days < 0 {
+	if r.days <= 0 {
 		return nil
 	}
 
@@ -88,31 +137,574 @@ func (r *DailyRotateRule) OutdatedFiles() []string {
 		pattern = fmt.Sprintf("%s%s*", r.filename, r.delimiter)
 	}
 
-}
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		log.Printf("failed to delete outdated log files, error: %s", err)
+		return nil
+	}
 
-func (r *DailyRotateRule) ShallRotate(size int64) bool {
+	// 备份文件名以日期结尾，按字典序比较即可判断新旧
+	var buf strings.Builder
+	boundary := r.clock.Now().Add(-time.Hour * time.Duration(hoursPerDay*r.days)).Format(time.DateOnly)
+	buf.WriteString(r.filename)
+	buf.WriteString(r.delimiter)
+	buf.WriteString(boundary)
+	if r.gzip {
+		buf.WriteString(gzipExt)
+	}
+	boundaryFile := buf.String()
+
+	var outdates []string
+	for _, file := range files {
+		if file < boundaryFile {
+			outdates = append(outdates, file)
+		}
+	}
 
+	return outdates
 }
 
-func getNowDate() string {
-	return time.Now().Format(time.DateOnly)
+// ShallRotate checks whether the date has changed since the last rotation.
+func (r *DailyRotateRule) ShallRotate(_ int64) bool {
+	return len(r.rotatedTime) > 0 && getNowDate(r.clock) != r.rotatedTime
 }
 
+// ==================== SizeLimitRotateRule Methods =========================
+
 // NewSizeLimitRotateRule returns the rotation rule with size limit
 func NewSizeLimitRotateRule(filename, delimiter string, days, maxSize, maxBackups int, gzip bool) RotateRule {
 	return &SizeLimitRotateRule{
 		DailyRotateRule: DailyRotateRule{
-			rotatedTime: getNowDateInRFC3339Format(),
+			rotatedTime: getNowDateInRFC3339Format(clock),
 			filename:    filename,
 			delimiter:   delimiter,
 			days:        days,
 			gzip:        gzip,
+			clock:       clock,
 		},
 		maxSize:    int64(maxSize) * megaBytes,
 		maxBackups: maxBackups,
 	}
 }
 
-func getNowDateInRFC3339Format() string {
-	return time.Now().Format(fileTimeFormat)
+// BackupFileName returns the backup file name with timestamp, e.g. access-2024-01-02T15:04:05.000+08:00.log.
+// If the backups rotated at the same time already take the name, a sequence number is added,
+// e.g. access-2024-01-02T15:04:05.000+08:00.1.log.
+func (r *SizeLimitRotateRule) BackupFileName() string {
+	dir := filepath.Dir(r.filename)
+	prefix, ext := r.parseFilename()
+	timestamp := getNowDateInRFC3339Format(r.clock)
+	name := filepath.Join(dir, fmt.Sprintf("%s%s%s%s", prefix, r.delimiter, timestamp, ext))
+	// 重命名时会覆盖同名的备份文件，加上序号避免丢失日志
+	for seq := 1; backupExists(name); seq++ {
+		name = filepath.Join(dir, fmt.Sprintf("%s%s%s.%d%s", prefix, r.delimiter, timestamp, seq, ext))
+	}
+
+	return name
+}
+
+// MarkRotated updates the rotated time to the current timestamp.
+func (r *SizeLimitRotateRule) MarkRotated() {
+	r.rotatedTime = getNowDateInRFC3339Format(r.clock)
+}
+
+// OutdatedFiles returns the backup files exceeding maxBackups or older than the keep days.
+func (r *SizeLimitRotateRule) OutdatedFiles() []string {
+	dir := filepath.Dir(r.filename)
+	prefix, ext := r.parseFilename()
+
+	var pattern string
+	if r.gzip {
+		pattern = fmt.Sprintf("%s%s%s%s*%s%s", dir, string(filepath.Separator),
+			prefix, r.delimiter, ext, gzipExt)
+	} else {
+		pattern = fmt.Sprintf("%s%s%s%s*%s", dir, string(filepath.Separator),
+			prefix, r.delimiter, ext)
+	}
+
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		log.Printf("failed to delete outdated log files, error: %s", err)
+		return nil
+	}
+
+	sortBackups(files, ext)
+
+	outdated := make(map[string]lang.PlaceholderType)
+
+	// 备份文件过多
+	if r.maxBackups > 0 && len(files) > r.maxBackups {
+		for _, f := range files[:len(files)-r.maxBackups] {
+			outdated[f] = lang.Placeholder
+		}
+		files = files[len(files)-r.maxBackups:]
+	}
+
+	// 备份文件过旧
+	if r.days > 0 {
+		boundary := r.clock.Now().Add(-time.Hour * time.Duration(hoursPerDay*r.days)).Format(fileTimeFormat)
+		boundaryFile := filepath.Join(dir, fmt.Sprintf("%s%s%s%s", prefix, r.delimiter, boundary, ext))
+		if r.gzip {
+			boundaryFile += gzipExt
+		}
+		for _, f := range files {
+			if f >= boundaryFile {
+				break
+			}
+			outdated[f] = lang.Placeholder
+		}
+	}
+
+	result := make([]string, 0, len(outdated))
+	for k := range outdated {
+		result = append(result, k)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// ShallRotate checks whether the file size exceeds maxSize.
+func (r *SizeLimitRotateRule) ShallRotate(size int64) bool {
+	return r.maxSize > 0 && r.maxSize < size
+}
+
+// parseFilename splits the base filename into prefix and extension, e.g. access.log -> access, .log
+func (r *SizeLimitRotateRule) parseFilename() (prefix, ext string) {
+	logName := filepath.Base(r.filename)
+	ext = filepath.Ext(r.filename)
+	prefix = logName[:len(logName)-len(ext)]
+	return
+}
+
+// ==================== RotateLogger Methods =========================
+
+// NewLogger returns a RotateLogger with given filename and rule.
+// By default, the log file is only fsynced on rotation and Close, use WithSyncInterval
+// or WithSyncEvery for more durability.
+func NewLogger(filename string, rule RotateRule, compress bool, opts ...RotateOption) (*RotateLogger, error) {
+	l := &RotateLogger{
+		filename: filename,
+		channel:  make(chan rotateEntry, bufferSize),
+		done:     make(chan lang.PlaceholderType),
+		rule:     rule,
+		compress: compress,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	if err := l.initialize(); err != nil {
+		return nil, err
+	}
+
+	l.startWorker()
+	return l, nil
+}
+
+// WithSyncEvery makes the RotateLogger fsync the log file after every n writes.
+// If n is 1, Write returns after the data is fsynced, so no log is lost on crash.
+func WithSyncEvery(n int) RotateOption {
+	return func(l *RotateLogger) {
+		l.syncEvery = n
+	}
+}
+
+// WithSyncInterval makes the RotateLogger fsync the log file every interval if written.
+func WithSyncInterval(interval time.Duration) RotateOption {
+	return func(l *RotateLogger) {
+		l.syncInterval = interval
+	}
+}
+
+// Close closes the logger, writing out all the buffered logs before returning.
+func (l *RotateLogger) Close() error {
+	var err error
+
+	l.closeOnce.Do(func() {
+		// 等待正在写入缓冲区的 Write 返回，之后的 Write 都会失败
+		l.closeLock.Lock()
+		l.closed = true
+		l.closeLock.Unlock()
+
+		close(l.done)
+		l.waitGroup.Wait()
+
+		// 轮转时创建文件失败，没有打开的文件
+		if l.fp == nil {
+			return
+		}
+		if err = syncFile(l.fp); err != nil {
+			l.fp.Close()
+			return
+		}
+
+		err = l.fp.Close()
+	})
+
+	return err
+}
+
+// Write sends data to the background worker, returns ErrorLogFileClosed if already closed.
+// Write returns after data is written and fsynced if WithSyncEvery(1) is used.
+func (l *RotateLogger) Write(data []byte) (int, error) {
+	l.closeLock.RLock()
+	defer l.closeLock.RUnlock()
+
+	if l.closed {
+		log.Println(string(data))
+		return 0, ErrorLogFileClosed
+	}
+
+	// 调用方可能复用 data（如池化的缓冲区），异步写入前需要拷贝
+	entry := rotateEntry{
+		data: append([]byte(nil), data...),
+	}
+	if l.syncEvery == 1 {
+		entry.written = make(chan lang.PlaceholderType)
+	}
+
+	// 持有读锁时后台一定在运行，不会阻塞
+	l.channel <- entry
+	if entry.written != nil {
+		<-entry.written
+	}
+
+	return len(data), nil
+}
+
+func (l *RotateLogger) initialize() error {
+	l.backup = l.rule.BackupFileName()
+
+	if fileInfo, err := os.Stat(l.filename); err != nil {
+		basePath := path.Dir(l.filename)
+		if _, err = os.Stat(basePath); err != nil {
+			if err = os.MkdirAll(basePath, defaultDirMode); err != nil {
+				return err
+			}
+		}
+
+		if l.fp, err = os.Create(l.filename); err != nil {
+			return err
+		}
+	} else {
+		if l.fp, err = os.OpenFile(l.filename, os.O_APPEND|os.O_RDWR, defaultFileMode); err != nil {
+			return err
+		}
+
+		l.currentSize = fileInfo.Size()
+		if err = l.recoverPartialLine(); err != nil {
+			l.fp.Close()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// recoverPartialLine 上次退出时最后一行未写完（如进程崩溃），补全换行，
+// 保证之后写入的日志从行首开始，不与不完整的行混在一起
+func (l *RotateLogger) recoverPartialLine() error {
+	if l.currentSize == 0 {
+		return nil
+	}
+
+	last := make([]byte, 1)
+	if _, err := l.fp.ReadAt(last, l.currentSize-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+
+	log.Printf("log file %s ends with a partial line, terminated with a newline", l.filename)
+	n, err := l.fp.Write([]byte{'\n'})
+	l.currentSize += int64(n)
+	return err
+}
+
+func (l *RotateLogger) maybeCompressFile(file string) {
+	if !l.compress {
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("failed to compress log file: %s, error: %v", file, r)
+		}
+	}()
+
+	if _, err := os.Stat(file); err != nil {
+		// 文件不存在或其他错误，忽略压缩
+		return
+	}
+
+	compressLogFile(file)
+}
+
+func (l *RotateLogger) maybeDeleteOutdatedFiles() {
+	files := l.rule.OutdatedFiles()
+	for _, file := range files {
+		if err := os.Remove(file); err != nil {
+			log.Printf("failed to remove outdated file: %s", file)
+		}
+	}
+}
+
+// postRotate 压缩和清理放到后台执行，不阻塞写入
+func (l *RotateLogger) postRotate(file string) {
+	l.waitGroup.Add(1)
+	go func() {
+		defer l.waitGroup.Done()
+		l.maybeCompressFile(file)
+		l.maybeDeleteOutdatedFiles()
+	}()
+}
+
+func (l *RotateLogger) rotate() error {
+	if l.fp != nil {
+		// 备份文件在压缩后会删除，先落盘，避免崩溃后备份文件不完整
+		if err := syncFile(l.fp); err != nil {
+			log.Printf("failed to sync log file: %s, error: %v", l.filename, err)
+		}
+		err := l.fp.Close()
+		l.fp = nil
+		l.unsynced = 0
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := os.Stat(l.filename)
+	if err == nil && len(l.backup) > 0 {
+		if err = os.Rename(l.filename, l.backup); err != nil {
+			return err
+		}
+
+		l.postRotate(l.backup)
+	}
+
+	l.backup = l.rule.BackupFileName()
+	l.fp, err = os.Create(l.filename)
+	return err
+}
+
+func (l *RotateLogger) startWorker() {
+	// 定时器在启动后台前创建，避免与测试中替换 clock 竞争
+	var ticker timex.Ticker
+	var ticks <-chan time.Time
+	if l.syncInterval > 0 {
+		ticker = clock.NewTicker(l.syncInterval)
+		ticks = ticker.Chan()
+	}
+
+	l.waitGroup.Add(1)
+
+	go func() {
+		defer l.waitGroup.Done()
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+
+		for {
+			select {
+			case entry := <-l.channel:
+				l.write(entry)
+			case <-ticks:
+				l.sync()
+			case <-l.done:
+				// 关闭后不再有新的写入，写完缓冲区中的日志
+				for {
+					select {
+					case entry := <-l.channel:
+						l.write(entry)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// sync 有未落盘的写入时落盘
+func (l *RotateLogger) sync() {
+	if l.fp == nil || l.unsynced == 0 {
+		return
+	}
+
+	l.unsynced = 0
+	if err := syncFile(l.fp); err != nil {
+		log.Printf("failed to sync log file: %s, error: %v", l.filename, err)
+	}
+}
+
+func (l *RotateLogger) write(entry rotateEntry) {
+	if entry.written != nil {
+		defer close(entry.written)
+	}
+
+	v := entry.data
+	if len(v) == 0 {
+		return
+	}
+	// 每次写入都以换行结束，保证下一次写入从行首开始
+	if v[len(v)-1] != '\n' {
+		v = append(v, '\n')
+	}
+
+	// 整条写入同一个文件，轮转不会切断日志
+	if l.rule.ShallRotate(l.currentSize + int64(len(v))) {
+		// 空文件不需要轮转，避免产生空的备份文件，只更新备份文件名
+		if l.currentSize == 0 {
+			l.backup = l.rule.BackupFileName()
+			l.rule.MarkRotated()
+		} else if err := l.rotate(); err != nil {
+			log.Println(err)
+		} else {
+			l.rule.MarkRotated()
+			l.currentSize = 0
+		}
+	}
+	// 轮转时创建文件失败，重新打开后继续写入
+	if l.fp == nil {
+		if err := l.reopen(); err != nil {
+			log.Printf("failed to open log file: %s, error: %v, log: %s", l.filename, err, v)
+			return
+		}
+	}
+
+	n, err := l.fp.Write(v)
+	l.currentSize += int64(n)
+	l.unsynced++
+	if err != nil {
+		log.Printf("failed to write log file: %s, error: %v", l.filename, err)
+		// 只写入了部分内容时补全换行，避免与下一条日志混在一起
+		if n > 0 {
+			n, _ = l.fp.Write([]byte{'\n'})
+			l.currentSize += int64(n)
+		}
+	}
+
+	if l.syncEvery > 0 && l.unsynced >= l.syncEvery {
+		l.sync()
+	}
+}
+
+func (l *RotateLogger) reopen() error {
+	fp, err := os.OpenFile(l.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, defaultFileMode)
+	if err != nil {
+		return err
+	}
+
+	info, err := fp.Stat()
+	if err != nil {
+		fp.Close()
+		return err
+	}
+
+	l.fp = fp
+	l.currentSize = info.Size()
+	return nil
+}
+
+// backupExists 判断备份文件或其压缩文件是否存在，压缩完成前两者至少有一个存在
+func backupExists(name string) bool {
+	for _, file := range []string{name, name + gzipExt} {
+		if _, err := os.Stat(file); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sortBackups 按文件名中的时间排序按大小轮转的备份文件，同一时刻轮转的按序号排序
+func sortBackups(files []string, ext string) {
+	sort.Slice(files, func(i, j int) bool {
+		si, seqi := splitBackupSeq(files[i], ext)
+		sj, seqj := splitBackupSeq(files[j], ext)
+		if si != sj {
+			return si < sj
+		}
+		return seqi < seqj
+	})
+}
+
+// splitBackupSeq 去掉扩展名后拆分出同一时刻轮转的序号，没有序号时为 0
+func splitBackupSeq(file, ext string) (string, int) {
+	name := strings.TrimSuffix(strings.TrimSuffix(file, gzipExt), ext)
+	if index := strings.LastIndexByte(name, '.'); index >= 0 {
+		if seq, err := strconv.Atoi(name[index+1:]); err == nil && seq > 0 {
+			return name[:index], seq
+		}
+	}
+
+	return name, 0
+}
+
+func compressLogFile(file string) {
+	if err := gzipFile(file); err != nil {
+		log.Printf("compress error: %s", err)
+	}
+}
+
+func getNowDate(clock timex.Clock) string {
+	return clock.Now().Format(time.DateOnly)
+}
+
+func getNowDateInRFC3339Format(clock timex.Clock) string {
+	return clock.Now().Format(fileTimeFormat)
+}
+
+// gzipFile 压缩文件为 file.gz，成功后删除原文件
+// 先写入临时文件并落盘再重命名，压缩过程中崩溃时只会留下临时文件，原文件保持完整
+func gzipFile(file string) (err error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := in.Close(); e != nil {
+			log.Printf("failed to close file: %s, error: %v", file, e)
+		}
+		if err == nil {
+			// 仅在压缩成功后删除原文件
+			err = os.Remove(file)
+		}
+	}()
+
+	target := fmt.Sprintf("%s%s", file, gzipExt)
+	tmp := target + tmpExt
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if err = writeGzip(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err = out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// writeGzip 压缩 in 写入 out 并落盘
+func writeGzip(out *os.File, in io.Reader) error {
+	w := gzip.NewWriter(out)
+	if _, err := io.Copy(w, in); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return out.Sync()
 }
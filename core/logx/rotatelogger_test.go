@@ -0,0 +1,611 @@
+package logx
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+// useFakeClock 将日志使用的时钟替换为从 start 开始的假时钟
+func useFakeClock(t *testing.T, start time.Time) *timex.FakeClock {
+	fake := timex.NewFakeClock(start)
+	old := clock
+	clock = fake
+	t.Cleanup(func() {
+		clock = old
+	})
+	return fake
+}
+
+func touch(t *testing.T, files ...string) {
+	for _, file := range files {
+		if err := os.WriteFile(file, []byte("x"), defaultFileMode); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDailyRotateRuleShallRotate(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 23, 0, 0, 0, time.Local))
+	rule := DefaultRotateRule("app.log", backupFileDelimiter, 1, false)
+
+	if rule.ShallRotate(0) {
+		t.Error("同一天内不应轮转")
+	}
+	if name := rule.BackupFileName(); name != "app.log-2024-01-01" {
+		t.Errorf("备份文件名错误: %s", name)
+	}
+
+	fake.Advance(2 * time.Hour)
+	if !rule.ShallRotate(0) {
+		t.Error("跨天后应轮转")
+	}
+
+	rule.MarkRotated()
+	if rule.ShallRotate(0) {
+		t.Error("标记轮转后不应再次轮转")
+	}
+}
+
+func TestDailyRotateRuleOutdatedFiles(t *testing.T) {
+	useFakeClock(t, time.Date(2024, time.January, 10, 12, 0, 0, 0, time.Local))
+	filename := filepath.Join(t.TempDir(), "app.log")
+	touch(t, filename+"-2024-01-01", filename+"-2024-01-05", filename+"-2024-01-09")
+
+	t.Run("永久保留", func(t *testing.T) {
+		rule := DefaultRotateRule(filename, backupFileDelimiter, 0, false)
+		if files := rule.OutdatedFiles(); len(files) != 0 {
+			t.Errorf("KeepDays为0时不应有过期文件: %v", files)
+		}
+	})
+
+	t.Run("保留7天", func(t *testing.T) {
+		rule := DefaultRotateRule(filename, backupFileDelimiter, 7, false)
+		files := rule.OutdatedFiles()
+		if len(files) != 1 || files[0] != filename+"-2024-01-01" {
+			t.Errorf("过期文件错误: %v", files)
+		}
+	})
+}
+
+func TestSizeLimitRotateRule(t *testing.T) {
+	useFakeClock(t, time.Date(2024, time.January, 10, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "app.log")
+
+	t.Run("ShallRotate", func(t *testing.T) {
+		rule := NewSizeLimitRotateRule(filename, backupFileDelimiter, 0, 1, 0, false)
+		if rule.ShallRotate(megaBytes) {
+			t.Error("未超过大小限制时不应轮转")
+		}
+		if !rule.ShallRotate(megaBytes + 1) {
+			t.Error("超过大小限制时应轮转")
+		}
+
+		unlimited := NewSizeLimitRotateRule(filename, backupFileDelimiter, 0, 0, 0, false)
+		if unlimited.ShallRotate(megaBytes * 1024) {
+			t.Error("MaxSize为0时不应轮转")
+		}
+	})
+
+	t.Run("BackupFileName", func(t *testing.T) {
+		rule := NewSizeLimitRotateRule(filename, backupFileDelimiter, 0, 1, 0, false)
+		want := filepath.Join(dir, "app-2024-01-10T12:00:00.000Z.log")
+		if name := rule.BackupFileName(); name != want {
+			t.Errorf("备份文件名错误: 期望 %s, 实际 %s", want, name)
+		}
+	})
+
+	t.Run("OutdatedFiles", func(t *testing.T) {
+		touch(t,
+			filepath.Join(dir, "app-2024-01-01T00:00:00Z.log"),
+			filepath.Join(dir, "app-2024-01-08T00:00:00Z.log"),
+			filepath.Join(dir, "app-2024-01-09T00:00:00Z.log"),
+			filepath.Join(dir, "app-2024-01-10T00:00:00Z.log"),
+		)
+
+		rule := NewSizeLimitRotateRule(filename, backupFileDelimiter, 0, 1, 2, false)
+		files := rule.OutdatedFiles()
+		if len(files) != 2 || !strings.Contains(files[0], "01-01") || !strings.Contains(files[1], "01-08") {
+			t.Errorf("超过MaxBackups的文件应被清理: %v", files)
+		}
+
+		rule = NewSizeLimitRotateRule(filename, backupFileDelimiter, 5, 1, 0, false)
+		files = rule.OutdatedFiles()
+		if len(files) != 1 || !strings.Contains(files[0], "01-01") {
+			t.Errorf("超过KeepDays的文件应被清理: %v", files)
+		}
+	})
+}
+
+func TestSortBackups(t *testing.T) {
+	files := []string{
+		"/logs.d/app-2024-01-10T12:00:00.000Z.10.log",
+		"/logs.d/app-2024-01-10T12:00:00.000Z.log",
+		"/logs.d/app-2024-01-10T12:00:00.000Z.2.log.gz",
+		"/logs.d/app-2024-01-09T12:00:00.000Z.log",
+	}
+	sortBackups(files, ".log")
+
+	expect := []string{
+		"/logs.d/app-2024-01-09T12:00:00.000Z.log",
+		"/logs.d/app-2024-01-10T12:00:00.000Z.log",
+		"/logs.d/app-2024-01-10T12:00:00.000Z.2.log.gz",
+		"/logs.d/app-2024-01-10T12:00:00.000Z.10.log",
+	}
+	if strings.Join(files, ",") != strings.Join(expect, ",") {
+		t.Errorf("同一时刻轮转的备份文件应按序号排序: %v", files)
+	}
+}
+
+func TestRotateLoggerDaily(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.Local))
+	filename := filepath.Join(t.TempDir(), "sub", "access.log")
+
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 1, false), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := logger.Write([]byte("day1\n")); err != nil {
+		t.Fatal(err)
+	}
+	// 等待第一条日志写入后再推进时间
+	waitForContent(t, filename, "day1")
+	fake.Advance(hoursPerDay * time.Hour)
+	if _, err := logger.Write([]byte("day2\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := os.ReadFile(filename + "-2024-01-01")
+	if err != nil {
+		t.Fatalf("跨天后应生成备份文件: %v", err)
+	}
+	if string(backup) != "day1\n" {
+		t.Errorf("备份文件内容错误: %q", backup)
+	}
+	current, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(current) != "day2\n" {
+		t.Errorf("当前文件内容错误: %q", current)
+	}
+
+	if _, err := logger.Write([]byte("closed\n")); err != ErrorLogFileClosed {
+		t.Errorf("关闭后写入应返回 ErrorLogFileClosed, 实际: %v", err)
+	}
+}
+
+func TestRotateLoggerSizeWithGzip(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	rule := &SizeLimitRotateRule{
+		DailyRotateRule: DailyRotateRule{
+			rotatedTime: getNowDateInRFC3339Format(fake),
+			filename:    filename,
+			delimiter:   backupFileDelimiter,
+			gzip:        true,
+			clock:       fake,
+		},
+		maxSize:    10,
+		maxBackups: 1,
+	}
+
+	logger, err := NewLogger(filename, rule, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := logger.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		waitForContent(t, filename, strings.TrimSpace(line))
+		fake.Advance(time.Second)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	backups, err := filepath.Glob(filepath.Join(dir, "access-*.log.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 1 {
+		t.Fatalf("应只保留1个压缩备份，实际: %v", backups)
+	}
+	if content := readGzip(t, backups[0]); content != "second\n" {
+		t.Errorf("压缩备份内容错误: %q", content)
+	}
+	plain, _ := filepath.Glob(filepath.Join(dir, "access-*.log"))
+	if len(plain) != 0 {
+		t.Errorf("压缩后应删除原备份文件: %v", plain)
+	}
+}
+
+func TestRotateLoggerSameTimeRotation(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	rule := &SizeLimitRotateRule{
+		DailyRotateRule: DailyRotateRule{
+			rotatedTime: getNowDateInRFC3339Format(fake),
+			filename:    filename,
+			delimiter:   backupFileDelimiter,
+			clock:       fake,
+		},
+		maxSize: 6,
+	}
+
+	logger, err := NewLogger(filename, rule, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 时间不变，第二条日志开始每条都触发轮转
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+		if _, err := logger.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		waitForContent(t, filename, strings.TrimSpace(line))
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expect := range map[string]string{
+		"access-2024-01-01T12:00:00.000Z.log":   "aaaa\n",
+		"access-2024-01-01T12:00:00.000Z.1.log": "bbbb\n",
+		"access.log":                            "cccc\n",
+	} {
+		if content, _ := os.ReadFile(filepath.Join(dir, name)); string(content) != expect {
+			t.Errorf("同一时刻轮转的备份文件 %s 内容错误: %q", name, content)
+		}
+	}
+}
+
+func TestRotateLoggerOversizedEntry(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	rule := &SizeLimitRotateRule{
+		DailyRotateRule: DailyRotateRule{
+			rotatedTime: getNowDateInRFC3339Format(fake),
+			filename:    filename,
+			delimiter:   backupFileDelimiter,
+			clock:       fake,
+		},
+		maxSize: 4,
+	}
+
+	logger, err := NewLogger(filename, rule, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := logger.Write([]byte("aaaaaaaa\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if backups, _ := filepath.Glob(filepath.Join(dir, "access-*")); len(backups) != 0 {
+		t.Errorf("空文件不应轮转出空的备份文件: %v", backups)
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "aaaaaaaa\n" {
+		t.Errorf("超过大小限制的日志应写入空文件，实际: %q", content)
+	}
+}
+
+func TestRotateLoggerAppendExisting(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(filename, []byte("x\n"), defaultFileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 0, false), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.currentSize != 2 {
+		t.Errorf("应记录已有文件大小，实际: %d", logger.currentSize)
+	}
+	logger.Write([]byte("y\n"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "x\ny\n" {
+		t.Errorf("应追加写入已有文件，实际: %q", content)
+	}
+}
+
+func TestRotateLoggerRecoverPartialLine(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	// 上次退出时最后一行未写完
+	touch(t, filename)
+
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 0, false), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logger.currentSize != 2 {
+		t.Errorf("补全换行后的文件大小错误: %d", logger.currentSize)
+	}
+	logger.Write([]byte("y\n"))
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(filename); string(content) != "x\ny\n" {
+		t.Errorf("不完整的行应补全换行，实际: %q", content)
+	}
+}
+
+func TestRotateLoggerLineAtomicity(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC))
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "access.log")
+	rule := &SizeLimitRotateRule{
+		DailyRotateRule: DailyRotateRule{
+			rotatedTime: getNowDateInRFC3339Format(fake),
+			filename:    filename,
+			delimiter:   backupFileDelimiter,
+			clock:       fake,
+		},
+		maxSize: 8,
+	}
+
+	logger, err := NewLogger(filename, rule, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 没有换行的写入补全换行，超过大小限制的日志整条写入新文件
+	for _, line := range []string{"aaa", "bbbbbb\n", "cc\n", "dddddddddddd\n"} {
+		if _, err := logger.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+		waitForContent(t, filename, strings.TrimSpace(line))
+		fake.Advance(time.Second)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "access*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(content), "\n") {
+			t.Errorf("文件 %s 应以完整的行结束: %q", file, content)
+		}
+		lines = append(lines, strings.Fields(string(content))...)
+	}
+	if len(lines) != 4 {
+		t.Errorf("日志不应被切断: %v", lines)
+	}
+}
+
+func TestRotateLoggerCloseDrains(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 0, false), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 与 Close 并发写入，返回成功的日志都应被写入文件
+	var written sync.Map
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				line := fmt.Sprintf("w%d-%d\n", i, j)
+				if _, err := logger.Write([]byte(line)); err != nil {
+					return
+				}
+				written.Store(strings.TrimSpace(line), true)
+			}
+		}(i)
+	}
+	time.Sleep(time.Millisecond)
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make(map[string]bool)
+	for _, line := range strings.Fields(string(content)) {
+		lines[line] = true
+	}
+	written.Range(func(key, _ any) bool {
+		if !lines[key.(string)] {
+			t.Errorf("写入成功的日志丢失: %s", key)
+		}
+		return true
+	})
+}
+
+// countSyncs 统计日志文件的落盘次数
+func countSyncs(t *testing.T) *atomic.Int32 {
+	var count atomic.Int32
+	old := syncFile
+	syncFile = func(fp *os.File) error {
+		count.Add(1)
+		return fp.Sync()
+	}
+	t.Cleanup(func() {
+		syncFile = old
+	})
+	return &count
+}
+
+func waitForSyncs(t *testing.T, count *atomic.Int32, expect int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if count.Load() == expect {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("落盘次数错误，期望 %d，实际 %d", expect, count.Load())
+}
+
+func TestRotateLoggerSyncEvery(t *testing.T) {
+	count := countSyncs(t)
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 0, false), false,
+		WithSyncEvery(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		logger.Write([]byte(line))
+	}
+	waitForContent(t, filename, "c")
+	waitForSyncs(t, count, 1)
+}
+
+func TestRotateLoggerSyncEachWrite(t *testing.T) {
+	count := countSyncs(t)
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 0, false), false,
+		WithSyncEvery(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	// 每条日志落盘后才返回
+	logger.Write([]byte("a\n"))
+	if content, _ := os.ReadFile(filename); string(content) != "a\n" || count.Load() != 1 {
+		t.Errorf("返回前应已写入并落盘: %q, %d", content, count.Load())
+	}
+}
+
+func TestRotateLoggerSyncInterval(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 12, 0, 0, 0, time.Local))
+	count := countSyncs(t)
+	filename := filepath.Join(t.TempDir(), "access.log")
+	logger, err := NewLogger(filename, DefaultRotateRule(filename, backupFileDelimiter, 0, false), false,
+		WithSyncInterval(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close()
+
+	logger.Write([]byte("a\n"))
+	waitForContent(t, filename, "a")
+	fake.Advance(time.Second)
+	waitForSyncs(t, count, 1)
+
+	// 没有新的写入时不落盘
+	fake.Advance(time.Second)
+	time.Sleep(10 * time.Millisecond)
+	if count.Load() != 1 {
+		t.Errorf("没有写入时不应落盘: %d", count.Load())
+	}
+}
+
+func TestFileSyncOptions(t *testing.T) {
+	old := options
+	t.Cleanup(func() {
+		options = old
+	})
+
+	tests := []struct {
+		policy string
+		severe bool
+		every  int
+		expect time.Duration
+	}{
+		{"never", true, 0, 0},
+		{syncInterval, false, 0, time.Second},
+		{syncEvery, false, 10, 0},
+		{syncSevere, false, 0, 0},
+		{syncSevere, true, 1, 0},
+	}
+	for _, test := range tests {
+		WithFileSync(test.policy, time.Second, 10)(&options)
+		var l RotateLogger
+		for _, opt := range fileSyncOptions(test.severe) {
+			opt(&l)
+		}
+		if l.syncEvery != test.every || l.syncInterval != test.expect {
+			t.Errorf("%s 策略的选项错误: %d, %v", test.policy, l.syncEvery, l.syncInterval)
+		}
+	}
+}
+
+func TestGzipFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "access.log-2024-01-01")
+	if err := os.WriteFile(file, []byte("hello\n"), defaultFileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gzipFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if content := readGzip(t, file+gzipExt); content != "hello\n" {
+		t.Errorf("压缩内容错误: %q", content)
+	}
+	if files, _ := filepath.Glob(filepath.Join(dir, "*")); len(files) != 1 {
+		t.Errorf("压缩后应只剩压缩文件: %v", files)
+	}
+}
+
+func waitForContent(t *testing.T, filename, text string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if content, err := os.ReadFile(filename); err == nil && strings.Contains(string(content), text) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("等待日志写入超时: %s", text)
+}
+
+func readGzip(t *testing.T, file string) string {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
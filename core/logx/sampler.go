@@ -0,0 +1,193 @@
+package logx
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/lang"
+)
+
+const (
+	// 每个周期内最多记录的模板数，避免模板过多时计数占用过多内存
+	maxSampleKeys = 1024
+	// 超过 maxSampleKeys 后新出现的模板共用该模板计数
+	sampleOverflowTemplate = "<overflow>"
+)
+
+// 当前生效的采样器，nil 表示不采样
+var activeSampler atomic.Pointer[sampler]
+
+type (
+	// sampler 按 级别+消息模板 对日志采样
+	// 每个周期内前 initial 条全部输出，之后每 thereafter 条输出一条
+	sampler struct {
+		initial    uint64
+		thereafter uint64
+		interval   time.Duration
+		counters   map[sampleKey]*sampleCounter
+		lock       sync.Mutex
+		done       chan lang.PlaceholderType
+		stopOnce   sync.Once
+	}
+
+	sampleKey struct {
+		level    string
+		template string
+	}
+
+	sampleCounter struct {
+		total     uint64
+		discarded uint64
+	}
+)
+
+func newSampler(c samplingConf) *sampler {
+	interval := time.Duration(c.IntervalMillis) * time.Millisecond
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	return &sampler{
+		initial:    uint64(c.Initial),
+		thereafter: uint64(c.Thereafter),
+		interval:   interval,
+		counters:   make(map[sampleKey]*sampleCounter),
+		done:       make(chan lang.PlaceholderType),
+	}
+}
+
+// allow 判断该条日志是否输出，并更新计数
+func (s *sampler) allow(level, template string) bool {
+	key := sampleKey{level: level, template: template}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	counter, ok := s.counters[key]
+	if !ok && len(s.counters) >= maxSampleKeys {
+		key.template = sampleOverflowTemplate
+		counter, ok = s.counters[key]
+	}
+	if !ok {
+		counter = new(sampleCounter)
+		s.counters[key] = counter
+	}
+
+	n := counter.total
+	counter.total++
+	if n < s.initial {
+		return true
+	}
+	if s.thereafter > 0 && (n-s.initial)%s.thereafter == 0 {
+		return true
+	}
+
+	counter.discarded++
+	return false
+}
+
+// flush 重置计数，并把本周期内被丢弃的条数按级别输出汇总日志
+func (s *sampler) flush() {
+	s.lock.Lock()
+	counters := s.counters
+	s.counters = make(map[sampleKey]*sampleCounter, len(counters))
+	s.lock.Unlock()
+
+	keys := make([]sampleKey, 0, len(counters))
+	for key, counter := range counters {
+		if counter.discarded > 0 {
+			keys = append(keys, key)
+		}
+	}
+	// 保证汇总日志输出顺序稳定
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].level != keys[j].level {
+			return keys[i].level < keys[j].level
+		}
+		return keys[i].template < keys[j].template
+	})
+
+	for _, key := range keys {
+		discarded := counters[key].discarded
+		msg := fmt.Sprintf("Discarded %d %s messages", discarded, key.level)
+		fields := []LogField{Field("template", key.template), Field("discarded", discarded)}
+		switch key.level {
+		case levelDebug:
+			writeDebug(msg, fields...)
+		case levelInfo:
+			writeInfo(msg, fields...)
+		case levelSlow:
+			writeSlow(msg, fields...)
+		default:
+			writeError(msg, fields...)
+		}
+	}
+}
+
+// start 启动后台协程，每个周期结束时调用 flush
+func (s *sampler) start() {
+	// 在当前协程中创建，后台协程不读取全局的时钟
+	ticker := clock.NewTicker(s.interval)
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.Chan():
+				s.flush()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// stop 停止后台协程，并输出最后一个周期的汇总
+func (s *sampler) stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.flush()
+	})
+}
+
+// sampleKeyOf 返回非模板类日志的采样键，字符串直接使用内容，其他类型使用类型名
+func sampleKeyOf(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+// sampleKeyOfArgs 返回 Debug、Info 等非格式化日志的采样键，取第一个参数，
+// 避免以格式化后的内容为键，参数不同的日志各自计数
+func sampleKeyOfArgs(v []any) string {
+	if len(v) == 0 {
+		return ""
+	}
+
+	return sampleKeyOf(v[0])
+}
+
+// setupSampling 按配置替换当前的采样器，Initial 为0时关闭采样
+func setupSampling(c samplingConf) {
+	var s *sampler
+	if c.Initial > 0 {
+		s = newSampler(c)
+		s.start()
+	}
+
+	if old := activeSampler.Swap(s); old != nil {
+		old.stop()
+	}
+}
+
+// shallSample 判断该条日志是否通过采样
+func shallSample(level, template string) bool {
+	s := activeSampler.Load()
+	return s == nil || s.allow(level, template)
+}
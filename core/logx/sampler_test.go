@@ -0,0 +1,206 @@
+package logx
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func useSampler(t *testing.T, c samplingConf) *sampler {
+	s := newSampler(c)
+	old := activeSampler.Swap(s)
+	t.Cleanup(func() {
+		activeSampler.Store(old)
+	})
+	return s
+}
+
+func TestSamplerAllow(t *testing.T) {
+	s := newSampler(samplingConf{Initial: 2, Thereafter: 3})
+
+	var allowed []int
+	for i := 0; i < 10; i++ {
+		if s.allow(levelError, "foo %d") {
+			allowed = append(allowed, i)
+		}
+	}
+
+	want := []int{0, 1, 2, 5, 8}
+	if len(allowed) != len(want) {
+		t.Fatalf("期望输出 %v，实际为 %v", want, allowed)
+	}
+	for i := range want {
+		if allowed[i] != want[i] {
+			t.Fatalf("期望输出 %v，实际为 %v", want, allowed)
+		}
+	}
+
+	// 不同级别、不同模板分别计数
+	if !s.allow(levelInfo, "foo %d") {
+		t.Error("不同级别应该单独计数")
+	}
+	if !s.allow(levelError, "bar %d") {
+		t.Error("不同模板应该单独计数")
+	}
+}
+
+func TestSamplerThereafterZero(t *testing.T) {
+	s := newSampler(samplingConf{Initial: 1})
+
+	if !s.allow(levelError, "foo") {
+		t.Error("第一条日志应该输出")
+	}
+	for i := 0; i < 5; i++ {
+		if s.allow(levelError, "foo") {
+			t.Error("Thereafter 为0时超过 Initial 的日志应该全部丢弃")
+		}
+	}
+}
+
+func TestSamplerMaxKeys(t *testing.T) {
+	s := newSampler(samplingConf{Initial: 1})
+
+	for i := 0; i < maxSampleKeys; i++ {
+		s.allow(levelError, strconv.Itoa(i))
+	}
+	// 超过上限后新的模板共用同一个计数
+	if !s.allow(levelError, "new1") {
+		t.Error("超过上限后第一条新模板日志应该输出")
+	}
+	if s.allow(levelError, "new2") {
+		t.Error("超过上限后新模板应共用计数")
+	}
+	if len(s.counters) != maxSampleKeys+1 {
+		t.Errorf("期望 %d 个计数，实际为 %d", maxSampleKeys+1, len(s.counters))
+	}
+	// 已有的模板不受影响
+	if s.allow(levelError, "0") {
+		t.Error("已有模板应继续使用自己的计数")
+	}
+}
+
+func TestSamplerFlush(t *testing.T) {
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	s := newSampler(samplingConf{Initial: 1})
+	for i := 0; i < 4; i++ {
+		s.allow(levelError, "foo %d")
+	}
+	s.allow(levelInfo, "bar")
+
+	s.flush()
+	if !w.Contains(`"content":"Discarded 3 error messages"`) {
+		t.Errorf("缺少丢弃汇总: %s", w.String())
+	}
+	if !w.Contains(`"template":"foo %d"`) || !w.Contains(`"discarded":3`) {
+		t.Errorf("汇总缺少模板或条数: %s", w.String())
+	}
+	if w.Contains("bar") {
+		t.Errorf("没有丢弃的模板不应该输出汇总: %s", w.String())
+	}
+
+	// flush 后重新计数
+	w.Reset()
+	if !s.allow(levelError, "foo %d") {
+		t.Error("新周期的第一条日志应该输出")
+	}
+	s.flush()
+	if w.String() != "" {
+		t.Errorf("没有丢弃时不应该输出汇总: %s", w.String())
+	}
+}
+
+func TestSamplingTemplate(t *testing.T) {
+	last := captureEntry(t)
+	w := writer.Load().(*mockWriter)
+	useSampler(t, samplingConf{Initial: 1})
+
+	// Xf 系列按格式化字符串采样，参数不同也视为同一模板
+	Errorf("user %d failed", 1)
+	Errorf("user %d failed", 2)
+	WithContext(context.Background()).Errorf("user %d failed", 3)
+	if n := strings.Count(w.String(), "failed"); n != 1 {
+		t.Errorf("期望输出 1 条，实际为 %d: %s", n, w.String())
+	}
+	if entry := last(); entry[defaultContentKey] != "user 1 failed" {
+		t.Errorf("期望输出第一条日志，实际为 %v", entry[defaultContentKey])
+	}
+
+	// Xw 系列按 msg 采样
+	Infow("hello", Field("id", 1))
+	Infow("hello", Field("id", 2))
+	if n := strings.Count(w.String(), "hello"); n != 1 {
+		t.Errorf("期望输出 1 条，实际为 %d: %s", n, w.String())
+	}
+
+	// X 系列按第一个参数采样，不以格式化后的内容为键
+	Info("visit from ", 1)
+	WithContext(context.Background()).Info("visit from ", 2)
+	if n := strings.Count(w.String(), "visit from"); n != 1 {
+		t.Errorf("期望输出 1 条，实际为 %d: %s", n, w.String())
+	}
+	w.Reset()
+
+	// severe 不采样
+	Severe("boom")
+	Severe("boom")
+	if n := strings.Count(w.String(), "boom"); n != 2 {
+		t.Errorf("severe 日志不应该被采样，实际输出 %d 条", n)
+	}
+}
+
+func TestSetUpSampling(t *testing.T) {
+	resetSetup(t)
+
+	if err := SetUp(LogConf{
+		Mode:     "console",
+		Sampling: samplingConf{Initial: 5, Thereafter: 10, IntervalMillis: 200},
+	}); err != nil {
+		t.Fatalf("SetUp 失败: %v", err)
+	}
+
+	s := activeSampler.Load()
+	if s == nil {
+		t.Fatal("配置 Sampling 后应该启用采样")
+	}
+	if s.initial != 5 || s.thereafter != 10 || s.interval.Milliseconds() != 200 {
+		t.Errorf("采样配置不正确: %+v", s)
+	}
+
+	Close()
+	if activeSampler.Load() != nil {
+		t.Error("Close 后应该停止采样")
+	}
+}
+
+func TestSamplerTicker(t *testing.T) {
+	fake := useFakeClock(t, time.Now())
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	s := newSampler(samplingConf{Initial: 1, IntervalMillis: 100})
+	s.start()
+	defer s.stop()
+	fake.BlockUntil(1)
+
+	s.allow(levelInfo, "foo")
+	s.allow(levelInfo, "foo")
+	fake.Advance(99 * time.Millisecond)
+	if w.Contains("Discarded") {
+		t.Fatalf("周期结束前不应输出汇总: %s", w.String())
+	}
+
+	fake.Advance(time.Millisecond)
+	deadline := time.Now().Add(time.Second)
+	for !w.Contains(`"content":"Discarded 1 info messages"`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("周期结束后应输出汇总: %s", w.String())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
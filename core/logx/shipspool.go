@@ -0,0 +1,165 @@
+package logx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// 磁盘缓存轮转文件名的分隔符
+const shipSpoolDelimiter = "-"
+
+// shipSpool 收集器不可用时，将日志按 JSON 行写入磁盘，使用 RotateLogger 按大小轮转
+// 只在 ShipWriter 的后台协程中使用，不需要加锁
+type shipSpool struct {
+	filename   string
+	maxSize    int
+	maxBackups int
+	logger     *RotateLogger
+	// 是否有未发送的缓存，启动时检查上次退出前留下的文件
+	pending bool
+}
+
+func newShipSpool(filename string, maxSize, maxBackups int) *shipSpool {
+	s := &shipSpool{
+		filename:   filename,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	s.pending = len(s.files()) > 0
+
+	return s
+}
+
+// append 将一批日志写入缓存文件
+func (s *shipSpool) append(batch [][]byte) error {
+	if s.logger == nil {
+		rule := NewSizeLimitRotateRule(s.filename, shipSpoolDelimiter, 0, s.maxSize, s.maxBackups, false)
+		logger, err := NewLogger(s.filename, rule, false)
+		if err != nil {
+			return err
+		}
+		s.logger = logger
+	}
+
+	for _, entry := range batch {
+		if _, err := s.logger.Write(entry); err != nil {
+			return err
+		}
+	}
+
+	s.pending = true
+	return nil
+}
+
+func (s *shipSpool) close() error {
+	if s.logger == nil {
+		return nil
+	}
+
+	err := s.logger.Close()
+	s.logger = nil
+	return err
+}
+
+// files 返回按写入顺序排列的缓存文件，轮转出的文件在前，当前文件在最后
+func (s *shipSpool) files() []string {
+	dir := filepath.Dir(s.filename)
+	ext := filepath.Ext(s.filename)
+	prefix := filepath.Base(s.filename)
+	prefix = prefix[:len(prefix)-len(ext)]
+
+	pattern := filepath.Join(dir, prefix+shipSpoolDelimiter+"*"+ext)
+	files, _ := filepath.Glob(pattern)
+	// 同一时刻轮转的文件带有序号，不能直接按文件名排序
+	sortBackups(files, ext)
+	if _, err := os.Stat(s.filename); err == nil {
+		files = append(files, s.filename)
+	}
+
+	return files
+}
+
+// replay 按顺序发送缓存的日志，返回成功发送和丢弃的条数
+// 发送成功的文件被删除，失败时将未发送的部分写回文件，避免重复发送
+// 不完整、无法解析以及被收集器拒绝的日志直接丢弃，避免一直重放失败
+func (s *shipSpool) replay(send func([][]byte) error, batchSize int) (sent, failed int, err error) {
+	// 关闭后缓冲的日志才会全部落盘
+	if err = s.close(); err != nil {
+		return 0, 0, err
+	}
+
+	for _, file := range s.files() {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return sent, failed, err
+		}
+
+		lines, invalid := splitSpoolLines(content)
+		if invalid > 0 {
+			failed += invalid
+			log.Printf("dropped %d invalid log entries in spool %s", invalid, file)
+		}
+		for len(lines) > 0 {
+			n := min(len(lines), batchSize)
+			if err = send(lines[:n]); err != nil && !errors.Is(err, errShipRejected) {
+				if rerr := rewriteSpoolFile(file, lines); rerr != nil {
+					return sent, failed, fmt.Errorf("%w, and failed to rewrite spool: %v", err, rerr)
+				}
+				return sent, failed, err
+			}
+
+			if err != nil {
+				failed += n
+				log.Printf("failed to ship %d spooled log entries: %v", n, err)
+			} else {
+				sent += n
+			}
+			lines = lines[n:]
+		}
+
+		if err = os.Remove(file); err != nil {
+			return sent, failed, err
+		}
+	}
+
+	s.pending = false
+	return sent, failed, nil
+}
+
+// rewriteSpoolFile 用未发送的日志替换缓存文件，先写临时文件再重命名，避免中途失败丢失日志
+func rewriteSpoolFile(file string, lines [][]byte) error {
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, bytes.Join(lines, nil), defaultFileMode); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+// splitSpoolLines 按行切分缓存内容，每行保留结尾的换行，忽略空行
+// 返回不是合法 JSON 的行数，这些行被丢弃，包括进程崩溃留下的不完整的最后一行
+func splitSpoolLines(content []byte) (lines [][]byte, invalid int) {
+	for len(content) > 0 {
+		end := bytes.IndexByte(content, '\n')
+		if end < 0 {
+			invalid++
+			break
+		}
+
+		if line := content[:end+1]; end > 0 {
+			if json.Valid(line) {
+				lines = append(lines, line)
+			} else {
+				invalid++
+			}
+		}
+		content = content[end+1:]
+	}
+
+	return lines, invalid
+}
@@ -0,0 +1,389 @@
+package logx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// ShipElasticBulk posts entries with the Elasticsearch _bulk API format.
+	ShipElasticBulk = "elasticsearch"
+	// ShipLoki posts entries with the Loki push API format.
+	ShipLoki = "loki"
+	// ShipNDJSON posts entries as newline delimited JSON.
+	ShipNDJSON = "ndjson"
+
+	// 读取错误响应的最大长度
+	maxShipErrorBody = 512
+)
+
+var (
+	elasticIndexAction = []byte(`{"index":{}}` + "\n")
+
+	// errShipRejected 收集器拒绝了这批日志，重试也不会成功
+	errShipRejected = errors.New("log entries rejected")
+	// errShipDrainTimeout 关闭时没能在截止时间前发送
+	errShipDrainTimeout = errors.New("log shipping timed out on close")
+)
+
+type (
+	// shipTransport 将一批 JSON 行发送到收集器，只在 ShipWriter 的后台协程中调用
+	shipTransport interface {
+		send(batch [][]byte) error
+		close() error
+	}
+
+	// forwardTransport 使用 Fluentd forward 协议的 Forward 模式：[tag, [[time, record], ...]]
+	forwardTransport struct {
+		tcpTransport
+		tag string
+	}
+
+	httpTransport struct {
+		url     string
+		format  string
+		headers map[string]string
+		labels  map[string]string
+		// OTLP 的资源属性，创建时编码一次
+		resource []byte
+		gzip     bool
+		client   *http.Client
+	}
+
+	tcpTransport struct {
+		addr    string
+		timeout time.Duration
+		conn    net.Conn
+	}
+
+	udpTransport struct {
+		conn net.Conn
+	}
+)
+
+func newShipTransport(protocol, addr string, options shipOptions) (shipTransport, error) {
+	switch protocol {
+	case ShipForward:
+		return &forwardTransport{
+			tcpTransport: tcpTransport{
+				addr:    addr,
+				timeout: options.timeout,
+			},
+			tag: options.tag,
+		}, nil
+	case ShipHTTP:
+		switch options.httpFormat {
+		case ShipElasticBulk, ShipLoki, ShipNDJSON:
+		default:
+			return nil, fmt.Errorf("unknown http shipping format %q", options.httpFormat)
+		}
+
+		return newHTTPTransport(addr, options), nil
+	case ShipTCP:
+		return &tcpTransport{
+			addr:    addr,
+			timeout: options.timeout,
+		}, nil
+	case ShipUDP:
+		conn, err := net.DialTimeout("udp", addr, options.timeout)
+		if err != nil {
+			return nil, err
+		}
+		return &udpTransport{conn: conn}, nil
+	default:
+		return nil, fmt.Errorf("unknown shipping protocol %q", protocol)
+	}
+}
+
+func newHTTPTransport(url string, options shipOptions) *httpTransport {
+	labels := options.labels
+	if len(labels) == 0 {
+		labels = map[string]string{"job": filepath.Base(os.Args[0])}
+	}
+
+	t := &httpTransport{
+		url:     url,
+		format:  options.httpFormat,
+		headers: options.headers,
+		labels:  labels,
+		gzip:    options.gzip,
+		client:  &http.Client{Timeout: options.timeout},
+	}
+	if t.format == shipOTLP {
+		t.resource = appendOTLPResource(nil, options.resource)
+	}
+
+	return t
+}
+
+func (t *forwardTransport) send(batch [][]byte) error {
+	msg, err := t.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	return t.write(net.Buffers{msg})
+}
+
+// encode 将 JSON 行解码后编码为 msgpack，时间使用发送时间，原始时间保留在记录中
+func (t *forwardTransport) encode(batch [][]byte) ([]byte, error) {
+	now := clock.Now().Unix()
+	b := appendMsgpackArrayHeader(nil, 2)
+	b = appendMsgpackValue(b, t.tag)
+	b = appendMsgpackArrayHeader(b, len(batch))
+	for _, line := range batch {
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.UseNumber()
+		var record map[string]any
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("%w: %v", errShipRejected, err)
+		}
+
+		b = appendMsgpackArrayHeader(b, 2)
+		b = appendMsgpackValue(b, now)
+		b = appendMsgpackValue(b, record)
+	}
+
+	return b, nil
+}
+
+func (t *httpTransport) close() error {
+	t.client.CloseIdleConnections()
+	return nil
+}
+
+func (t *httpTransport) send(batch [][]byte) error {
+	body, contentType, err := t.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if t.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxShipErrorBody))
+		if isShipRejected(resp.StatusCode) {
+			return fmt.Errorf("%w, status: %s, body: %s", errShipRejected, resp.Status, msg)
+		}
+		return fmt.Errorf("failed to ship logs, status: %s, body: %s", resp.Status, msg)
+	}
+
+	// 读完响应体以复用连接
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// encode 按格式生成请求体，需要时使用 gzip 压缩
+func (t *httpTransport) encode(batch [][]byte) ([]byte, string, error) {
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var zw *gzip.Writer
+	if t.gzip {
+		zw = gzip.NewWriter(&buf)
+		w = zw
+	}
+
+	contentType := "application/x-ndjson"
+	switch t.format {
+	case ShipElasticBulk:
+		for _, line := range batch {
+			w.Write(elasticIndexAction)
+			w.Write(line)
+		}
+	case ShipLoki:
+		// Loki 要求每行带纳秒时间戳，使用发送时间并递增以保持顺序
+		contentType = "application/json"
+		now := clock.Now().UnixNano()
+		b := []byte(`{"streams":[{"stream":`)
+		b = appendJsonValue(b, t.labels)
+		b = append(b, `,"values":[`...)
+		for i, line := range batch {
+			if i > 0 {
+				b = append(b, ',')
+			}
+			b = append(b, `["`...)
+			b = strconv.AppendInt(b, now+int64(i), 10)
+			b = append(b, `",`...)
+			b = appendJsonString(b, string(bytes.TrimSuffix(line, []byte{'\n'})))
+			b = append(b, ']')
+		}
+		b = append(b, "]}]}"...)
+		w.Write(b)
+	case shipOTLP:
+		contentType = "application/json"
+		w.Write(appendOTLPRequest(nil, t.resource, batch))
+	default:
+		for _, line := range batch {
+			w.Write(line)
+		}
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return buf.Bytes(), contentType, nil
+}
+
+func (t *tcpTransport) close() error {
+	if t.conn == nil {
+		return nil
+	}
+
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func (t *tcpTransport) send(batch [][]byte) error {
+	buffers := make(net.Buffers, len(batch))
+	copy(buffers, batch)
+	return t.write(buffers)
+}
+
+// write 写入数据，需要时建立连接，失败时断开，下次发送时重连
+func (t *tcpTransport) write(buffers net.Buffers) error {
+	if t.conn == nil {
+		conn, err := net.DialTimeout("tcp", t.addr, t.timeout)
+		if err != nil {
+			return err
+		}
+		t.conn = conn
+	}
+
+	t.conn.SetWriteDeadline(time.Now().Add(t.timeout))
+	if _, err := buffers.WriteTo(t.conn); err != nil {
+		t.close()
+		return err
+	}
+
+	return nil
+}
+
+func (t *udpTransport) close() error {
+	return t.conn.Close()
+}
+
+func (t *udpTransport) send(batch [][]byte) error {
+	for _, line := range batch {
+		if _, err := t.conn.Write(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isShipRejected 判断收集器是否拒绝了请求内容，除超时和限流外的 4xx 重试也不会成功
+func isShipRejected(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests:
+		return false
+	default:
+		return status >= http.StatusBadRequest && status < http.StatusInternalServerError
+	}
+}
+
+// appendMsgpackArrayHeader 追加 msgpack 数组头
+func appendMsgpackArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return append(b, 0xdc, byte(n>>8), byte(n))
+	default:
+		return append(b, 0xdd, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+}
+
+// appendMsgpackValue 将 JSON 解码的值编码为 msgpack
+func appendMsgpackValue(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if val {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int64:
+		// 统一使用 int64 格式，不区分长度
+		return append(b, 0xd3, byte(val>>56), byte(val>>48), byte(val>>40), byte(val>>32),
+			byte(val>>24), byte(val>>16), byte(val>>8), byte(val))
+	case float64:
+		bits := math.Float64bits(val)
+		return append(b, 0xcb, byte(bits>>56), byte(bits>>48), byte(bits>>40), byte(bits>>32),
+			byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return appendMsgpackValue(b, n)
+		}
+		f, _ := val.Float64()
+		return appendMsgpackValue(b, f)
+	case string:
+		n := len(val)
+		switch {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = append(b, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			b = append(b, 0xda, byte(n>>8), byte(n))
+		default:
+			b = append(b, 0xdb, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+		}
+		return append(b, val...)
+	case []any:
+		b = appendMsgpackArrayHeader(b, len(val))
+		for _, item := range val {
+			b = appendMsgpackValue(b, item)
+		}
+		return b
+	case map[string]any:
+		n := len(val)
+		switch {
+		case n < 16:
+			b = append(b, 0x80|byte(n))
+		case n <= math.MaxUint16:
+			b = append(b, 0xde, byte(n>>8), byte(n))
+		default:
+			b = append(b, 0xdf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+		}
+		for k, item := range val {
+			b = appendMsgpackValue(b, k)
+			b = appendMsgpackValue(b, item)
+		}
+		return b
+	default:
+		return appendMsgpackValue(b, fmt.Sprint(val))
+	}
+}
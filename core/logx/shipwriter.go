@@ -0,0 +1,546 @@
+package logx
+
+import (
+	"errors"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/errorx"
+	"github.com/YunFy26/mini-zero/core/lang"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+const (
+	// ShipForward ships entries with the Fluentd/Fluent Bit forward protocol.
+	ShipForward = "forward"
+	// ShipHTTP ships entries with HTTP bulk POST requests.
+	ShipHTTP = "http"
+	// ShipTCP ships entries as newline delimited JSON over TCP.
+	ShipTCP = "tcp"
+	// ShipUDP ships entries as JSON datagrams, one entry per datagram.
+	ShipUDP = "udp"
+
+	defaultShipBufferSize    = 8192
+	defaultShipBatchSize     = 512
+	defaultShipFlushInterval = time.Second
+	defaultShipRetries       = 3
+	defaultShipMinBackoff    = 100 * time.Millisecond
+	defaultShipMaxBackoff    = 30 * time.Second
+	defaultShipTimeout       = 5 * time.Second
+	defaultShipStatInterval  = time.Minute
+	defaultShipTag           = "logx"
+)
+
+type (
+	// ShipOption customizes a ShipWriter.
+	ShipOption func(opts *shipOptions)
+
+	// ShipStats is the delivery statistics of a ShipWriter.
+	ShipStats struct {
+		// Sent is the number of entries delivered to the collector, including replayed ones.
+		Sent uint64
+		// Failed is the number of entries dropped after all retries failed,
+		// or rejected by the collector, or invalid in the spool.
+		Failed uint64
+		// Dropped is the number of entries dropped because the buffer was full.
+		Dropped uint64
+		// Spooled is the number of entries written to the disk spool.
+		Spooled uint64
+		// Replayed is the number of spooled entries delivered to the collector.
+		Replayed uint64
+	}
+
+	// ShipWriter is a Writer that ships JSON entries to a remote collector.
+	// Entries are batched in a background goroutine, and failed batches are retried
+	// with exponential backoff. If a spool is configured, batches that still fail are
+	// written to disk, and replayed in order once the collector is back.
+	ShipWriter struct {
+		transport shipTransport
+		encode    shipEncoder
+		options   shipOptions
+		spool     *shipSpool
+		clock     timex.Clock
+		channel   chan []byte
+		done      chan lang.PlaceholderType
+		stopped   chan lang.PlaceholderType
+		once      sync.Once
+		closeErr  error
+		// closed 在 closeLock 的写锁中设置，ship 持有读锁写入缓冲区
+		// 保证关闭后不会再有日志进入缓冲区，关闭前进入的日志都被发送
+		closeLock sync.RWMutex
+		closed    bool
+		// 关闭时写出剩余日志的截止时间，超过后不再发送，未关闭时为零值
+		drainDeadline time.Time
+		// 连续重放失败的次数，用于计算下次重放的时间
+		replayFailures int
+		nextReplay     time.Time
+		lastStats      ShipStats
+		sent           uint64
+		failed         uint64
+		dropped        uint64
+		spooled        uint64
+		replayed       uint64
+	}
+
+	// shipEncoder 将一条日志编码为单行文本，不含结尾的换行
+	shipEncoder func(b []byte, keys *systemKeys, level string, val any, fields []LogField) []byte
+
+	shipOptions struct {
+		bufferSize    int
+		batchSize     int
+		flushInterval time.Duration
+		retries       int
+		minBackoff    time.Duration
+		maxBackoff    time.Duration
+		timeout       time.Duration
+		statInterval  time.Duration
+		gzip          bool
+		httpFormat    string
+		headers       map[string]string
+		labels        map[string]string
+		tag           string
+		spoolFile     string
+		spoolMaxSize  int
+		spoolBackups  int
+		resource      map[string]string
+	}
+)
+
+// NewShipWriter returns a ShipWriter that ships entries to addr with the given protocol,
+// which can be ShipForward, ShipHTTP, ShipTCP or ShipUDP. For ShipHTTP, addr is the URL
+// to post to, such as http://localhost:9200/_bulk.
+// Close flushes all buffered entries before returning.
+func NewShipWriter(protocol, addr string, opts ...ShipOption) (*ShipWriter, error) {
+	options := newShipOptions(opts)
+	transport, err := newShipTransport(protocol, addr, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return newShipWriter(transport, appendJsonEntry, options), nil
+}
+
+func newShipWriter(transport shipTransport, encode shipEncoder, options shipOptions) *ShipWriter {
+	w := &ShipWriter{
+		transport: transport,
+		encode:    encode,
+		options:   options,
+		clock:     clock,
+		channel:   make(chan []byte, options.bufferSize),
+		done:      make(chan lang.PlaceholderType),
+		stopped:   make(chan lang.PlaceholderType),
+	}
+	if len(options.spoolFile) > 0 {
+		w.spool = newShipSpool(options.spoolFile, options.spoolMaxSize, options.spoolBackups)
+	}
+
+	// 定时器在启动后台前创建，避免与测试中替换 clock 竞争
+	var statTicker timex.Ticker
+	if options.statInterval > 0 {
+		statTicker = w.clock.NewTicker(options.statInterval)
+	}
+	go w.run(w.clock.NewTicker(options.flushInterval), statTicker)
+
+	return w
+}
+
+func newShipOptions(opts []ShipOption) shipOptions {
+	options := shipOptions{
+		bufferSize:    defaultShipBufferSize,
+		batchSize:     defaultShipBatchSize,
+		flushInterval: defaultShipFlushInterval,
+		retries:       defaultShipRetries,
+		minBackoff:    defaultShipMinBackoff,
+		maxBackoff:    defaultShipMaxBackoff,
+		timeout:       defaultShipTimeout,
+		statInterval:  defaultShipStatInterval,
+		httpFormat:    ShipNDJSON,
+		tag:           defaultShipTag,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.maxBackoff < options.minBackoff {
+		options.maxBackoff = options.minBackoff
+	}
+
+	return options
+}
+
+// WithShipBackoff customizes the backoff between retries, the backoff starts from
+// minBackoff and doubles on each failure, up to maxBackoff.
+func WithShipBackoff(minBackoff, maxBackoff time.Duration) ShipOption {
+	return func(opts *shipOptions) {
+		if minBackoff > 0 {
+			opts.minBackoff = minBackoff
+		}
+		if maxBackoff > 0 {
+			opts.maxBackoff = maxBackoff
+		}
+	}
+}
+
+// WithShipBatchSize customizes the max number of entries in one delivery.
+func WithShipBatchSize(size int) ShipOption {
+	return func(opts *shipOptions) {
+		if size > 0 {
+			opts.batchSize = size
+		}
+	}
+}
+
+// WithShipBufferSize customizes the number of entries that can be buffered,
+// entries are dropped when the buffer is full.
+func WithShipBufferSize(size int) ShipOption {
+	return func(opts *shipOptions) {
+		if size > 0 {
+			opts.bufferSize = size
+		}
+	}
+}
+
+// WithShipFlushInterval customizes the interval of periodic deliveries.
+func WithShipFlushInterval(interval time.Duration) ShipOption {
+	return func(opts *shipOptions) {
+		if interval > 0 {
+			opts.flushInterval = interval
+		}
+	}
+}
+
+// WithShipGzip enables gzip compression of HTTP request bodies.
+func WithShipGzip() ShipOption {
+	return func(opts *shipOptions) {
+		opts.gzip = true
+	}
+}
+
+// WithShipHTTPFormat customizes the body format of ShipHTTP,
+// which can be ShipNDJSON, ShipElasticBulk or ShipLoki, defaults to ShipNDJSON.
+func WithShipHTTPFormat(format string) ShipOption {
+	return func(opts *shipOptions) {
+		opts.httpFormat = format
+	}
+}
+
+// WithShipHeaders adds headers to HTTP requests, such as Authorization.
+func WithShipHeaders(headers map[string]string) ShipOption {
+	return func(opts *shipOptions) {
+		opts.headers = headers
+	}
+}
+
+// WithShipLabels customizes the stream labels of ShipLoki.
+func WithShipLabels(labels map[string]string) ShipOption {
+	return func(opts *shipOptions) {
+		opts.labels = labels
+	}
+}
+
+// WithShipRetries customizes the number of retries before a batch is spooled or dropped.
+func WithShipRetries(retries int) ShipOption {
+	return func(opts *shipOptions) {
+		if retries >= 0 {
+			opts.retries = retries
+		}
+	}
+}
+
+// WithShipSpool enables the disk spool, batches that cannot be delivered are written
+// to filename, rotated by maxSize in megabytes, and at most maxBackups rotated files are kept.
+func WithShipSpool(filename string, maxSize, maxBackups int) ShipOption {
+	return func(opts *shipOptions) {
+		opts.spoolFile = filename
+		opts.spoolMaxSize = maxSize
+		opts.spoolBackups = maxBackups
+	}
+}
+
+// WithShipStatInterval customizes the interval of reporting delivery stats
+// through the stat log, 0 means never report.
+func WithShipStatInterval(interval time.Duration) ShipOption {
+	return func(opts *shipOptions) {
+		if interval >= 0 {
+			opts.statInterval = interval
+		}
+	}
+}
+
+// WithShipTag customizes the tag of ShipForward, defaults to logx.
+func WithShipTag(tag string) ShipOption {
+	return func(opts *shipOptions) {
+		opts.tag = tag
+	}
+}
+
+// WithShipTimeout customizes the timeout of dialing and sending.
+func WithShipTimeout(timeout time.Duration) ShipOption {
+	return func(opts *shipOptions) {
+		if timeout > 0 {
+			opts.timeout = timeout
+		}
+	}
+}
+
+func (w *ShipWriter) Alert(v any) {
+	w.ship(levelAlert, v, nil)
+}
+
+// Close flushes the buffered entries, spools the undelivered ones if the spool
+// is enabled, then closes the connection. The flush takes at most the send timeout,
+// the entries not delivered by then are spooled, or counted as failed without a spool.
+func (w *ShipWriter) Close() error {
+	w.once.Do(func() {
+		w.closeLock.Lock()
+		w.closed = true
+		w.closeLock.Unlock()
+		close(w.done)
+		<-w.stopped
+	})
+
+	return w.closeErr
+}
+
+func (w *ShipWriter) Debug(v any, fields ...LogField) {
+	w.ship(levelDebug, v, fields)
+}
+
+func (w *ShipWriter) Error(v any, fields ...LogField) {
+	w.ship(levelError, v, fields)
+}
+
+func (w *ShipWriter) Fatal(v any, fields ...LogField) {
+	w.ship(levelFatal, v, fields)
+}
+
+func (w *ShipWriter) Info(v any, fields ...LogField) {
+	w.ship(levelInfo, v, fields)
+}
+
+func (w *ShipWriter) Severe(v any) {
+	w.ship(levelSevere, v, nil)
+}
+
+func (w *ShipWriter) Slow(v any, fields ...LogField) {
+	w.ship(levelSlow, v, fields)
+}
+
+func (w *ShipWriter) Stack(v any) {
+	w.ship(levelError, v, nil)
+}
+
+func (w *ShipWriter) Stat(v any, fields ...LogField) {
+	w.ship(levelStat, v, fields)
+}
+
+// Stats returns the delivery statistics.
+func (w *ShipWriter) Stats() ShipStats {
+	return ShipStats{
+		Sent:     atomic.LoadUint64(&w.sent),
+		Failed:   atomic.LoadUint64(&w.failed),
+		Dropped:  atomic.LoadUint64(&w.dropped),
+		Spooled:  atomic.LoadUint64(&w.spooled),
+		Replayed: atomic.LoadUint64(&w.replayed),
+	}
+}
+
+// backoff 返回第 attempt 次失败后的等待时间，指数增长并加入随机抖动，避免多个实例同时重连
+func (w *ShipWriter) backoff(attempt int) time.Duration {
+	d := w.options.maxBackoff
+	if attempt < 32 {
+		if next := w.options.minBackoff << attempt; next > 0 && next < d {
+			d = next
+		}
+	}
+
+	return d/2 + rand.N(d/2+1)
+}
+
+// deliver 发送一批日志，失败时写入磁盘缓存，没有缓存时丢弃
+func (w *ShipWriter) deliver(batch [][]byte) {
+	// 磁盘缓存中还有未发送的日志时，继续写入缓存以保证顺序
+	if w.spool != nil && w.spool.pending {
+		w.spoolBatch(batch)
+		return
+	}
+
+	err := w.sendWithRetry(batch)
+	if err == nil {
+		atomic.AddUint64(&w.sent, uint64(len(batch)))
+		return
+	}
+
+	// 被拒绝的日志写入缓存也无法发送
+	if w.spool != nil && !errors.Is(err, errShipRejected) {
+		w.spoolBatch(batch)
+		w.replayFailures = 0
+		w.nextReplay = w.clock.Now().Add(w.backoff(0))
+		return
+	}
+
+	atomic.AddUint64(&w.failed, uint64(len(batch)))
+	log.Printf("failed to ship %d log entries: %v", len(batch), err)
+}
+
+// flush 按 batchSize 分批发送
+func (w *ShipWriter) flush(batch [][]byte) {
+	for len(batch) > 0 {
+		n := min(len(batch), w.options.batchSize)
+		w.deliver(batch[:n])
+		batch = batch[n:]
+	}
+}
+
+// replay 到达重放时间时，按顺序发送磁盘缓存中的日志
+func (w *ShipWriter) replay() {
+	if w.spool == nil || !w.spool.pending || w.clock.Now().Before(w.nextReplay) {
+		return
+	}
+
+	n, failed, err := w.spool.replay(w.send, w.options.batchSize)
+	atomic.AddUint64(&w.sent, uint64(n))
+	atomic.AddUint64(&w.replayed, uint64(n))
+	atomic.AddUint64(&w.failed, uint64(failed))
+	if err != nil {
+		w.replayFailures++
+		w.nextReplay = w.clock.Now().Add(w.backoff(w.replayFailures))
+		return
+	}
+
+	w.replayFailures = 0
+}
+
+// reportStats 通过 stat 日志输出发送统计，没有变化时不输出
+func (w *ShipWriter) reportStats() {
+	stats := w.Stats()
+	if stats == w.lastStats {
+		return
+	}
+
+	w.lastStats = stats
+	Statf("log shipping - sent: %d, failed: %d, dropped: %d, spooled: %d, replayed: %d",
+		stats.Sent, stats.Failed, stats.Dropped, stats.Spooled, stats.Replayed)
+}
+
+func (w *ShipWriter) run(ticker, statTicker timex.Ticker) {
+	defer close(w.stopped)
+	defer ticker.Stop()
+
+	var statC <-chan time.Time
+	if statTicker != nil {
+		defer statTicker.Stop()
+		statC = statTicker.Chan()
+	}
+
+	batch := make([][]byte, 0, w.options.batchSize)
+	for {
+		select {
+		case entry := <-w.channel:
+			batch = append(batch, entry)
+			if len(batch) >= w.options.batchSize {
+				w.deliver(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.Chan():
+			w.replay()
+			if len(batch) > 0 {
+				w.deliver(batch)
+				batch = batch[:0]
+			}
+		case <-statC:
+			w.reportStats()
+		case <-w.done:
+			w.shutdown(batch)
+			return
+		}
+	}
+}
+
+// send 发送一批日志，关闭时超过截止时间后不再发送
+func (w *ShipWriter) send(batch [][]byte) error {
+	if !w.drainDeadline.IsZero() && !w.clock.Now().Before(w.drainDeadline) {
+		return errShipDrainTimeout
+	}
+
+	return w.transport.send(batch)
+}
+
+// sendWithRetry 发送一批日志，失败时按指数退避重试，关闭时不再等待
+func (w *ShipWriter) sendWithRetry(batch [][]byte) error {
+	for attempt := 0; ; attempt++ {
+		err := w.send(batch)
+		if err == nil || attempt >= w.options.retries || errors.Is(err, errShipRejected) {
+			return err
+		}
+
+		select {
+		case <-w.clock.After(w.backoff(attempt)):
+		case <-w.done:
+			return err
+		}
+	}
+}
+
+func (w *ShipWriter) ship(level string, val any, fields []LogField) {
+	keys := loadFieldKeys()
+	fields = mergeGloablFields(fields)
+	val, truncated := processContent(val)
+	if truncated {
+		fields = append(fields, Field(keys.truncated, true))
+	}
+	entry := w.encode(make([]byte, 0, 256), keys, level, val, fields)
+
+	w.closeLock.RLock()
+	defer w.closeLock.RUnlock()
+
+	// 关闭后的日志直接丢弃
+	if w.closed {
+		atomic.AddUint64(&w.dropped, 1)
+		return
+	}
+
+	// 缓冲区满时丢弃，不阻塞业务
+	select {
+	case w.channel <- append(entry, '\n'):
+	default:
+		atomic.AddUint64(&w.dropped, 1)
+	}
+}
+
+// shutdown 写出剩余的日志，然后关闭缓存和连接
+// 整个过程共用一个截止时间，收集器不可用时不会为每一批日志等待一次超时
+func (w *ShipWriter) shutdown(batch [][]byte) {
+	w.drainDeadline = w.clock.Now().Add(w.options.timeout)
+	// 只有当前协程读取，可以直接按长度取出
+	for len(w.channel) > 0 {
+		batch = append(batch, <-w.channel)
+	}
+
+	// 收集器恢复时先重放缓存，保证顺序
+	if w.spool != nil && w.spool.pending {
+		w.nextReplay = time.Time{}
+		w.replay()
+	}
+	w.flush(batch)
+
+	var be errorx.BatchError
+	if w.spool != nil {
+		be.Add(w.spool.close())
+	}
+	be.Add(w.transport.close())
+	w.closeErr = be.Err()
+}
+
+// spoolBatch 将一批日志写入磁盘缓存，写入失败时丢弃
+func (w *ShipWriter) spoolBatch(batch [][]byte) {
+	if err := w.spool.append(batch); err != nil {
+		atomic.AddUint64(&w.failed, uint64(len(batch)))
+		log.Printf("failed to spool %d log entries: %v", len(batch), err)
+		return
+	}
+
+	atomic.AddUint64(&w.spooled, uint64(len(batch)))
+}
@@ -0,0 +1,691 @@
+package logx
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/lang"
+	"github.com/YunFy26/mini-zero/core/timex"
+)
+
+// shipCollector 模拟 HTTP 收集器，available 为 false 时返回 503
+type shipCollector struct {
+	*httptest.Server
+	available atomic.Bool
+	requests  atomic.Int32
+	lock      sync.Mutex
+	bodies    []string
+	headers   []http.Header
+}
+
+func newShipCollector(t *testing.T) *shipCollector {
+	c := new(shipCollector)
+	c.available.Store(true)
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.requests.Add(1)
+		if !c.available.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var reader io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			reader = zr
+		}
+		body, _ := io.ReadAll(reader)
+
+		c.lock.Lock()
+		c.bodies = append(c.bodies, string(body))
+		c.headers = append(c.headers, r.Header)
+		c.lock.Unlock()
+	}))
+	t.Cleanup(c.Close)
+
+	return c
+}
+
+// lines 返回收到的所有 JSON 行的 content
+func (c *shipCollector) lines() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var result []string
+	for _, body := range c.bodies {
+		for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				continue
+			}
+			// 跳过 bulk 格式的 action 行
+			if content, ok := entry[defaultContentKey].(string); ok {
+				result = append(result, content)
+			}
+		}
+	}
+
+	return result
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("等待超时")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestShipWriterTCP(t *testing.T) {
+	resetGlobalFields(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			received <- scanner.Text()
+		}
+	}()
+
+	w, err := NewShipWriter(ShipTCP, ln.Addr().String(), WithShipFlushInterval(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("hello", Field("foo", "bar"))
+	w.Error("world")
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expect := range []string{`"level":"info","content":"hello","foo":"bar"}`, `"level":"error","content":"world"}`} {
+		select {
+		case line := <-received:
+			if !strings.HasSuffix(line, expect) {
+				t.Errorf("期望以 %s 结尾, 实际 %s", expect, line)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("没有收到日志")
+		}
+	}
+	if stats := w.Stats(); stats.Sent != 2 {
+		t.Errorf("期望发送 2 条, 实际 %+v", stats)
+	}
+}
+
+func TestShipWriterUDP(t *testing.T) {
+	resetGlobalFields(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewShipWriter(ShipUDP, conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Slow("slow")
+	w.Close()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(string(buf[:n]), `"level":"slow","content":"slow"}`+"\n") {
+		t.Errorf("数据报内容错误: %s", buf[:n])
+	}
+}
+
+func TestShipWriterHTTP(t *testing.T) {
+	resetGlobalFields(t)
+
+	t.Run("ndjson with gzip", func(t *testing.T) {
+		c := newShipCollector(t)
+		w, err := NewShipWriter(ShipHTTP, c.URL, WithShipGzip(),
+			WithShipHeaders(map[string]string{"Authorization": "Bearer token"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Info("a")
+		w.Info("b")
+		w.Close()
+
+		if lines := c.lines(); strings.Join(lines, ",") != "a,b" {
+			t.Errorf("收到的日志错误: %v", lines)
+		}
+		header := c.headers[0]
+		if header.Get("Authorization") != "Bearer token" || header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("请求头错误: %v", header)
+		}
+	})
+
+	t.Run("elasticsearch bulk", func(t *testing.T) {
+		c := newShipCollector(t)
+		w, err := NewShipWriter(ShipHTTP, c.URL, WithShipHTTPFormat(ShipElasticBulk))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Info("a")
+		w.Info("b")
+		w.Close()
+
+		lines := strings.Split(strings.TrimSpace(c.bodies[0]), "\n")
+		if len(lines) != 4 || lines[0] != `{"index":{}}` || lines[2] != `{"index":{}}` {
+			t.Errorf("bulk 格式错误: %v", lines)
+		}
+		if got := c.lines(); strings.Join(got, ",") != "a,b" {
+			t.Errorf("收到的日志错误: %v", got)
+		}
+	})
+
+	t.Run("loki", func(t *testing.T) {
+		c := newShipCollector(t)
+		w, err := NewShipWriter(ShipHTTP, c.URL, WithShipHTTPFormat(ShipLoki),
+			WithShipLabels(map[string]string{"app": "demo"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Info("a")
+		w.Info("b")
+		w.Close()
+
+		var push struct {
+			Streams []struct {
+				Stream map[string]string `json:"stream"`
+				Values [][2]string       `json:"values"`
+			} `json:"streams"`
+		}
+		if err = json.Unmarshal([]byte(c.bodies[0]), &push); err != nil {
+			t.Fatal(err)
+		}
+		if len(push.Streams) != 1 || push.Streams[0].Stream["app"] != "demo" || len(push.Streams[0].Values) != 2 {
+			t.Fatalf("loki 格式错误: %s", c.bodies[0])
+		}
+		values := push.Streams[0].Values
+		if values[0][0] >= values[1][0] || !strings.Contains(values[1][1], `"content":"b"`) {
+			t.Errorf("loki 日志错误: %v", values)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		if _, err := NewShipWriter(ShipHTTP, "http://localhost", WithShipHTTPFormat("foo")); err == nil {
+			t.Error("未知格式应返回错误")
+		}
+		if _, err := NewShipWriter("foo", "localhost:1234"); err == nil {
+			t.Error("未知协议应返回错误")
+		}
+	})
+}
+
+func TestShipWriterForward(t *testing.T) {
+	resetGlobalFields(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		data, _ := io.ReadAll(conn)
+		received <- data
+	}()
+
+	w, err := NewShipWriter(ShipForward, ln.Addr().String(), WithShipTag("app.access"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("hello", Field("count", 3), Field("ratio", 0.5), Field("ok", true), Field("tags", []string{"a"}))
+	w.Close()
+
+	var data []byte
+	select {
+	case data = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("没有收到日志")
+	}
+
+	msg, rest := decodeMsgpack(t, data)
+	if len(rest) != 0 {
+		t.Errorf("多余的数据: %d 字节", len(rest))
+	}
+	forward := msg.([]any)
+	if forward[0] != "app.access" {
+		t.Errorf("tag 错误: %v", forward[0])
+	}
+	entries := forward[1].([]any)
+	if len(entries) != 1 {
+		t.Fatalf("期望 1 条日志, 实际 %d", len(entries))
+	}
+	entry := entries[0].([]any)
+	if ts := entry[0].(int64); time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Errorf("时间错误: %d", ts)
+	}
+	record := entry[1].(map[string]any)
+	if record["content"] != "hello" || record["count"] != int64(3) || record["ratio"] != 0.5 ||
+		record["ok"] != true || record["tags"].([]any)[0] != "a" {
+		t.Errorf("记录错误: %v", record)
+	}
+}
+
+func TestShipWriterRetry(t *testing.T) {
+	resetGlobalFields(t)
+	c := newShipCollector(t)
+	c.available.Store(false)
+	w, err := NewShipWriter(ShipHTTP, c.URL, WithShipRetries(5),
+		WithShipBackoff(time.Millisecond, 5*time.Millisecond), WithShipBatchSize(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Info("a")
+	waitFor(t, func() bool {
+		return c.requests.Load() >= 3
+	})
+	c.available.Store(true)
+	waitFor(t, func() bool {
+		return w.Stats().Sent == 1
+	})
+	if lines := c.lines(); len(lines) != 1 || lines[0] != "a" {
+		t.Errorf("收到的日志错误: %v", lines)
+	}
+}
+
+func TestShipWriterFailedWithoutSpool(t *testing.T) {
+	resetGlobalFields(t)
+	c := newShipCollector(t)
+	c.available.Store(false)
+	w, err := NewShipWriter(ShipHTTP, c.URL, WithShipRetries(1),
+		WithShipBackoff(time.Millisecond, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Info("a")
+	w.Info("b")
+	w.Close()
+	if stats := w.Stats(); stats.Failed != 2 || stats.Sent != 0 {
+		t.Errorf("统计错误: %+v", stats)
+	}
+	// 关闭后的日志直接丢弃
+	w.Info("c")
+	if stats := w.Stats(); stats.Dropped != 1 {
+		t.Errorf("统计错误: %+v", stats)
+	}
+}
+
+func TestShipWriterSpool(t *testing.T) {
+	resetGlobalFields(t)
+	c := newShipCollector(t)
+	c.available.Store(false)
+	spool := filepath.Join(t.TempDir(), "spool", "ship.log")
+	w, err := NewShipWriter(ShipHTTP, c.URL, WithShipRetries(0), WithShipBatchSize(2),
+		WithShipBackoff(time.Millisecond, 10*time.Millisecond),
+		WithShipFlushInterval(10*time.Millisecond), WithShipSpool(spool, 1, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		w.Info(v)
+	}
+	waitFor(t, func() bool {
+		return w.Stats().Spooled == 5
+	})
+
+	c.available.Store(true)
+	waitFor(t, func() bool {
+		return w.Stats().Replayed == 5
+	})
+	w.Info("f")
+	waitFor(t, func() bool {
+		return w.Stats().Sent == 6
+	})
+
+	// 重放后的顺序与写入顺序一致
+	if lines := c.lines(); strings.Join(lines, ",") != "a,b,c,d,e,f" {
+		t.Errorf("收到的日志错误: %v", lines)
+	}
+	if _, err = os.Stat(spool); !os.IsNotExist(err) {
+		t.Error("重放后应删除缓存文件")
+	}
+}
+
+func TestShipWriterSpoolAcrossRestart(t *testing.T) {
+	resetGlobalFields(t)
+	c := newShipCollector(t)
+	c.available.Store(false)
+	spool := filepath.Join(t.TempDir(), "ship.log")
+	opts := []ShipOption{
+		WithShipRetries(0), WithShipFlushInterval(10 * time.Millisecond),
+		WithShipBackoff(time.Millisecond, 10*time.Millisecond), WithShipSpool(spool, 1, 3),
+	}
+
+	w, err := NewShipWriter(ShipHTTP, c.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("a")
+	w.Info("b")
+	w.Close()
+	if stats := w.Stats(); stats.Spooled != 2 {
+		t.Fatalf("关闭时应写入缓存: %+v", stats)
+	}
+
+	// 重启后重放上次留下的缓存
+	c.available.Store(true)
+	w, err = NewShipWriter(ShipHTTP, c.URL, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Info("c")
+	w.Close()
+	if lines := c.lines(); strings.Join(lines, ",") != "a,b,c" {
+		t.Errorf("收到的日志错误: %v", lines)
+	}
+}
+
+func TestShipWriterReplayBackoff(t *testing.T) {
+	resetGlobalFields(t)
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	c := newShipCollector(t)
+	c.available.Store(false)
+	spool := filepath.Join(t.TempDir(), "ship.log")
+	w, err := NewShipWriter(ShipHTTP, c.URL, WithShipRetries(0), WithShipBatchSize(1),
+		WithShipBackoff(10*time.Second, 10*time.Second), WithShipFlushInterval(time.Second),
+		WithShipStatInterval(0), WithShipSpool(spool, 1, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Info("a")
+	waitFor(t, func() bool {
+		return w.Stats().Spooled == 1
+	})
+
+	// 退避时间在 5s 到 10s 之间，未到重放时间时不重放
+	c.available.Store(true)
+	fake.Advance(time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if n := c.requests.Load(); n != 1 {
+		t.Errorf("未到重放时间时不应发送，实际请求 %d 次", n)
+	}
+
+	fake.Advance(10 * time.Second)
+	waitFor(t, func() bool {
+		return w.Stats().Replayed == 1
+	})
+}
+
+func TestShipWriterCloseRace(t *testing.T) {
+	resetGlobalFields(t)
+	c := newShipCollector(t)
+	w, err := NewShipWriter(ShipHTTP, c.URL, WithShipStatInterval(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const goroutines, count = 8, 200
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				w.Info("a")
+			}
+		}()
+	}
+	w.Close()
+	wg.Wait()
+
+	// 每条日志要么被发送，要么计入丢弃数
+	if stats := w.Stats(); stats.Sent+stats.Dropped != goroutines*count {
+		t.Errorf("关闭时有日志丢失且未计数: %+v", stats)
+	}
+}
+
+// slowTransport 每次发送耗时 timeout 后失败，使用 FakeClock 模拟耗时
+type slowTransport struct {
+	clock   *timex.FakeClock
+	timeout time.Duration
+	calls   int
+}
+
+func (t *slowTransport) close() error {
+	return nil
+}
+
+func (t *slowTransport) send(_ [][]byte) error {
+	t.calls++
+	t.clock.Advance(t.timeout)
+	return io.EOF
+}
+
+func TestShipWriterShutdownDeadline(t *testing.T) {
+	fake := useFakeClock(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC))
+	options := newShipOptions([]ShipOption{WithShipRetries(0), WithShipBatchSize(1)})
+	transport := &slowTransport{clock: fake, timeout: options.timeout}
+	w := &ShipWriter{
+		transport: transport,
+		options:   options,
+		clock:     fake,
+		channel:   make(chan []byte, 10),
+		done:      make(chan lang.PlaceholderType),
+	}
+	close(w.done)
+
+	batch := make([][]byte, 20)
+	for i := range batch {
+		batch[i] = []byte("{}\n")
+	}
+	// 收集器不可用时，超过截止时间后剩余的批次不再发送
+	w.shutdown(batch)
+	if transport.calls != 1 {
+		t.Errorf("期望发送 1 次，实际 %d 次", transport.calls)
+	}
+	if stats := w.Stats(); stats.Failed != 20 {
+		t.Errorf("统计错误: %+v", stats)
+	}
+}
+
+func TestShipSpoolPartialReplay(t *testing.T) {
+	spool := newShipSpool(filepath.Join(t.TempDir(), "ship.log"), 1, 3)
+	if err := spool.append([][]byte{[]byte("\"a\"\n"), []byte("\"b\"\n"), []byte("\"c\"\n")}); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int
+	n, _, err := spool.replay(func(batch [][]byte) error {
+		calls++
+		if calls > 1 {
+			return io.EOF
+		}
+		return nil
+	}, 1)
+	if err == nil || n != 1 || !spool.pending {
+		t.Fatalf("期望发送 1 条后失败, 实际 %d, %v", n, err)
+	}
+
+	// 已发送的部分不会重复发送
+	var lines []string
+	n, _, err = spool.replay(func(batch [][]byte) error {
+		for _, line := range batch {
+			lines = append(lines, string(line))
+		}
+		return nil
+	}, 10)
+	if err != nil || n != 2 || spool.pending || strings.Join(lines, "") != "\"b\"\n\"c\"\n" {
+		t.Errorf("重放错误: %d, %v, %q", n, err, lines)
+	}
+}
+
+func TestShipSpoolInvalidLines(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "ship.log")
+	// 无法解析的行和进程崩溃留下的不完整的最后一行
+	content := "{\"content\":\"a\"}\nnot json\n{\"content\":\"b\"}\n{\"content\":\"c"
+	if err := os.WriteFile(file, []byte(content), defaultFileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	spool := newShipSpool(file, 1, 3)
+	var lines []string
+	sent, failed, err := spool.replay(func(batch [][]byte) error {
+		for _, line := range batch {
+			lines = append(lines, string(line))
+		}
+		return nil
+	}, 10)
+	if err != nil || sent != 2 || failed != 2 || spool.pending || len(lines) != 2 {
+		t.Errorf("应丢弃无效的行: %d, %d, %v, %q", sent, failed, err, lines)
+	}
+
+	// 被收集器拒绝的日志丢弃，不再重放
+	if err = spool.append([][]byte{[]byte("\"d\"\n")}); err != nil {
+		t.Fatal(err)
+	}
+	sent, failed, err = spool.replay(func(batch [][]byte) error {
+		return errShipRejected
+	}, 10)
+	if err != nil || sent != 0 || failed != 1 || spool.pending {
+		t.Errorf("被拒绝的日志应丢弃: %d, %d, %v", sent, failed, err)
+	}
+}
+
+func TestShipWriterRejected(t *testing.T) {
+	resetGlobalFields(t)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	spool := filepath.Join(t.TempDir(), "ship.log")
+	w, err := NewShipWriter(ShipHTTP, server.URL, WithShipRetries(3),
+		WithShipBackoff(time.Millisecond, time.Millisecond), WithShipSpool(spool, 1, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Info("a")
+	w.Close()
+	// 被拒绝的日志不重试，也不写入缓存
+	if stats := w.Stats(); stats.Failed != 1 || stats.Spooled != 0 || requests.Load() != 1 {
+		t.Errorf("统计错误: %+v, 请求 %d 次", stats, requests.Load())
+	}
+}
+
+func TestShipWriterReportStats(t *testing.T) {
+	resetGlobalFields(t)
+	mw := new(mockWriter)
+	old := writer.Swap(mw)
+	defer writer.Store(old)
+
+	c := newShipCollector(t)
+	w, err := NewShipWriter(ShipHTTP, c.URL, WithShipFlushInterval(5*time.Millisecond),
+		WithShipStatInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Info("a")
+	waitFor(t, func() bool {
+		return mw.Contains("log shipping - sent: 1, failed: 0, dropped: 0, spooled: 0, replayed: 0")
+	})
+}
+
+func TestSplitSpoolLines(t *testing.T) {
+	lines, invalid := splitSpoolLines([]byte("1\n\n{\"a\":2}\nb\n3"))
+	if len(lines) != 2 || string(lines[0]) != "1\n" || string(lines[1]) != "{\"a\":2}\n" || invalid != 2 {
+		t.Errorf("切分错误: %q, %d", lines, invalid)
+	}
+}
+
+// decodeMsgpack 解码测试中用到的 msgpack 类型
+func decodeMsgpack(t *testing.T, b []byte) (any, []byte) {
+	t.Helper()
+	c := b[0]
+	b = b[1:]
+	switch {
+	case c == 0xc0:
+		return nil, b
+	case c == 0xc2, c == 0xc3:
+		return c == 0xc3, b
+	case c == 0xd3:
+		return int64(binary.BigEndian.Uint64(b)), b[8:]
+	case c == 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), b[8:]
+	case c&0xe0 == 0xa0:
+		n := int(c & 0x1f)
+		return string(b[:n]), b[n:]
+	case c == 0xd9:
+		n := int(b[0])
+		return string(b[1 : 1+n]), b[1+n:]
+	case c&0xf0 == 0x90, c == 0xdc:
+		n := int(c & 0x0f)
+		if c == 0xdc {
+			n = int(binary.BigEndian.Uint16(b))
+			b = b[2:]
+		}
+		result := make([]any, n)
+		for i := range result {
+			result[i], b = decodeMsgpack(t, b)
+		}
+		return result, b
+	case c&0xf0 == 0x80:
+		n := int(c & 0x0f)
+		result := make(map[string]any, n)
+		for i := 0; i < n; i++ {
+			var k, v any
+			k, b = decodeMsgpack(t, b)
+			v, b = decodeMsgpack(t, b)
+			result[k.(string)] = v
+		}
+		return result, b
+	default:
+		t.Fatalf("不支持的 msgpack 类型: %x", c)
+		return nil, nil
+	}
+}
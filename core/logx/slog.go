@@ -0,0 +1,298 @@
+package logx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// slogLevelSevere 对应 logx 的 severe 级别，slog 中显示为 ERROR+4
+	slogLevelSevere = slog.LevelError + 4
+	// slogLevelFatal 对应 logx 的 fatal 级别，slog 中显示为 ERROR+8
+	slogLevelFatal = slog.LevelError + 8
+	// slogLevelKey 无法用 slog 级别区分的 logx 级别（alert、slow、stack、stat、severe、fatal）以该字段输出
+	slogLevelKey = "logx.level"
+)
+
+type (
+	// slogHandler 将 slog 的日志记录转发到 logx 当前的 Writer
+	slogHandler struct {
+		// 通过 WithAttrs 添加的字段，已展开为带分组前缀的键名
+		fields []LogField
+		// 当前分组前缀，如 "a.b."
+		prefix string
+	}
+
+	// slogWriter 将 logx 的日志转发到任意的 slog.Handler
+	slogWriter struct {
+		handler slog.Handler
+	}
+)
+
+// NewSlogHandler returns a slog.Handler that writes the records through the logx Writer.
+//
+// slog levels are mapped as:
+//
+//	level < Info          -> Debug
+//	Info <= level < Warn  -> Info
+//	Warn <= level < Error+4 -> Error
+//	level >= Error+4      -> Severe
+//
+// Attributes in groups are flattened into dotted keys, e.g. slog.Group("req", "id", 1) -> req.id=1.
+func NewSlogHandler() slog.Handler {
+	return new(slogHandler)
+}
+
+// NewSlogWriter returns a Writer that forwards the log entries to the given slog.Handler.
+func NewSlogWriter(handler slog.Handler) Writer {
+	return &slogWriter{
+		handler: handler,
+	}
+}
+
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	// 存在按包覆盖的级别时，需要根据 Record.PC 在 Handle 中判断
+	if packageLevels.Load() != nil {
+		return true
+	}
+
+	return atomic.LoadUint32(&logLevel) <= fromSlogLevel(level)
+}
+
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if pcLevel(r.PC) > fromSlogLevel(r.Level) {
+		return nil
+	}
+
+	keys := loadFieldKeys()
+	fields := make([]LogField, 0, len(h.fields)+r.NumAttrs()+3)
+
+	if ctx != nil {
+		if arr, ok := ctx.Value(fieldsKey{}).([]LogField); ok {
+			fields = append(fields, arr...)
+		}
+	}
+
+	fields = append(fields, h.fields...)
+	r.Attrs(func(attr slog.Attr) bool {
+		fields = appendSlogAttr(fields, h.prefix, attr)
+		return true
+	})
+	fields = protectReservedFields(keys, fields)
+
+	level := fromSlogLevel(r.Level)
+	if s := activeCaller.Load(); r.PC != 0 && s.enabled(slogCallerLevel(level)) {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		fields = s.appendFrame(keys, fields, frame)
+	}
+
+	if ctx != nil {
+		if traceId := traceIdFromContext(ctx); len(traceId) > 0 {
+			fields = append(fields, Field(keys.trace, traceId))
+		}
+		if spanId := spanIdFromContext(ctx); len(spanId) > 0 {
+			fields = append(fields, Field(keys.span, spanId))
+		}
+	}
+
+	w := getWriter()
+	switch level {
+	case DebugLevel:
+		w.Debug(r.Message, fields...)
+	case InfoLevel:
+		w.Info(r.Message, fields...)
+	case ErrorLevel:
+		w.Error(r.Message, fields...)
+	default:
+		// Severe 不支持字段，将字段以 key=value 的形式拼接到内容中
+		w.Severe(joinSlogFields(r.Message, fields))
+	}
+
+	return nil
+}
+
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	fields := make([]LogField, 0, len(h.fields)+len(attrs))
+	fields = append(fields, h.fields...)
+	for _, attr := range attrs {
+		fields = appendSlogAttr(fields, h.prefix, attr)
+	}
+
+	return &slogHandler{
+		fields: fields,
+		prefix: h.prefix,
+	}
+}
+
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if len(name) == 0 {
+		return h
+	}
+
+	return &slogHandler{
+		fields: h.fields,
+		prefix: h.prefix + name + ".",
+	}
+}
+
+func (w *slogWriter) Alert(v any) {
+	w.write(levelAlert, slog.LevelWarn, v, nil)
+}
+
+func (w *slogWriter) Close() error {
+	if closer, ok := w.handler.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func (w *slogWriter) Debug(v any, fields ...LogField) {
+	w.write(levelDebug, slog.LevelDebug, v, fields)
+}
+
+func (w *slogWriter) Error(v any, fields ...LogField) {
+	w.write(levelError, slog.LevelError, v, fields)
+}
+
+func (w *slogWriter) Fatal(v any, fields ...LogField) {
+	w.write(levelFatal, slogLevelFatal, v, fields)
+}
+
+func (w *slogWriter) Info(v any, fields ...LogField) {
+	w.write(levelInfo, slog.LevelInfo, v, fields)
+}
+
+func (w *slogWriter) Severe(v any) {
+	w.write(levelSevere, slogLevelSevere, v, nil)
+}
+
+func (w *slogWriter) Slow(v any, fields ...LogField) {
+	w.write(levelSlow, slog.LevelWarn, v, fields)
+}
+
+func (w *slogWriter) Stack(v any) {
+	w.write(levelError, slog.LevelError, v, nil)
+}
+
+func (w *slogWriter) Stat(v any, fields ...LogField) {
+	w.write(levelStat, slog.LevelInfo, v, fields)
+}
+
+func (w *slogWriter) write(level string, slogLevel slog.Level, v any, fields []LogField) {
+	ctx := context.Background()
+	if !w.handler.Enabled(ctx, slogLevel) {
+		return
+	}
+
+	fields = mergeGloablFields(fields)
+	val, truncated := processContent(v)
+	record := slog.NewRecord(clock.Now(), slogLevel, slogMessage(val), 0)
+
+	switch level {
+	case levelDebug, levelInfo, levelError:
+	default:
+		record.AddAttrs(slog.String(slogLevelKey, level))
+	}
+	for _, field := range fields {
+		record.AddAttrs(slog.Any(field.Key, processFieldValue(maskField(field.Key, field.Value))))
+	}
+	if truncated {
+		record.AddAttrs(slog.Bool(loadFieldKeys().truncated, true))
+	}
+
+	if err := w.handler.Handle(ctx, record); err != nil {
+		log.Println("failed to write log:", err)
+	}
+}
+
+// appendSlogAttr 展开 slog.Attr，分组以 "." 连接为键名前缀，空属性和空分组被忽略
+func appendSlogAttr(fields []LogField, prefix string, attr slog.Attr) []LogField {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		groupPrefix := prefix
+		// 键名为空的分组直接内联
+		if len(attr.Key) > 0 {
+			groupPrefix = prefix + attr.Key + "."
+		}
+		for _, ga := range attr.Value.Group() {
+			fields = appendSlogAttr(fields, groupPrefix, ga)
+		}
+		return fields
+	}
+
+	return append(fields, Field(prefix+attr.Key, attr.Value.Any()))
+}
+
+func fromSlogLevel(level slog.Level) uint32 {
+	switch {
+	case level < slog.LevelInfo:
+		return DebugLevel
+	case level < slog.LevelWarn:
+		return InfoLevel
+	case level < slogLevelSevere:
+		return ErrorLevel
+	default:
+		return SevereLevel
+	}
+}
+
+// slogCallerLevel 返回判断是否记录调用位置使用的级别，Severe 按 error 处理
+func slogCallerLevel(level uint32) string {
+	switch level {
+	case DebugLevel:
+		return levelDebug
+	case InfoLevel:
+		return levelInfo
+	default:
+		return levelError
+	}
+}
+
+func joinSlogFields(msg string, fields []LogField) string {
+	if len(fields) == 0 {
+		return msg
+	}
+
+	var builder strings.Builder
+	builder.WriteString(msg)
+	for _, field := range fields {
+		builder.WriteByte(' ')
+		builder.WriteString(field.Key)
+		builder.WriteByte('=')
+		builder.WriteString(fmt.Sprint(processFieldValue(maskField(field.Key, field.Value))))
+	}
+
+	return builder.String()
+}
+
+// slogMessage 将日志内容转换为 slog 的消息字符串，非文本内容编码为 JSON
+func slogMessage(val any) string {
+	switch v := val.(type) {
+	case string:
+		return v
+	case error:
+		return encodeError(v)
+	case fmt.Stringer:
+		return encodeStringer(v)
+	default:
+		if content, err := marshalJson(v); err == nil {
+			return string(content)
+		}
+		return fmt.Sprint(v)
+	}
+}
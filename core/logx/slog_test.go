@@ -0,0 +1,158 @@
+package logx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type maskedPassword string
+
+func (p maskedPassword) MaskSensitive() any {
+	return "******"
+}
+
+func TestSlogHandler(t *testing.T) {
+	last := captureEntry(t)
+	logger := slog.New(NewSlogHandler())
+
+	t.Run("分组展开为点分键名", func(t *testing.T) {
+		logger.Info("hello", "a", 1, slog.Group("g", "b", "x", slog.Group("h", "c", true)))
+		entry := last()
+		if entry[defaultContentKey] != "hello" || entry[defaultLevelKey] != levelInfo {
+			t.Errorf("内容或级别错误: %v", entry)
+		}
+		if entry["a"] != float64(1) || entry["g.b"] != "x" || entry["g.h.c"] != true {
+			t.Errorf("分组字段错误: %v", entry)
+		}
+		if caller, _ := entry[defaultCallerKey].(string); !strings.HasPrefix(caller, "logx/slog_test.go:") {
+			t.Errorf("caller 应指向调用处, 实际 %v", entry[defaultCallerKey])
+		}
+	})
+
+	t.Run("WithAttrs 和 WithGroup", func(t *testing.T) {
+		logger.With("x", 1).WithGroup("req").With("method", "GET").Info("request", "id", 3)
+		entry := last()
+		if entry["x"] != float64(1) || entry["req.method"] != "GET" || entry["req.id"] != float64(3) {
+			t.Errorf("WithAttrs/WithGroup 字段错误: %v", entry)
+		}
+	})
+
+	t.Run("context 中的 trace", func(t *testing.T) {
+		ctx := ContextWithTrace(context.Background(), "trace-id", "span-id")
+		logger.InfoContext(ctx, "traced")
+		entry := last()
+		if entry[defaultTraceKey] != "trace-id" || entry[defaultSpanKey] != "span-id" {
+			t.Errorf("应包含 trace 和 span, 实际 %v", entry)
+		}
+	})
+
+	t.Run("级别映射", func(t *testing.T) {
+		tests := []struct {
+			level slog.Level
+			want  string
+		}{
+			{slog.LevelDebug, levelDebug},
+			{slog.LevelInfo, levelInfo},
+			{slog.LevelWarn, levelError},
+			{slog.LevelError, levelError},
+			{slogLevelSevere, levelSevere},
+		}
+		for _, test := range tests {
+			logger.Log(context.Background(), test.level, "leveled", "k", "v")
+			entry := last()
+			if entry[defaultLevelKey] != test.want {
+				t.Errorf("slog 级别 %v 期望映射为 %s, 实际 %v", test.level, test.want, entry[defaultLevelKey])
+			}
+		}
+		logger.Log(context.Background(), slogLevelSevere, "leveled", "k", "v")
+		if content, _ := last()[defaultContentKey].(string); !strings.HasPrefix(content, "leveled k=v ") {
+			t.Errorf("severe 级别应将字段拼接到内容中, 实际 %q", content)
+		}
+	})
+
+	t.Run("脱敏", func(t *testing.T) {
+		logger.Info("login", "password", maskedPassword("secret"))
+		if entry := last(); entry["password"] != "******" {
+			t.Errorf("字段应被脱敏, 实际 %v", entry["password"])
+		}
+	})
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	originalLevel := atomic.LoadUint32(&logLevel)
+	defer atomic.StoreUint32(&logLevel, originalLevel)
+	SetLevel(ErrorLevel)
+
+	h := NewSlogHandler()
+	if h.Enabled(context.Background(), slog.LevelInfo) {
+		t.Error("ErrorLevel 下不应启用 info")
+	}
+	if !h.Enabled(context.Background(), slog.LevelWarn) {
+		t.Error("ErrorLevel 下应启用 warn")
+	}
+}
+
+func TestSlogHandlerTruncate(t *testing.T) {
+	last := captureEntry(t)
+	old := atomic.SwapUint32(&maxContentLength, 3)
+	defer atomic.StoreUint32(&maxContentLength, old)
+
+	slog.New(NewSlogHandler()).Info("abcdef")
+	entry := last()
+	if entry[defaultContentKey] != "abc" || entry[defaultTruncatedKey] != true {
+		t.Errorf("内容应被截断, 实际 %v", entry)
+	}
+}
+
+func TestSlogWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewSlogWriter(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	last := func() map[string]any {
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("日志不是合法的 JSON: %s", buf.String())
+		}
+		buf.Reset()
+		return entry
+	}
+
+	w.Info("hello", Field("a", 1), Field("password", maskedPassword("secret")))
+	entry := last()
+	if entry["msg"] != "hello" || entry["level"] != "INFO" || entry["a"] != float64(1) {
+		t.Errorf("转发到 slog 的内容错误: %v", entry)
+	}
+	if entry["password"] != "******" {
+		t.Errorf("字段应被脱敏, 实际 %v", entry["password"])
+	}
+
+	w.Slow("slow query")
+	entry = last()
+	if entry["level"] != "WARN" || entry[slogLevelKey] != levelSlow {
+		t.Errorf("slow 日志映射错误: %v", entry)
+	}
+
+	w.Severe("boom")
+	entry = last()
+	if entry["level"] != "ERROR+4" || entry[slogLevelKey] != levelSevere {
+		t.Errorf("severe 日志映射错误: %v", entry)
+	}
+
+	w.Error(map[string]int{"code": 500})
+	if entry = last(); entry["msg"] != `{"code":500}` {
+		t.Errorf("非文本内容应编码为 JSON, 实际 %v", entry["msg"])
+	}
+
+	infoOnly := NewSlogWriter(slog.NewJSONHandler(&buf, nil))
+	infoOnly.Debug("hidden")
+	if buf.Len() > 0 {
+		t.Errorf("handler 未启用的级别不应输出: %s", buf.String())
+	}
+	if err := infoOnly.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,419 @@
+package logx
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YunFy26/mini-zero/core/lang"
+)
+
+const (
+	// 可上报的指标
+	statMetricRuntime  = "runtime"
+	statMetricCpu      = "cpu"
+	statMetricCgroup   = "cgroup"
+	statMetricCounters = "counters"
+
+	// /proc/self/stat 中 CPU 时间的单位，Linux 上 USER_HZ 固定为 100
+	clockTicksPerSecond = 100
+	// cgroup 中表示不限制的值
+	cgroupUnlimited = "max"
+)
+
+const (
+	statRuntime uint8 = 1 << iota
+	statCpu
+	statCgroup
+	statCounters
+
+	statAll = statRuntime | statCpu | statCgroup | statCounters
+)
+
+var (
+	// 当前生效的指标上报器，nil 表示不上报
+	activeStatReporter atomic.Pointer[statReporter]
+
+	// 已注册的自定义计数器（名称 -> *StatCounter）
+	registeredCounters sync.Map
+
+	// 测试时替换为临时文件
+	procStatFile = "/proc/self/stat"
+	cgroupRoot   = "/sys/fs/cgroup"
+)
+
+type (
+	// StatCounter is a custom counter reported to the stat log periodically.
+	// The reported value is the increment during the report interval, like the requests
+	// handled, so the QPS can be calculated from it.
+	StatCounter struct {
+		name  string
+		value atomic.Int64
+	}
+
+	// statReporter 定期将运行时指标输出到统计日志
+	statReporter struct {
+		interval time.Duration
+		metrics  uint8
+		done     chan lang.PlaceholderType
+		stopOnce sync.Once
+		// 上一次上报时的状态，只在后台协程中访问
+		lastCpu     cpuSample
+		lastNumGC   uint32
+		lastPauseNs uint64
+	}
+
+	// cpuSample 进程累计使用的 CPU 时间
+	cpuSample struct {
+		ticks uint64
+		at    time.Time
+	}
+
+	// cgroupStats cgroup 的资源限制和使用量，小于0表示不限制或未知
+	cgroupStats struct {
+		version     int
+		cpuLimit    float64
+		memoryLimit int64
+		memoryUsage int64
+	}
+)
+
+// NewStatCounter returns the StatCounter with the given name, the counter is created and
+// registered if not exists, so it's safe to call NewStatCounter with the same name in
+// different places.
+func NewStatCounter(name string) *StatCounter {
+	counter, _ := registeredCounters.LoadOrStore(name, &StatCounter{name: name})
+	return counter.(*StatCounter)
+}
+
+// Add adds delta to c.
+func (c *StatCounter) Add(delta int64) {
+	c.value.Add(delta)
+}
+
+// Inc increments c by 1.
+func (c *StatCounter) Inc() {
+	c.value.Add(1)
+}
+
+func newStatReporter(c statReportConf) (*statReporter, error) {
+	r := &statReporter{
+		interval: time.Duration(c.IntervalMillis) * time.Millisecond,
+		done:     make(chan lang.PlaceholderType),
+	}
+
+	if len(c.Metrics) == 0 {
+		r.metrics = statAll
+		return r, nil
+	}
+
+	for _, metric := range c.Metrics {
+		bit := statMetricBit(strings.ToLower(strings.TrimSpace(metric)))
+		if bit == 0 {
+			return nil, fmt.Errorf("unknown stat metric %q", metric)
+		}
+		r.metrics |= bit
+	}
+
+	return r, nil
+}
+
+// report 输出一个周期的指标，每类指标一条日志
+func (r *statReporter) report() {
+	if !shallLogStat() || atomic.LoadUint32(&logLevel) > InfoLevel {
+		return
+	}
+
+	w := getWriter()
+	if r.metrics&statRuntime != 0 {
+		w.Stat("runtime stats", r.runtimeFields()...)
+	}
+	if r.metrics&statCpu != 0 {
+		if fields, ok := r.cpuFields(); ok {
+			w.Stat("cpu stats", fields...)
+		}
+	}
+	if r.metrics&statCgroup != 0 {
+		if fields, ok := cgroupFields(); ok {
+			w.Stat("cgroup stats", fields...)
+		}
+	}
+	if r.metrics&statCounters != 0 {
+		if fields := counterFields(); len(fields) > 0 {
+			w.Stat("counter stats", fields...)
+		}
+	}
+}
+
+// cpuFields 返回上一周期进程的 CPU 使用量，单位为毫核，1000 表示占满一个核
+func (r *statReporter) cpuFields() ([]LogField, bool) {
+	sample, err := readCpuSample()
+	if err != nil {
+		return nil, false
+	}
+
+	last := r.lastCpu
+	r.lastCpu = sample
+	if last.at.IsZero() {
+		return nil, false
+	}
+
+	return []LogField{
+		Field("cpu_millicores", cpuMillicores(last, sample)),
+		Field("num_cpu", runtime.NumCPU()),
+		Field("gomaxprocs", runtime.GOMAXPROCS(0)),
+	}, true
+}
+
+// runtimeFields 返回协程数、堆内存和上一周期的 GC 情况
+func (r *statReporter) runtimeFields() []LogField {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	numGC := stats.NumGC - r.lastNumGC
+	pause := time.Duration(stats.PauseTotalNs - r.lastPauseNs)
+	// PauseNs 是最近 256 次 GC 的环形缓冲区
+	var maxPause uint64
+	for i := uint32(0); i < min(numGC, uint32(len(stats.PauseNs))); i++ {
+		maxPause = max(maxPause, stats.PauseNs[(stats.NumGC-i+255)%256])
+	}
+	r.lastNumGC = stats.NumGC
+	r.lastPauseNs = stats.PauseTotalNs
+
+	return []LogField{
+		Field("goroutines", runtime.NumGoroutine()),
+		Field("heap_alloc", stats.HeapAlloc),
+		Field("heap_inuse", stats.HeapInuse),
+		Field("heap_objects", stats.HeapObjects),
+		Field("sys", stats.Sys),
+		Field("next_gc", stats.NextGC),
+		Field("gc_count", numGC),
+		Field("gc_pause", pause),
+		Field("gc_pause_max", time.Duration(maxPause)),
+	}
+}
+
+// start 启动后台协程，每个周期结束时调用 report
+func (r *statReporter) start() {
+	// 记录 CPU 的初始值，第一个周期才能计算使用量
+	r.lastCpu, _ = readCpuSample()
+
+	// 在当前协程中创建，后台协程不读取全局的时钟
+	ticker := clock.NewTicker(r.interval)
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.Chan():
+				r.report()
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+func (r *statReporter) stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// cgroupFields 返回 cgroup 的资源限制，不在 cgroup 中时返回 false
+func cgroupFields() ([]LogField, bool) {
+	stats, ok := readCgroupStats(cgroupRoot)
+	if !ok {
+		return nil, false
+	}
+
+	fields := []LogField{Field("cgroup_version", stats.version)}
+	if stats.cpuLimit >= 0 {
+		fields = append(fields, Field("cpu_limit", stats.cpuLimit))
+	}
+	if stats.memoryLimit >= 0 {
+		fields = append(fields, Field("memory_limit", stats.memoryLimit))
+	}
+	if stats.memoryUsage >= 0 {
+		fields = append(fields, Field("memory_usage", stats.memoryUsage))
+	}
+
+	return fields, true
+}
+
+// counterFields 返回各计数器上一周期的增量，按名称排序
+func counterFields() []LogField {
+	var fields []LogField
+	registeredCounters.Range(func(_, value any) bool {
+		counter := value.(*StatCounter)
+		fields = append(fields, Field(counter.name, counter.value.Swap(0)))
+		return true
+	})
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Key < fields[j].Key
+	})
+
+	return fields
+}
+
+func cpuMillicores(prev, cur cpuSample) int64 {
+	elapsed := cur.at.Sub(prev.at)
+	if elapsed <= 0 || cur.ticks < prev.ticks {
+		return 0
+	}
+
+	used := time.Duration(cur.ticks-prev.ticks) * time.Second / clockTicksPerSecond
+	return int64(used) * 1000 / int64(elapsed)
+}
+
+// readCgroupStats 读取 cgroup 的 CPU 和内存限制，优先使用 v2
+func readCgroupStats(root string) (cgroupStats, bool) {
+	stats := cgroupStats{cpuLimit: -1, memoryLimit: -1, memoryUsage: -1}
+
+	if _, err := os.Stat(filepath.Join(root, "cgroup.controllers")); err == nil {
+		stats.version = 2
+		// cpu.max 格式为 "$MAX $PERIOD"，$MAX 为 max 表示不限制
+		if fields := strings.Fields(readCgroupFile(root, "cpu.max")); len(fields) == 2 {
+			stats.cpuLimit = cpuQuota(fields[0], fields[1])
+		}
+		stats.memoryLimit = parseCgroupInt(readCgroupFile(root, "memory.max"))
+		stats.memoryUsage = parseCgroupInt(readCgroupFile(root, "memory.current"))
+		return stats, true
+	}
+
+	cpuDir := filepath.Join(root, "cpu")
+	memoryDir := filepath.Join(root, "memory")
+	quota := readCgroupFile(cpuDir, "cpu.cfs_quota_us")
+	limit := readCgroupFile(memoryDir, "memory.limit_in_bytes")
+	if len(quota) == 0 && len(limit) == 0 {
+		return stats, false
+	}
+
+	stats.version = 1
+	stats.cpuLimit = cpuQuota(quota, readCgroupFile(cpuDir, "cpu.cfs_period_us"))
+	stats.memoryLimit = parseCgroupInt(limit)
+	// v1 没有限制时为一个接近 int64 最大值的数
+	if stats.memoryLimit >= 1<<62 {
+		stats.memoryLimit = -1
+	}
+	stats.memoryUsage = parseCgroupInt(readCgroupFile(memoryDir, "memory.usage_in_bytes"))
+
+	return stats, true
+}
+
+// cpuQuota 返回可用的核数，v1 中 quota 为 -1 表示不限制
+func cpuQuota(quota, period string) float64 {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil || q <= 0 {
+		return -1
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return -1
+	}
+
+	return q / p
+}
+
+func parseCgroupInt(s string) int64 {
+	if len(s) == 0 || s == cgroupUnlimited {
+		return -1
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return -1
+	}
+
+	return n
+}
+
+// readCpuSample 从 /proc/self/stat 读取进程累计的用户态和内核态 CPU 时间
+func readCpuSample() (cpuSample, error) {
+	content, err := os.ReadFile(procStatFile)
+	if err != nil {
+		return cpuSample{}, err
+	}
+
+	ticks, err := parseProcStat(content)
+	if err != nil {
+		return cpuSample{}, err
+	}
+
+	return cpuSample{ticks: ticks, at: clock.Now()}, nil
+}
+
+func readCgroupFile(dir, name string) string {
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(content))
+}
+
+// parseProcStat 返回 utime + stime，进程名可能包含空格和括号，从最后一个 ')' 之后开始解析
+func parseProcStat(content []byte) (uint64, error) {
+	idx := bytes.LastIndexByte(content, ')')
+	if idx < 0 {
+		return 0, fmt.Errorf("invalid proc stat: %q", content)
+	}
+
+	// 从第 3 个字段 state 开始，utime 和 stime 是第 14、15 个字段
+	fields := strings.Fields(string(content[idx+1:]))
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("invalid proc stat: %q", content)
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return utime + stime, nil
+}
+
+// setupStatReport 按配置替换当前的指标上报器，关闭统计日志或周期为0时不上报
+func setupStatReport(c LogConf) error {
+	var r *statReporter
+	if c.Stat && c.StatReport.IntervalMillis > 0 {
+		var err error
+		if r, err = newStatReporter(c.StatReport); err != nil {
+			return err
+		}
+		r.start()
+	}
+
+	if old := activeStatReporter.Swap(r); old != nil {
+		old.stop()
+	}
+
+	return nil
+}
+
+func statMetricBit(metric string) uint8 {
+	switch metric {
+	case statMetricRuntime:
+		return statRuntime
+	case statMetricCpu:
+		return statCpu
+	case statMetricCgroup:
+		return statCgroup
+	case statMetricCounters:
+		return statCounters
+	default:
+		return 0
+	}
+}
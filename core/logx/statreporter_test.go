@@ -0,0 +1,209 @@
+package logx
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func writeStatFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		file := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func useStatFiles(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	oldProc, oldCgroup := procStatFile, cgroupRoot
+	procStatFile = filepath.Join(dir, "stat")
+	cgroupRoot = filepath.Join(dir, "cgroup")
+	t.Cleanup(func() {
+		procStatFile, cgroupRoot = oldProc, oldCgroup
+	})
+
+	return dir
+}
+
+func TestStatReporterReport(t *testing.T) {
+	dir := useStatFiles(t)
+	writeStatFiles(t, dir, map[string]string{
+		"stat":                      "42 (my (app)) S 1 42 42 0 -1 4194560 100 0 0 0 150 50 0 0 20 0 8 0",
+		"cgroup/cgroup.controllers": "cpu memory",
+		"cgroup/cpu.max":            "150000 100000\n",
+		"cgroup/memory.max":         "max\n",
+		"cgroup/memory.current":     "1048576\n",
+	})
+
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+	originalLevel := atomic.LoadUint32(&logLevel)
+	defer atomic.StoreUint32(&logLevel, originalLevel)
+	atomic.StoreUint32(&logLevel, InfoLevel)
+
+	counter := NewStatCounter("requests")
+	defer registeredCounters.Delete("requests")
+	if NewStatCounter("requests") != counter {
+		t.Fatal("同名的计数器应返回同一个")
+	}
+	counter.Add(2)
+	counter.Inc()
+
+	r, err := newStatReporter(statReportConf{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 上一周期使用了 100 个 tick，即 1 秒的 CPU 时间
+	r.lastCpu = cpuSample{ticks: 100, at: time.Now().Add(-2 * time.Second)}
+	r.report()
+
+	for _, text := range []string{
+		`"level":"stat"`, `"content":"runtime stats"`, `"goroutines":`, `"gc_pause_max":`,
+		`"content":"cpu stats"`, `"cpu_millicores":`, `"content":"cgroup stats"`, `"cgroup_version":2`,
+		`"cpu_limit":1.5`, `"memory_usage":1048576`, `"content":"counter stats"`, `"requests":3`,
+	} {
+		if !w.Contains(text) {
+			t.Errorf("缺少 %s: %s", text, w.String())
+		}
+	}
+	if w.Contains("memory_limit") {
+		t.Errorf("不限制内存时不应输出 memory_limit: %s", w.String())
+	}
+
+	// 计数器按周期上报增量
+	w.Reset()
+	r.report()
+	if !w.Contains(`"requests":0`) {
+		t.Errorf("计数器应在上报后清零: %s", w.String())
+	}
+
+	w.Reset()
+	atomic.StoreUint32(&disableStat, 1)
+	defer atomic.StoreUint32(&disableStat, 0)
+	r.report()
+	if len(w.String()) > 0 {
+		t.Errorf("关闭统计日志时不应上报: %s", w.String())
+	}
+}
+
+func TestStatReporterMetrics(t *testing.T) {
+	useStatFiles(t)
+	w := new(mockWriter)
+	old := writer.Swap(w)
+	defer writer.Store(old)
+
+	r, err := newStatReporter(statReportConf{Metrics: []string{" CPU ", "cgroup"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.metrics != statCpu|statCgroup {
+		t.Errorf("指标错误: %b", r.metrics)
+	}
+	// 读取不到 /proc 和 cgroup 时不输出
+	r.report()
+	if len(w.String()) > 0 {
+		t.Errorf("不应输出: %s", w.String())
+	}
+
+	if _, err = newStatReporter(statReportConf{Metrics: []string{"disk"}}); err == nil {
+		t.Error("未知的指标应返回错误")
+	}
+}
+
+func TestReadCgroupStatsV1(t *testing.T) {
+	dir := t.TempDir()
+	writeStatFiles(t, dir, map[string]string{
+		"cpu/cpu.cfs_quota_us":         "-1\n",
+		"cpu/cpu.cfs_period_us":        "100000\n",
+		"memory/memory.limit_in_bytes": "9223372036854771712\n",
+		"memory/memory.usage_in_bytes": "2048\n",
+	})
+
+	stats, ok := readCgroupStats(dir)
+	if !ok || stats.version != 1 || stats.cpuLimit != -1 || stats.memoryLimit != -1 || stats.memoryUsage != 2048 {
+		t.Errorf("v1 不限制时解析错误: %+v", stats)
+	}
+
+	writeStatFiles(t, dir, map[string]string{
+		"cpu/cpu.cfs_quota_us":         "50000\n",
+		"memory/memory.limit_in_bytes": "536870912\n",
+	})
+	stats, _ = readCgroupStats(dir)
+	if stats.cpuLimit != 0.5 || stats.memoryLimit != 536870912 {
+		t.Errorf("v1 限制解析错误: %+v", stats)
+	}
+
+	if _, ok = readCgroupStats(t.TempDir()); ok {
+		t.Error("不在 cgroup 中时应返回 false")
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	ticks, err := parseProcStat([]byte("1 (a) b) R 0 1 1 0 -1 0 0 0 0 0 7 3 0 0"))
+	if err != nil || ticks != 10 {
+		t.Errorf("期望 10, 实际 %d, %v", ticks, err)
+	}
+
+	for _, content := range []string{"1 app R", "1 (app) R 0 1"} {
+		if _, err = parseProcStat([]byte(content)); err == nil {
+			t.Errorf("%s 应返回错误", content)
+		}
+	}
+}
+
+func TestCpuMillicores(t *testing.T) {
+	now := time.Now()
+	prev := cpuSample{ticks: 100, at: now}
+	if n := cpuMillicores(prev, cpuSample{ticks: 150, at: now.Add(time.Second)}); n != 500 {
+		t.Errorf("期望 500, 实际 %d", n)
+	}
+	if n := cpuMillicores(prev, cpuSample{ticks: 50, at: now.Add(time.Second)}); n != 0 {
+		t.Errorf("计数回退时期望 0, 实际 %d", n)
+	}
+}
+
+func TestSetUpStatReport(t *testing.T) {
+	resetSetup(t)
+
+	if err := SetUp(LogConf{Mode: "console", Stat: true, StatReport: statReportConf{IntervalMillis: 10}}); err != nil {
+		t.Fatal(err)
+	}
+	if activeStatReporter.Load() == nil {
+		t.Fatal("应启动指标上报")
+	}
+
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+	if activeStatReporter.Load() != nil {
+		t.Error("Close 后应停止指标上报")
+	}
+}
+
+func TestSetUpStatReportDisabled(t *testing.T) {
+	resetSetup(t)
+
+	if err := SetUp(LogConf{Mode: "console", StatReport: statReportConf{IntervalMillis: 10}}); err != nil {
+		t.Fatal(err)
+	}
+	if activeStatReporter.Load() != nil {
+		t.Error("关闭统计日志时不应启动指标上报")
+	}
+
+	resetSetup(t)
+	err := SetUp(LogConf{Mode: "console", Stat: true, StatReport: statReportConf{IntervalMillis: 10, Metrics: []string{"disk"}}})
+	if err == nil || !strings.Contains(err.Error(), "disk") {
+		t.Errorf("应返回配置错误: %v", err)
+	}
+}
@@ -0,0 +1,425 @@
+package logx
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// syslog 严重级别，RFC 5424 6.2.1
+	syslogAlert    = 1
+	syslogCritical = 2
+	syslogError    = 3
+	syslogWarning  = 4
+	syslogInfo     = 6
+	syslogDebug    = 7
+
+	defaultSyslogFacility = 1 // user-level messages
+	syslogTimeFormat      = "2006-01-02T15:04:05.000000Z07:00"
+	syslogDialTimeout     = 5 * time.Second
+	syslogWriteTimeout    = 5 * time.Second
+	// 连接失败后重连的退避时间，每次失败加倍
+	syslogMinBackoff = time.Second
+	syslogMaxBackoff = time.Minute
+	// SD-ID 使用 IANA 为文档保留的企业号
+	syslogSDID         = "logx@32473"
+	maxSyslogSDNameLen = 32
+	maxSyslogAppLen    = 48
+	maxSyslogHostLen   = 255
+	syslogNilValue     = "-"
+)
+
+// ErrSyslogUnavailable is returned when no local syslog socket can be connected.
+var ErrSyslogUnavailable = errors.New("no available local syslog socket")
+
+// 本地 syslog 的 Unix socket 路径，与 log/syslog 一致
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+type (
+	// SyslogOption customizes the syslog writer.
+	SyslogOption func(opts *syslogOptions)
+
+	syslogOptions struct {
+		facility int
+		appName  string
+		hostname string
+	}
+
+	syslogWriter struct {
+		network string
+		addr    string
+		options syslogOptions
+		pid     string
+		conn    net.Conn
+		// 流式连接需要分帧：TCP 使用 octet counting（RFC 6587），Unix stream 使用换行
+		framing byte
+		closed  bool
+		lock    sync.Mutex
+		// 在锁外重连，connecting 保证只有一个调用方在重连
+		connecting bool
+		// 连续重连失败的次数，retryAt 之前不再重连
+		failures int
+		retryAt  time.Time
+	}
+)
+
+const (
+	syslogNoFraming byte = iota
+	syslogOctetCounting
+	syslogNewlineFraming
+)
+
+// NewSyslogWriter returns a Writer that sends RFC 5424 messages to a syslog server.
+// network can be udp, tcp, unix or unixgram, if both network and addr are empty,
+// the local syslog socket is used.
+// Alert, Severe, Error, Slow, Info and Debug are mapped to the alert, crit, err, warning,
+// info and debug severities, fields are written as structured data.
+func NewSyslogWriter(network, addr string, opts ...SyslogOption) (Writer, error) {
+	options := syslogOptions{
+		facility: defaultSyslogFacility,
+		appName:  filepath.Base(os.Args[0]),
+		hostname: hostname(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	options.appName = syslogHeaderValue(options.appName, maxSyslogAppLen)
+	options.hostname = syslogHeaderValue(options.hostname, maxSyslogHostLen)
+
+	w := &syslogWriter{
+		network: network,
+		addr:    addr,
+		options: options,
+		pid:     strconv.Itoa(os.Getpid()),
+	}
+	conn, framing, err := w.dial()
+	if err != nil {
+		return nil, err
+	}
+	w.conn = conn
+	w.framing = framing
+
+	return w, nil
+}
+
+// WithSyslogAppName customizes the APP-NAME of syslog messages, defaults to the program name.
+func WithSyslogAppName(name string) SyslogOption {
+	return func(opts *syslogOptions) {
+		opts.appName = name
+	}
+}
+
+// WithSyslogFacility customizes the facility of syslog messages, from 0 to 23,
+// such as 16 for local0. Defaults to 1, the user-level messages.
+func WithSyslogFacility(facility int) SyslogOption {
+	return func(opts *syslogOptions) {
+		if facility >= 0 && facility <= 23 {
+			opts.facility = facility
+		}
+	}
+}
+
+func (w *syslogWriter) Alert(v any) {
+	w.write(syslogAlert, levelAlert, v, nil)
+}
+
+func (w *syslogWriter) Close() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.closed = true
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func (w *syslogWriter) Debug(v any, fields ...LogField) {
+	w.write(syslogDebug, levelDebug, v, fields)
+}
+
+func (w *syslogWriter) Error(v any, fields ...LogField) {
+	w.write(syslogError, levelError, v, fields)
+}
+
+func (w *syslogWriter) Fatal(v any, fields ...LogField) {
+	w.write(syslogAlert, levelFatal, v, fields)
+}
+
+func (w *syslogWriter) Info(v any, fields ...LogField) {
+	w.write(syslogInfo, levelInfo, v, fields)
+}
+
+func (w *syslogWriter) Severe(v any) {
+	w.write(syslogCritical, levelSevere, v, nil)
+}
+
+func (w *syslogWriter) Slow(v any, fields ...LogField) {
+	w.write(syslogWarning, levelSlow, v, fields)
+}
+
+func (w *syslogWriter) Stack(v any) {
+	w.write(syslogError, levelError, v, nil)
+}
+
+func (w *syslogWriter) Stat(v any, fields ...LogField) {
+	w.write(syslogInfo, levelStat, v, fields)
+}
+
+// appendMessage 按 RFC 5424 格式追加一条消息：
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID name="value" ...] MSG
+// MSGID 使用 logx 的级别，便于区分映射到同一严重级别的 slow、stat 等日志
+func (w *syslogWriter) appendMessage(b []byte, severity int, level string, val any, fields []LogField) []byte {
+	keys := loadFieldKeys()
+	fields = mergeGloablFields(fields)
+	val, truncated := processContent(val)
+	if truncated {
+		fields = append(fields, Field(keys.truncated, true))
+	}
+
+	b = append(b, '<')
+	b = strconv.AppendInt(b, int64(w.options.facility*8+severity), 10)
+	b = append(b, ">1 "...)
+	b = clock.Now().AppendFormat(b, syslogTimeFormat)
+	b = append(b, ' ')
+	b = append(b, w.options.hostname...)
+	b = append(b, ' ')
+	b = append(b, w.options.appName...)
+	b = append(b, ' ')
+	b = append(b, w.pid...)
+	b = append(b, ' ')
+	b = append(b, level...)
+	b = append(b, ' ')
+	b = appendSyslogStructuredData(b, keys, fields)
+	b = append(b, ' ')
+
+	return appendTextValue(b, val)
+}
+
+// backoff 返回连续失败后重连前等待的时间
+func (w *syslogWriter) backoff() time.Duration {
+	d := syslogMaxBackoff
+	if w.failures < 32 {
+		if next := syslogMinBackoff << (w.failures - 1); next > 0 && next < d {
+			d = next
+		}
+	}
+
+	return d
+}
+
+// dial 连接 syslog 服务，返回连接和对应的分帧方式
+func (w *syslogWriter) dial() (net.Conn, byte, error) {
+	if len(w.network) > 0 || len(w.addr) > 0 {
+		conn, err := net.DialTimeout(w.network, w.addr, syslogDialTimeout)
+		if err != nil {
+			return nil, syslogNoFraming, err
+		}
+
+		switch w.network {
+		case "tcp", "tcp4", "tcp6":
+			return conn, syslogOctetCounting, nil
+		case "unix":
+			return conn, syslogNewlineFraming, nil
+		default:
+			return conn, syslogNoFraming, nil
+		}
+	}
+
+	// 本地 syslog 优先使用数据报
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range syslogSockets {
+			conn, err := net.DialTimeout(network, path, syslogDialTimeout)
+			if err != nil {
+				continue
+			}
+
+			if network == "unix" {
+				return conn, syslogNewlineFraming, nil
+			}
+			return conn, syslogNoFraming, nil
+		}
+	}
+
+	return nil, syslogNoFraming, ErrSyslogUnavailable
+}
+
+// reconnect 在持有锁时调用，重连时释放锁，避免其它日志等待连接超时
+// 其它调用方正在重连或者还在退避时间内时返回 false，日志直接丢弃
+func (w *syslogWriter) reconnect() bool {
+	if w.connecting || clock.Now().Before(w.retryAt) {
+		return false
+	}
+
+	w.connecting = true
+	w.lock.Unlock()
+	conn, framing, err := w.dial()
+	w.lock.Lock()
+	w.connecting = false
+
+	if err != nil {
+		w.failures++
+		w.retryAt = clock.Now().Add(w.backoff())
+		log.Println("failed to connect syslog:", err)
+		return false
+	}
+	if w.closed {
+		conn.Close()
+		return false
+	}
+
+	w.conn = conn
+	w.framing = framing
+	w.failures = 0
+	return true
+}
+
+// send 按连接类型分帧后发送一条消息，返回写入的字节数
+func (w *syslogWriter) send(msg []byte) (int64, error) {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(syslogWriteTimeout)); err != nil {
+		return 0, err
+	}
+
+	switch w.framing {
+	case syslogOctetCounting:
+		var header [24]byte
+		prefix := append(strconv.AppendInt(header[:0], int64(len(msg)), 10), ' ')
+		buffers := net.Buffers{prefix, msg}
+		return buffers.WriteTo(w.conn)
+	case syslogNewlineFraming:
+		// 以换行分帧时，消息中的换行转义，避免一条消息被拆成多条
+		if bytes.IndexByte(msg, '\n') >= 0 {
+			msg = bytes.ReplaceAll(msg, []byte{'\n'}, []byte(`\n`))
+		}
+		buffers := net.Buffers{msg, []byte{'\n'}}
+		return buffers.WriteTo(w.conn)
+	default:
+		n, err := w.conn.Write(msg)
+		return int64(n), err
+	}
+}
+
+func (w *syslogWriter) write(severity int, level string, val any, fields []LogField) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	*buf = w.appendMessage(*buf, severity, level, val, fields)
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// 连接可能已断开，重连后重试一次
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.closed {
+			return
+		}
+		if w.conn == nil && !w.reconnect() {
+			return
+		}
+
+		n, err := w.send(*buf)
+		if err == nil {
+			return
+		}
+
+		w.conn.Close()
+		w.conn = nil
+		// 已经写入部分内容时不再重发，避免服务端收到重复的消息
+		if n > 0 {
+			log.Println("failed to write syslog:", err)
+			return
+		}
+		if attempt > 0 {
+			log.Println("failed to write syslog:", err)
+		}
+	}
+}
+
+// appendSyslogStructuredData 将字段写为一个 SD-ELEMENT，没有字段时写入 -
+func appendSyslogStructuredData(b []byte, keys *systemKeys, fields []LogField) []byte {
+	start := len(b)
+	b = append(b, '[')
+	b = append(b, syslogSDID...)
+	empty := true
+	for _, field := range dedupFields(fields) {
+		name := syslogSDName(fieldKey(keys, field.Key))
+		if len(name) == 0 {
+			continue
+		}
+
+		empty = false
+		b = append(b, ' ')
+		b = append(b, name...)
+		b = append(b, `="`...)
+		b = appendSyslogParamValue(b, maskField(field.Key, field.Value))
+		b = append(b, '"')
+	}
+
+	if empty {
+		return append(b[:start], syslogNilValue...)
+	}
+
+	return append(b, ']')
+}
+
+// appendSyslogParamValue 追加 PARAM-VALUE，" \ ] 需要转义
+func appendSyslogParamValue(b []byte, v any) []byte {
+	start := len(b)
+	b = appendTextValue(b, v)
+	for i := start; i < len(b); i++ {
+		switch b[i] {
+		case '"', '\\', ']':
+			// 极少出现，直接插入转义符
+			b = append(b, 0)
+			copy(b[i+1:], b[i:])
+			b[i] = '\\'
+			i++
+		}
+	}
+
+	return b
+}
+
+// syslogSDName 返回合法的 PARAM-NAME：可打印 ASCII，不含 = 空格 ] "，最长 32 字节
+func syslogSDName(name string) string {
+	var builder strings.Builder
+	for i := 0; i < len(name) && builder.Len() < maxSyslogSDNameLen; i++ {
+		c := name[i]
+		if c <= ' ' || c >= 0x7f || c == '=' || c == ']' || c == '"' {
+			builder.WriteByte('_')
+		} else {
+			builder.WriteByte(c)
+		}
+	}
+
+	return builder.String()
+}
+
+// syslogHeaderValue 返回合法的头部字段：可打印 ASCII 且不含空格，为空时使用 -
+func syslogHeaderValue(val string, maxLen int) string {
+	if len(val) > maxLen {
+		val = val[:maxLen]
+	}
+
+	val = strings.Map(func(r rune) rune {
+		if r <= ' ' || r >= 0x7f {
+			return '_'
+		}
+		return r
+	}, val)
+	if len(val) == 0 {
+		return syslogNilValue
+	}
+
+	return val
+}
@@ -0,0 +1,311 @@
+package logx
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var syslogPattern = regexp.MustCompile(`^<(\d+)>1 (\S+) (\S+) (\S+) (\d+) (\S+) (-|\[.*\]) (.*)$`)
+
+func parseSyslog(t *testing.T, msg string) []string {
+	t.Helper()
+	matches := syslogPattern.FindStringSubmatch(msg)
+	if matches == nil {
+		t.Fatalf("不是合法的 RFC 5424 消息: %q", msg)
+	}
+	return matches[1:]
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n])
+}
+
+func TestSyslogWriterUDP(t *testing.T) {
+	resetGlobalFields(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String(),
+		WithSyslogAppName("my app"), WithSyslogFacility(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	tests := []struct {
+		name     string
+		log      func()
+		severity int
+		msgId    string
+	}{
+		{"alert", func() { w.Alert("foo") }, syslogAlert, levelAlert},
+		{"severe", func() { w.Severe("foo") }, syslogCritical, levelSevere},
+		{"error", func() { w.Error("foo") }, syslogError, levelError},
+		{"stack", func() { w.Stack("foo") }, syslogError, levelError},
+		{"slow", func() { w.Slow("foo") }, syslogWarning, levelSlow},
+		{"info", func() { w.Info("foo") }, syslogInfo, levelInfo},
+		{"stat", func() { w.Stat("foo") }, syslogInfo, levelStat},
+		{"debug", func() { w.Debug("foo") }, syslogDebug, levelDebug},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.log()
+			parts := parseSyslog(t, readPacket(t, conn))
+			if parts[0] != strconv.Itoa(16*8+test.severity) {
+				t.Errorf("PRI 期望 %d, 实际 %s", 16*8+test.severity, parts[0])
+			}
+			if _, err := time.Parse(time.RFC3339Nano, parts[1]); err != nil {
+				t.Errorf("时间格式错误: %s", parts[1])
+			}
+			if parts[3] != "my_app" || parts[4] != strconv.Itoa(os.Getpid()) || parts[5] != test.msgId {
+				t.Errorf("头部错误: %v", parts)
+			}
+			if parts[6] != "-" || parts[7] != "foo" {
+				t.Errorf("没有字段时结构化数据应为 -, 实际 %v", parts)
+			}
+		})
+	}
+}
+
+func TestSyslogWriterStructuredData(t *testing.T) {
+	resetGlobalFields(t)
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := NewSyslogWriter("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	w.Info(map[string]int{"a": 1}, Field("user id", `a"b]c\d`), Field("count", 3),
+		Field(defaultLevelKey, "x"), Field("count", 4))
+	parts := parseSyslog(t, readPacket(t, conn))
+	want := `[logx@32473 user_id="a\"b\]c\\d" fields.level="x" count="4"]`
+	if parts[6] != want {
+		t.Errorf("结构化数据期望 %s, 实际 %s", want, parts[6])
+	}
+	if parts[7] != `{"a":1}` {
+		t.Errorf("非字符串内容应使用 JSON 编码, 实际 %s", parts[7])
+	}
+}
+
+func TestSyslogWriterTCP(t *testing.T) {
+	resetGlobalFields(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	w, err := NewSyslogWriter("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w.Error("line1\nline2", Field("foo", "bar"))
+	w.Info("second")
+
+	// RFC 6587 octet counting: MSG-LEN SP SYSLOG-MSG
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(conn)
+	for _, content := range []string{"line1\nline2", "second"} {
+		size, err := reader.ReadString(' ')
+		if err != nil {
+			t.Fatal(err)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			t.Fatalf("长度前缀错误: %q", size)
+		}
+		msg := make([]byte, n)
+		if _, err = io.ReadFull(reader, msg); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(string(msg), " "+content) {
+			t.Errorf("消息内容错误: %q", msg)
+		}
+	}
+}
+
+func TestSyslogWriterUnixStream(t *testing.T) {
+	resetGlobalFields(t)
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix 不可用:", err)
+	}
+	defer ln.Close()
+
+	w, err := NewSyslogWriter("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w.Error("line1\nline2")
+	w.Info("second")
+
+	// 以换行分帧，消息中的换行被转义
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	reader := bufio.NewReader(conn)
+	for _, content := range []string{`line1\nline2`, "second"} {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parts := parseSyslog(t, strings.TrimSuffix(line, "\n")); parts[7] != content {
+			t.Errorf("消息内容错误: %q", line)
+		}
+	}
+}
+
+func TestSyslogWriterReconnectBackoff(t *testing.T) {
+	resetGlobalFields(t)
+	fake := useFakeClock(t, time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC))
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix 不可用:", err)
+	}
+
+	w, err := NewSyslogWriter("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	// 模拟连接断开且服务不可用，重连失败后进入退避
+	sw := w.(*syslogWriter)
+	sw.lock.Lock()
+	sw.conn.Close()
+	sw.conn = nil
+	sw.lock.Unlock()
+	ln.Close()
+	w.Info("lost")
+
+	ln, err = net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		if conn, err := ln.Accept(); err == nil {
+			accepted <- conn
+		}
+	}()
+
+	// 退避时间内不重连，日志直接丢弃
+	w.Info("dropped")
+	select {
+	case conn := <-accepted:
+		conn.Close()
+		t.Fatal("退避时间内不应重连")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	fake.Advance(syslogMinBackoff)
+	w.Info("hello")
+	var conn net.Conn
+	select {
+	case conn = <-accepted:
+	case <-time.After(time.Second):
+		t.Fatal("退避结束后应重连")
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parts := parseSyslog(t, strings.TrimSuffix(line, "\n")); parts[7] != "hello" {
+		t.Errorf("消息内容错误: %q", line)
+	}
+}
+
+func TestSyslogWriterUnixgram(t *testing.T) {
+	resetGlobalFields(t)
+	path := filepath.Join(t.TempDir(), "syslog.sock")
+	conn, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Skip("unixgram 不可用:", err)
+	}
+	defer conn.Close()
+
+	// 替换本地 syslog 路径，验证默认连接方式
+	old := syslogSockets
+	syslogSockets = []string{filepath.Join(t.TempDir(), "missing.sock"), path}
+	defer func() {
+		syslogSockets = old
+	}()
+
+	w, err := NewSyslogWriter("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.Info("hello", Field("foo", "bar"))
+	parts := parseSyslog(t, readPacket(t, conn))
+	if parts[6] != `[logx@32473 foo="bar"]` || parts[7] != "hello" {
+		t.Errorf("消息错误: %v", parts)
+	}
+
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭后不再发送
+	w.Info("closed")
+	conn.SetReadDeadline(time.Now().Add(50 * time.Millisecond))
+	if _, _, err = conn.ReadFrom(make([]byte, 1024)); err == nil {
+		t.Error("关闭后不应再发送")
+	}
+}
+
+func TestSyslogWriterUnavailable(t *testing.T) {
+	old := syslogSockets
+	syslogSockets = []string{filepath.Join(t.TempDir(), "missing.sock")}
+	defer func() {
+		syslogSockets = old
+	}()
+
+	if _, err := NewSyslogWriter("", ""); err != ErrSyslogUnavailable {
+		t.Errorf("期望 ErrSyslogUnavailable, 实际 %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package logx
+
+import "context"
+
+type (
+	traceIdKey struct{}
+	spanIdKey  struct{}
+)
+
+// ContextWithTrace returns a new context carrying the given trace id and span id,
+// which are written into the log entries as the trace and span keys by the context-aware Logger.
+func ContextWithTrace(ctx context.Context, traceId, spanId string) context.Context {
+	if len(traceId) > 0 {
+		ctx = context.WithValue(ctx, traceIdKey{}, traceId)
+	}
+	if len(spanId) > 0 {
+		ctx = context.WithValue(ctx, spanIdKey{}, spanId)
+	}
+	return ctx
+}
+
+func spanIdFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(spanIdKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
+
+func traceIdFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(traceIdKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
@@ -1,7 +1,55 @@
 package logx
 
-import "time"
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
 
 func getTimestamp() string {
-	return time.Now().Format(timeFormat)
+	return clock.Now().Format(timeFormat)
+}
+
+// appendTimestamp 将当前时间以 JSON 字符串追加到 b，避免分配中间字符串
+func appendTimestamp(b []byte) []byte {
+	start := len(b)
+	b = append(b, '"')
+	b = clock.Now().AppendFormat(b, timeFormat)
+	for _, c := range b[start+1:] {
+		// 自定义时间格式中包含需要转义的字符时，走完整的转义流程
+		if c < 0x20 || c == '"' || c == '\\' {
+			return appendJsonString(b[:start], string(b[start+1:]))
+		}
+	}
+
+	return append(b, '"')
+}
+
+// prettyCaller 只保留最后两级路径，/path/to/project/handler/user.go:45 -> handler/user.go:45
+func prettyCaller(file string, line int) string {
+	return shortPath(file) + ":" + strconv.Itoa(line)
+}
+
+// appendTextValue 追加值的文本形式，字符串原样追加，结构化的值使用 JSON 编码
+// 用于 syslog、journald 等不需要引号的输出
+func appendTextValue(b []byte, v any) []byte {
+	switch val := v.(type) {
+	case nil:
+		return append(b, nilAngleString...)
+	case string:
+		return append(b, val...)
+	case time.Duration:
+		return append(b, val.String()...)
+	case time.Time:
+		return val.AppendFormat(b, time.RFC3339Nano)
+	case error:
+		return append(b, encodeError(val)...)
+	case json.Marshaler:
+		return appendJsonValue(b, val)
+	case fmt.Stringer:
+		return append(b, encodeStringer(val)...)
+	default:
+		return appendJsonValue(b, val)
+	}
 }
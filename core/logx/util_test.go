@@ -3,6 +3,7 @@ package logx
 import (
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestGetTimestamp(t *testing.T) {
@@ -12,3 +13,15 @@ func TestGetTimestamp(t *testing.T) {
 	}
 	fmt.Printf("getTimestamp 时间格式：%s\n", time)
 }
+
+func TestGetTimestampWithClock(t *testing.T) {
+	now := time.Date(2024, time.March, 4, 5, 6, 7, 8e6, time.UTC)
+	useFakeClock(t, now)
+
+	if ts := getTimestamp(); ts != now.Format(timeFormat) {
+		t.Errorf("期望 %s, 实际 %s", now.Format(timeFormat), ts)
+	}
+	if ts := string(appendTimestamp(nil)); ts != `"`+now.Format(timeFormat)+`"` {
+		t.Errorf("appendTimestamp 错误: %s", ts)
+	}
+}
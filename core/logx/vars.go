@@ -2,8 +2,11 @@ package logx
 
 import (
 	"errors"
+	"strings"
+	"sync/atomic"
 
 	"github.com/YunFy26/mini-zero/core/syncx"
+	"github.com/YunFy26/mini-zero/core/timex"
 )
 
 // 日志级别常量
@@ -15,10 +18,12 @@ const (
 	disableLevel = 0xff        // 255: 禁用所有日志
 )
 
-// 日志编码格式：json 或 纯文本
+// 日志编码格式：json、纯文本、logfmt 或 开发环境使用的 pretty
 const (
-	jsonEncodingType  = iota // 0: JSON 编码
-	plainEncodingType        // 1: 纯文本编码
+	jsonEncodingType   = iota // 0: JSON 编码
+	plainEncodingType         // 1: 纯文本编码
+	logfmtEncodingType        // 2: logfmt 编码
+	prettyEncodingType        // 3: 对齐、着色的开发环境编码
 )
 
 // 文件名和模式常量
@@ -31,13 +36,20 @@ const (
 	statFilename   = "stat.log"   // 统计日志
 
 	// 编码方式
-	plainEncoding    = "plain" // 纯文本编码
-	plainEncodingSep = '\t'    // 纯文本分隔符（制表符）
+	plainEncoding    = "plain"  // 纯文本编码
+	plainEncodingSep = '\t'     // 纯文本分隔符（制表符）
+	logfmtEncoding   = "logfmt" // logfmt 编码
+	prettyEncoding   = "pretty" // pretty 编码
 
 	// 日志轮转规则
 	// 按大小轮转
 	sizeRotationRule = "size"
 
+	// 日志文件落盘策略
+	syncInterval = "interval" // 定期落盘
+	syncEvery    = "every"    // 每 N 条日志落盘
+	syncSevere   = "severe"   // 严重错误日志每条落盘
+
 	// 写入模式
 	fileMode   = "file"   // 文件模式
 	volumeMode = "volume" // 卷模式（可能指日志卷）
@@ -62,7 +74,9 @@ const (
 	defaultCallerKey    = "caller"     // 调用者信息字段名
 	defaultContentKey   = "content"    // 日志内容字段名
 	defaultDurationKey  = "duration"   // 耗时字段名
+	defaultFunctionKey  = "func"       // 调用函数名字段名
 	defaultLevelKey     = "level"      // 日志级别字段名
+	defaultLoggerKey    = "logger"     // 命名 Logger 的名称字段名
 	defaultSpanKey      = "span"       // 跨度ID字段名
 	defaultTimestampKey = "@timestamp" // 时间戳字段名
 	defaultTraceKey     = "trace"      // 追踪ID字段名
@@ -76,17 +90,44 @@ var (
 	ErrLogServiceNameNotSet = errors.New("log service name must be set")
 	// 是否在致命错误时退出
 	ExitOnFatal = syncx.ForAtomicBool(true)
-	// 标记日志内容是否被截断（日志内容太长时，就会被截断）
-	truncatedField = Field(truncatedKey, true)
+
+	// 日志时间戳、轮转、限流和采样使用的时钟，测试中替换为 timex.FakeClock
+	// 轮转规则和限流在创建时取当前的时钟
+	clock = timex.RealClock()
+
+	// plain 编码内容中的控制字符转义
+	plainContentEscaper = strings.NewReplacer("\t", `\t`, "\r", `\r`, "\n", `\n`)
 )
 
 var (
-	callerKey    = defaultCallerKey
-	contentKey   = defaultContentKey
-	durationKey  = defaultDurationKey
-	levelKey     = defaultLevelKey
-	spanKey      = defaultSpanKey
-	timestampKey = defaultTimestampKey
-	traceKey     = defaultTraceKey
-	truncatedKey = defaultTruncatedKey
+	// 当前生效的系统字段键名（*systemKeys），SetUp 时整体替换
+	// 写日志时一次性读取，保证并发写入的同一条日志使用一致的键名
+	fieldKeys atomic.Value
+
+	defaultSystemKeys = &systemKeys{
+		caller:    defaultCallerKey,
+		content:   defaultContentKey,
+		duration:  defaultDurationKey,
+		function:  defaultFunctionKey,
+		level:     defaultLevelKey,
+		logger:    defaultLoggerKey,
+		span:      defaultSpanKey,
+		timestamp: defaultTimestampKey,
+		trace:     defaultTraceKey,
+		truncated: defaultTruncatedKey,
+	}
 )
+
+// systemKeys 系统字段键名，对应 LogConf.FieldKeys
+type systemKeys struct {
+	caller    string
+	content   string
+	duration  string
+	function  string
+	level     string
+	logger    string
+	span      string
+	timestamp string
+	trace     string
+	truncated string
+}
@@ -7,7 +7,10 @@ import (
 	"io"
 	"log"
 	"path"
+	"reflect"
 	"runtime/debug"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -121,6 +124,12 @@ func (c comboWriter) Error(v any, fields ...LogField) {
 	}
 }
 
+func (c comboWriter) Fatal(v any, fields ...LogField) {
+	for _, w := range c.writers {
+		writeFatal(w, v, fields)
+	}
+}
+
 func (c comboWriter) Info(v any, fields ...LogField) {
 	for _, w := range c.writers {
 		w.Info(v, fields...)
@@ -198,6 +207,9 @@ func newFileWriter(c LogConf) (Writer, error) {
 	}
 	// 日志轮转规则
 	opts = append(opts, WithRotation(c.Rotation))
+	// 日志落盘策略
+	opts = append(opts, WithFileSync(c.FileSync.Policy,
+		time.Duration(c.FileSync.IntervalMillis)*time.Millisecond, c.FileSync.Every))
 
 	accessFile := path.Join(c.Path, accessFilename)
 	errorFile := path.Join(c.Path, errorFilename)
@@ -207,23 +219,23 @@ func newFileWriter(c LogConf) (Writer, error) {
 
 	handleOptions(opts)
 
-	if infoLog, err = createOutput(accessFile); err != nil {
+	if infoLog, err = createOutput(accessFile, fileSyncOptions(false)...); err != nil {
 		return nil, err
 	}
 
-	if errorLog, err = createOutput(errorFile); err != nil {
+	if errorLog, err = createOutput(errorFile, fileSyncOptions(false)...); err != nil {
 		return nil, err
 	}
 
-	if severeLog, err = createOutput(severeFile); err != nil {
+	if severeLog, err = createOutput(severeFile, fileSyncOptions(true)...); err != nil {
 		return nil, err
 	}
 
-	if slowLog, err = createOutput(slowFile); err != nil {
+	if slowLog, err = createOutput(slowFile, fileSyncOptions(false)...); err != nil {
 		return nil, err
 	}
 
-	if statLog, err = createOutput(statFile); err != nil {
+	if statLog, err = createOutput(statFile, fileSyncOptions(false)...); err != nil {
 		return nil, err
 	}
 
@@ -245,72 +257,152 @@ func (w *concreteWriter) Alert(v any) {
 }
 
 func (w *concreteWriter) Close() error {
-	return nil
+	var be errorx.BatchError
+	// 多个级别可能共用同一个底层输出（如 NewWriter / newConsoleWriter），只关闭一次
+	closed := make([]io.WriteCloser, 0, 5)
+	for _, wc := range []io.WriteCloser{w.infoLog, w.errorLog, w.severeLog, w.slowLog, w.statLog} {
+		if wc == nil || containsWriteCloser(closed, wc) {
+			continue
+		}
+		closed = append(closed, wc)
+		be.Add(wc.Close())
+	}
+	return be.Err()
 }
 
 func (w *concreteWriter) Debug(v any, fields ...LogField) {
-
+	output(w.infoLog, levelDebug, v, fields...)
 }
 
 func (w *concreteWriter) Error(v any, fields ...LogField) {
+	output(w.errorLog, levelError, v, fields...)
+}
 
+func (w *concreteWriter) Fatal(v any, fields ...LogField) {
+	output(w.severeLog, levelFatal, v, fields...)
 }
 
 func (w *concreteWriter) Info(v any, fields ...LogField) {
-
+	output(w.infoLog, levelInfo, v, fields...)
 }
 
 func (w *concreteWriter) Severe(v any) {
-
+	output(w.severeLog, levelSevere, v)
 }
 
 func (w *concreteWriter) Slow(v any, fields ...LogField) {
-
+	output(w.slowLog, levelSlow, v, fields...)
 }
 
 func (w *concreteWriter) Stack(v any) {
-
+	output(w.stackLog, levelError, v)
 }
 
 func (w *concreteWriter) Stat(v any, fields ...LogField) {
-
+	output(w.statLog, levelStat, v, fields...)
 }
 
-func output(writer io.Writer, level string, val any, fields ...LogField) {
-	switch v := val.(type) {
-	case string:
-		// 检查是否需要截断
-		maxLen := atomic.LoadUint32(&maxContentLength)
-		if maxLen > 0 && len(v) > int(maxLen) {
-			val = v[:maxLen]
-			fields = append(fields, truncatedField)
+// encodeEntry 按级别选择输出并编码日志，返回的函数写出编码结果，用于 AsyncWriter 入队时编码
+func (w *concreteWriter) encodeEntry(level string, val any, fields []LogField) func() {
+	var target io.Writer
+	switch level {
+	case levelDebug, levelInfo:
+		target = w.infoLog
+	case levelFatal, levelSevere:
+		target = w.severeLog
+	case levelSlow:
+		target = w.slowLog
+	case levelStat:
+		target = w.statLog
+	case asyncStack:
+		target = w.stackLog
+		level = levelError
+	default:
+		target = w.errorLog
+	}
+
+	var buf bytes.Buffer
+	output(&buf, level, val, fields...)
+	return func() {
+		if target == nil {
+			log.Print(buf.String())
+			return
 		}
+		if _, err := target.Write(buf.Bytes()); err != nil {
+			log.Println("failed to write log:", err)
+		}
+	}
+}
 
-	case Sensitive:
-		// content 脱敏
-		val = v.MaskSensitive()
+func containsWriteCloser(writers []io.WriteCloser, target io.WriteCloser) bool {
+	for _, w := range writers {
+		if sameWriteCloser(w, target) {
+			return true
+		}
 	}
-	// 创建日志条目 +3: level, timestamp and content
-	entry := make(logEntry, len(fields)+3)
-	for _, field := range fields {
-		// field value 脱敏
-		mval := maskSensitive(field.Value)
-		// 对field value进行编码
-		entry[field.Key] = processFieldValue(mval)
+	return false
+}
+
+// sameWriteCloser 判断是否为同一个输出，动态类型不可比较时（如包含切片的结构体）直接用 == 比较会 panic
+func sameWriteCloser(a, b io.WriteCloser) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	return va.Type() == vb.Type() && va.Comparable() && vb.Comparable() && a == b
+}
+
+func output(writer io.Writer, level string, val any, fields ...LogField) {
+	keys := loadFieldKeys()
+	fields = mergeGloablFields(fields)
+
+	val, truncated := processContent(val)
+	if truncated {
+		fields = append(fields, Field(keys.truncated, true))
 	}
 
 	// 根据日志格式输出
 	switch atomic.LoadUint32(&encoding) {
 	case plainEncodingType:
 		// 处理key-value结构
-		plainFields := buildPlainFields(entry)
+		plainFields := buildPlainFields(keys, fields)
 		writePlainAny(writer, level, val, plainFields...)
+	case logfmtEncodingType:
+		writeLogfmt(writer, keys, level, val, fields)
+	case prettyEncodingType:
+		writePretty(writer, keys, level, val, fields)
 	default:
-		entry[timestampKey] = getTimestamp()
-		entry[levelKey] = level
-		entry[contentKey] = val
-		writeJson(writer, entry)
+		writeJsonEntry(writer, keys, level, val, fields)
+	}
+}
+
+// fieldKey 与 output 写入的系统字段同名的字段（可能直接通过 Writer 传入）加上前缀输出
+func fieldKey(keys *systemKeys, key string) string {
+	if key == keys.timestamp || key == keys.level || key == keys.content {
+		return reservedFieldPrefix + key
+	}
+
+	return key
+}
+
+// processContent 对内容脱敏并截断过长的字符串内容，返回处理后的内容和是否被截断
+// 先脱敏再截断，避免截断后的敏感内容无法被规则匹配
+func processContent(val any) (any, bool) {
+	v, ok := val.(string)
+	if !ok {
+		return maskField("", val), false
+	}
+
+	// 字符串单独处理，未修改时不重新装箱，避免分配
+	if masked := activeMasker.Load().maskString(v); masked != v {
+		v = masked
+		val = masked
 	}
+
+	// 检查是否需要截断
+	maxLen := atomic.LoadUint32(&maxContentLength)
+	if maxLen > 0 && len(v) > int(maxLen) {
+		return v[:maxLen], true
+	}
+
+	return val, false
 }
 
 // 处理字段值，按照不同类型进行编码
@@ -354,20 +446,46 @@ func processFieldValue(value any) any {
 
 }
 
-// 把key-value结构展开，构建为字符串切片
-func buildPlainFields(fields logEntry) []string {
+// buildPlainFields 按字段顺序构建 key=value 切片：全局字段、context 字段、调用处字段，最后是系统字段
+// 同名字段只保留最后一个，位置取最后一次出现的位置
+func buildPlainFields(keys *systemKeys, fields []LogField) []string {
 	items := make([]string, 0, len(fields))
-	for k, v := range fields {
+	for _, field := range dedupFields(fields) {
+		key := fieldKey(keys, field.Key)
+
 		// 格式化输出 %v 和 %+v 的区别
 		// %v:
 		// {Bob 30 {New York NY}}
 		// %+v:
 		// {Name:Bob Age:30 Address:{City:New York State:NY}}
-		items = append(items, fmt.Sprintf("%s=%+v", k, v))
+		value := fmt.Sprintf("%+v", processFieldValue(maskField(field.Key, field.Value)))
+		items = append(items, quotePlain(key)+"="+quotePlain(value))
 	}
+
 	return items
 }
 
+// quotePlain 包含分隔符、换行、等号或引号的内容加引号并转义，保证一行日志可以被正确切分
+func quotePlain(s string) string {
+	if len(s) == 0 {
+		return `""`
+	}
+	if strings.ContainsAny(s, "\t\n\r=\"") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
+
+// escapePlainContent 将内容中的制表符、回车和换行转义为 \t、\r 和 \n
+func escapePlainContent(s string) string {
+	if !strings.ContainsAny(s, "\t\n\r") {
+		return s
+	}
+
+	return plainContentEscaper.Replace(s)
+}
+
 // 写入纯文本格式的日志
 func writePlainAny(writer io.Writer, level string, val any, fields ...string) {
 	level = wrapLevelWithColor(level)
@@ -384,29 +502,35 @@ func writePlainAny(writer io.Writer, level string, val any, fields ...string) {
 }
 
 func wrapLevelWithColor(level string) string {
-	var colour color.Color
+	colour := levelColor(level)
+	if colour == color.NoColor {
+		return level
+	}
+
+	return color.WithColorPadding(level, colour)
+}
+
+func levelColor(level string) color.Color {
 	switch level {
 	case levelAlert:
-		colour = color.FgRed
+		return color.FgRed
 	case levelError:
-		colour = color.FgRed
+		return color.FgRed
+	case levelSevere:
+		return color.FgRed
 	case levelFatal:
-		colour = color.FgRed
+		return color.FgRed
 	case levelInfo:
-		colour = color.FgGreen
+		return color.FgGreen
 	case levelSlow:
-		colour = color.FgYellow
+		return color.FgYellow
 	case levelDebug:
-		colour = color.FgYellow
+		return color.FgYellow
 	case levelStat:
-		colour = color.FgGreen
-	}
-
-	if colour == color.NoColor {
-		return level
+		return color.FgGreen
+	default:
+		return color.NoColor
 	}
-
-	return color.WithColorPadding(level, colour)
 }
 
 // 写入文本日志
@@ -416,7 +540,8 @@ func writePlainText(writer io.Writer, level string, msg string, fields ...string
 	buf.WriteByte(plainEncodingSep)
 	buf.WriteString(level)
 	buf.WriteByte(plainEncodingSep)
-	buf.WriteString(msg)
+	// 内容中的换行和制表符转义，包含堆栈的内容不会破坏一行一条日志，其他内容原样输出
+	buf.WriteString(escapePlainContent(msg))
 	for _, field := range fields {
 		buf.WriteByte(plainEncodingSep)
 		buf.WriteString(field)
@@ -438,11 +563,14 @@ func writePlainValue(writer io.Writer, level string, val any, fields ...string)
 	buf.WriteByte(plainEncodingSep)
 	buf.WriteString(level)
 	buf.WriteByte(plainEncodingSep)
-	// 将val编码为JSON格式并写入缓冲区
-	if err := json.NewEncoder(&buf).Encode(val); err != nil {
+	// 将val编码为JSON格式并写入缓冲区，去掉结尾的换行
+	content, err := marshalJson(val)
+	if err != nil {
 		log.Printf("err: %s\n\n%s", err.Error(), debug.Stack())
 		return
 	}
+	// JSON 中的控制字符已转义，不需要再处理
+	buf.Write(content)
 	for _, field := range fields {
 		buf.WriteByte(plainEncodingSep)
 		buf.WriteString(field)
@@ -458,18 +586,6 @@ func writePlainValue(writer io.Writer, level string, val any, fields ...string)
 	}
 }
 
-func writeJson(writer io.Writer, info any) {
-	if content, err := marshalJson(info); err != nil {
-		log.Printf("err: %s\n\n%s", err.Error(), debug.Stack())
-	} else if writer == nil {
-		log.Println(string(contentKey))
-	} else {
-		if _, err := writer.Write(append(content, '\n')); err != nil {
-			log.Println(err.Error())
-		}
-	}
-}
-
 // 将任意值编码为JSON格式的字节切片
 func marshalJson(v any) ([]byte, error) {
 	var buf bytes.Buffer
@@ -484,9 +600,16 @@ func marshalJson(v any) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
-// TODO: 实现全局字段合并
+// mergeGloablFields 将全局字段放在最前面，同名时被 context 字段和调用处字段覆盖
 func mergeGloablFields(fields []LogField) []LogField {
-	return nil
+	globals, ok := globalFields.Load().([]LogField)
+	if !ok || len(globals) == 0 {
+		return fields
+	}
+
+	merged := make([]LogField, 0, len(globals)+len(fields))
+	merged = append(merged, globals...)
+	return append(merged, fields...)
 }
 
 func (n nopWriter) Alert(_ any)                {}
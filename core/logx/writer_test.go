@@ -2,8 +2,11 @@ package logx
 
 import (
 	"bytes"
+	"errors"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestWritePlainText(t *testing.T) {
@@ -96,3 +99,251 @@ func TestWritePlainValue(t *testing.T) {
 		}
 	})
 }
+
+func TestWritePlainEscapesContent(t *testing.T) {
+	var buf bytes.Buffer
+	writePlainText(&buf, "ERROR", "failed\n\tat main.go:10", "foo=bar")
+	if expect := "\tERROR\tfailed\\n\\tat main.go:10\tfoo=bar\n"; !strings.HasSuffix(buf.String(), expect) {
+		t.Errorf("内容中的换行和制表符应转义: %q", buf.String())
+	}
+
+	// 普通内容原样输出，不加引号
+	buf.Reset()
+	writePlainText(&buf, "INFO", `key=value "done"`)
+	if expect := "\tINFO\tkey=value \"done\"\n"; !strings.HasSuffix(buf.String(), expect) {
+		t.Errorf("普通内容应原样输出: %q", buf.String())
+	}
+
+	buf.Reset()
+	writePlainValue(&buf, "INFO", map[string]int{"a": 1}, "foo=bar")
+	if strings.Count(buf.String(), "\n") != 1 || !strings.HasSuffix(buf.String(), "\t{\"a\":1}\tfoo=bar\n") {
+		t.Errorf("结构化的内容应编码为一行: %q", buf.String())
+	}
+}
+
+type closeCountWriter struct {
+	strings.Builder
+	closed int
+	err    error
+}
+
+func (w *closeCountWriter) Close() error {
+	w.closed++
+	return w.err
+}
+
+// sliceWriter 包含切片，动态类型不可比较
+type sliceWriter struct {
+	closed *int
+	lines  []string
+}
+
+func (w sliceWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (w sliceWriter) Close() error {
+	*w.closed++
+	return nil
+}
+
+func TestConcreteWriterLevels(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+
+	tests := []struct {
+		level string
+		log   func(v any)
+	}{
+		{levelAlert, func(v any) { w.Alert(v) }},
+		{levelDebug, func(v any) { w.Debug(v) }},
+		{levelError, func(v any) { w.Error(v) }},
+		{levelInfo, func(v any) { w.Info(v) }},
+		{levelSevere, func(v any) { w.Severe(v) }},
+		{levelSlow, func(v any) { w.Slow(v) }},
+		{levelStat, func(v any) { w.Stat(v) }},
+	}
+
+	for _, test := range tests {
+		t.Run(test.level, func(t *testing.T) {
+			buf.Reset()
+			test.log("hello")
+			if !strings.Contains(buf.String(), `"level":"`+test.level+`"`) {
+				t.Errorf("期望日志级别 %s，实际输出: %s", test.level, buf.String())
+			}
+			if !strings.Contains(buf.String(), "hello") {
+				t.Errorf("日志应包含消息内容，实际输出: %s", buf.String())
+			}
+		})
+	}
+
+	t.Run("stack", func(t *testing.T) {
+		buf.Reset()
+		w.Stack("stack trace")
+		if !strings.Contains(buf.String(), `"level":"error"`) {
+			t.Errorf("堆栈日志应为 error 级别，实际输出: %s", buf.String())
+		}
+	})
+}
+
+func TestConcreteWriterStreams(t *testing.T) {
+	info := new(closeCountWriter)
+	errLog := new(closeCountWriter)
+	severe := new(closeCountWriter)
+	slow := new(closeCountWriter)
+	stat := new(closeCountWriter)
+	w := &concreteWriter{
+		infoLog:   info,
+		errorLog:  errLog,
+		severeLog: severe,
+		slowLog:   slow,
+		statLog:   stat,
+		stackLog:  errLog,
+	}
+
+	w.Info("info")
+	w.Debug("debug")
+	w.Error("error")
+	w.Severe("severe")
+	w.Slow("slow")
+	w.Stat("stat")
+	w.Stack("stack")
+
+	if !strings.Contains(info.String(), "info") || !strings.Contains(info.String(), "debug") {
+		t.Errorf("access 日志应包含 info 和 debug: %s", info.String())
+	}
+	if !strings.Contains(errLog.String(), `"content":"error"`) || !strings.Contains(errLog.String(), "stack") {
+		t.Errorf("error 日志应包含 error 和 stack: %s", errLog.String())
+	}
+	if !strings.Contains(severe.String(), "severe") {
+		t.Errorf("severe 日志内容错误: %s", severe.String())
+	}
+	if !strings.Contains(slow.String(), "slow") {
+		t.Errorf("slow 日志内容错误: %s", slow.String())
+	}
+	if !strings.Contains(stat.String(), "stat") {
+		t.Errorf("stat 日志内容错误: %s", stat.String())
+	}
+}
+
+func TestConcreteWriterClose(t *testing.T) {
+	t.Run("共享输出只关闭一次", func(t *testing.T) {
+		shared := new(closeCountWriter)
+		w := &concreteWriter{
+			infoLog:   shared,
+			errorLog:  shared,
+			severeLog: shared,
+			slowLog:   shared,
+			statLog:   shared,
+			stackLog:  shared,
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close 不应返回错误: %v", err)
+		}
+		if shared.closed != 1 {
+			t.Errorf("期望关闭1次，实际关闭%d次", shared.closed)
+		}
+	})
+
+	t.Run("不可比较的输出", func(t *testing.T) {
+		var closed int
+		sw := sliceWriter{closed: &closed}
+		w := &concreteWriter{
+			infoLog:   sw,
+			errorLog:  sw,
+			severeLog: new(closeCountWriter),
+			slowLog:   sw,
+			statLog:   sw,
+			stackLog:  sw,
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close 不应返回错误: %v", err)
+		}
+		// 无法判断是否为同一个输出，每个都关闭
+		if closed != 4 {
+			t.Errorf("期望关闭4次，实际关闭%d次", closed)
+		}
+	})
+
+	t.Run("聚合关闭错误", func(t *testing.T) {
+		errA := errors.New("close a")
+		errB := errors.New("close b")
+		a := &closeCountWriter{err: errA}
+		b := &closeCountWriter{err: errB}
+		c := new(closeCountWriter)
+		w := &concreteWriter{
+			infoLog:   a,
+			errorLog:  b,
+			severeLog: c,
+			slowLog:   a,
+			statLog:   c,
+			stackLog:  b,
+		}
+		err := w.Close()
+		if !errors.Is(err, errA) || !errors.Is(err, errB) {
+			t.Errorf("期望同时包含两个关闭错误，实际: %v", err)
+		}
+		if a.closed != 1 || b.closed != 1 || c.closed != 1 {
+			t.Errorf("每个输出都应只关闭一次: a=%d b=%d c=%d", a.closed, b.closed, c.closed)
+		}
+	})
+}
+
+func TestBuildPlainFields(t *testing.T) {
+	fields := []LogField{
+		Field("global", "g"),
+		Field("key", "context"),
+		Field("z", 1),
+		Field("a", 2),
+		Field("key", "call"),
+		Field(defaultLevelKey, "fake"),
+		Field("tab", "a\tb"),
+		Field("newline", "a\nb"),
+		Field("equal", "a=b"),
+		Field("quote", `say "hi"`),
+		Field("empty", ""),
+		Field("duration", time.Second),
+	}
+
+	got := buildPlainFields(defaultSystemKeys, fields)
+	want := []string{
+		"global=g",
+		"z=1",
+		"a=2",
+		"key=call",
+		"fields.level=fake",
+		`tab="a\tb"`,
+		`newline="a\nb"`,
+		`equal="a=b"`,
+		`quote="say \"hi\""`,
+		`empty=""`,
+		"duration=1s",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("plain 字段顺序或转义错误:\n实际 %q\n期望 %q", got, want)
+	}
+}
+
+func TestPlainOutputStableOrder(t *testing.T) {
+	resetGlobalFields(t)
+	old := atomic.SwapUint32(&encoding, plainEncodingType)
+	defer atomic.StoreUint32(&encoding, old)
+
+	var first string
+	for i := 0; i < 20; i++ {
+		var buf bytes.Buffer
+		output(&buf, levelInfo, "msg", Field("c", 3), Field("b", 2), Field("a", 1))
+		fields := strings.SplitN(strings.TrimSpace(buf.String()), string(plainEncodingSep), 4)
+		if len(fields) != 4 {
+			t.Fatalf("plain 日志格式错误: %q", buf.String())
+		}
+		if fields[3] != "c=3\tb=2\ta=1" {
+			t.Fatalf("字段应保持调用顺序, 实际 %q", fields[3])
+		}
+		if i == 0 {
+			first = fields[3]
+		} else if fields[3] != first {
+			t.Fatalf("多次输出的字段顺序不一致: %q != %q", fields[3], first)
+		}
+	}
+}
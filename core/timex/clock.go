@@ -0,0 +1,83 @@
+package timex
+
+import "time"
+
+type (
+	// Clock provides the current time and the time based channels, so that the code
+	// depending on time can be tested with a FakeClock instead of sleeping.
+	Clock interface {
+		// After waits for d to elapse and then sends the current time on the returned channel.
+		After(d time.Duration) <-chan time.Time
+		// NewTicker returns a Ticker that sends the current time every d.
+		NewTicker(d time.Duration) Ticker
+		// NewTimer returns a Timer that sends the current time after d.
+		NewTimer(d time.Duration) Timer
+		// Now returns the current time.
+		Now() time.Time
+		// Since returns the time elapsed since t.
+		Since(t time.Time) time.Duration
+	}
+
+	// Ticker is the Clock version of time.Ticker.
+	Ticker interface {
+		// Chan returns the channel on which the ticks are delivered.
+		Chan() <-chan time.Time
+		// Reset stops the ticker and resets its period to d.
+		Reset(d time.Duration)
+		// Stop turns off the ticker.
+		Stop()
+	}
+
+	// Timer is the Clock version of time.Timer.
+	Timer interface {
+		// Chan returns the channel on which the time is delivered.
+		Chan() <-chan time.Time
+		// Reset changes the timer to expire after d, returns true if the timer had been active.
+		Reset(d time.Duration) bool
+		// Stop prevents the timer from firing, returns true if the timer had been active.
+		Stop() bool
+	}
+
+	realClock struct{}
+
+	realTicker struct {
+		*time.Ticker
+	}
+
+	realTimer struct {
+		*time.Timer
+	}
+)
+
+// RealClock returns the Clock backed by the time package.
+func RealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{Ticker: time.NewTicker(d)}
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{Timer: time.NewTimer(d)}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (t realTicker) Chan() <-chan time.Time {
+	return t.C
+}
+
+func (t realTimer) Chan() <-chan time.Time {
+	return t.C
+}
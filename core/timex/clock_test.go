@@ -0,0 +1,134 @@
+package timex
+
+import (
+	"testing"
+	"time"
+)
+
+var fakeStart = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func expectFired(t *testing.T, ch <-chan time.Time, expect time.Time) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		if !v.Equal(expect) {
+			t.Errorf("期望 %v, 实际 %v", expect, v)
+		}
+	default:
+		t.Errorf("期望在 %v 触发", expect)
+	}
+}
+
+func expectNotFired(t *testing.T, ch <-chan time.Time) {
+	t.Helper()
+	select {
+	case v := <-ch:
+		t.Errorf("不应触发, 实际在 %v 触发", v)
+	default:
+	}
+}
+
+func TestRealClock(t *testing.T) {
+	clock := RealClock()
+	start := clock.Now()
+
+	timer := clock.NewTimer(time.Millisecond)
+	<-timer.Chan()
+	if timer.Stop() {
+		t.Error("已触发的定时器 Stop 应返回 false")
+	}
+
+	ticker := clock.NewTicker(time.Millisecond)
+	<-ticker.Chan()
+	ticker.Stop()
+
+	<-clock.After(time.Millisecond)
+	if clock.Since(start) < 3*time.Millisecond {
+		t.Errorf("经过的时间错误: %v", clock.Since(start))
+	}
+}
+
+func TestFakeClockNow(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	clock.Advance(time.Hour)
+	if !clock.Now().Equal(fakeStart.Add(time.Hour)) || clock.Since(fakeStart) != time.Hour {
+		t.Errorf("时间错误: %v", clock.Now())
+	}
+
+	clock.Set(fakeStart)
+	if !clock.Now().Equal(fakeStart) {
+		t.Errorf("Set 后时间错误: %v", clock.Now())
+	}
+}
+
+func TestFakeClockTimer(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	timer := clock.NewTimer(time.Second)
+	after := clock.After(2 * time.Second)
+
+	clock.Advance(999 * time.Millisecond)
+	expectNotFired(t, timer.Chan())
+
+	clock.Advance(time.Second)
+	expectFired(t, timer.Chan(), fakeStart.Add(time.Second))
+	expectNotFired(t, after)
+	if timer.Stop() {
+		t.Error("已触发的定时器 Stop 应返回 false")
+	}
+
+	clock.Advance(time.Second)
+	expectFired(t, after, fakeStart.Add(2*time.Second))
+
+	if timer.Reset(time.Second) {
+		t.Error("已触发的定时器 Reset 应返回 false")
+	}
+	if !timer.Stop() {
+		t.Error("活动的定时器 Stop 应返回 true")
+	}
+	clock.Advance(time.Hour)
+	expectNotFired(t, timer.Chan())
+
+	timer.Reset(0)
+	expectFired(t, timer.Chan(), clock.Now())
+	expectFired(t, clock.After(0), clock.Now())
+}
+
+func TestFakeClockTicker(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	ticker := clock.NewTicker(time.Second)
+
+	clock.Advance(time.Second)
+	expectFired(t, ticker.Chan(), fakeStart.Add(time.Second))
+
+	// 错过的周期被丢弃
+	clock.Advance(3500 * time.Millisecond)
+	expectFired(t, ticker.Chan(), fakeStart.Add(2*time.Second))
+	expectNotFired(t, ticker.Chan())
+
+	clock.Advance(500 * time.Millisecond)
+	expectFired(t, ticker.Chan(), fakeStart.Add(5*time.Second))
+
+	ticker.Reset(time.Minute)
+	clock.Advance(time.Second)
+	expectNotFired(t, ticker.Chan())
+	clock.Advance(time.Minute)
+	expectFired(t, ticker.Chan(), fakeStart.Add(5*time.Second+time.Minute))
+
+	ticker.Stop()
+	clock.Advance(time.Hour)
+	expectNotFired(t, ticker.Chan())
+}
+
+func TestFakeClockBlockUntil(t *testing.T) {
+	clock := NewFakeClock(fakeStart)
+	done := make(chan time.Time)
+	go func() {
+		done <- <-clock.After(time.Minute)
+	}()
+
+	clock.BlockUntil(1)
+	clock.Advance(time.Minute)
+	if v := <-done; !v.Equal(fakeStart.Add(time.Minute)) {
+		t.Errorf("触发时间错误: %v", v)
+	}
+}
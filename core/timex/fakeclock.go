@@ -0,0 +1,216 @@
+package timex
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+type (
+	// FakeClock is a Clock that only moves when Advance or Set is called.
+	// The timers and tickers fire synchronously in Advance and Set once their deadlines
+	// are reached, the ticks are dropped if the receivers are not ready, like time.Ticker.
+	FakeClock struct {
+		lock    sync.Mutex
+		cond    *sync.Cond
+		now     time.Time
+		waiters []*fakeWaiter
+	}
+
+	// fakeWaiter 假时钟上的定时器，period 为 0 时为 Timer，否则为 Ticker
+	fakeWaiter struct {
+		clock    *FakeClock
+		ch       chan time.Time
+		deadline time.Time
+		period   time.Duration
+	}
+
+	fakeTicker struct {
+		*fakeWaiter
+	}
+
+	fakeTimer struct {
+		*fakeWaiter
+	}
+)
+
+// NewFakeClock returns a FakeClock starting at now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.lock)
+	return c
+}
+
+// Advance moves the clock forward by d, and fires the timers and tickers due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.setLocked(c.now.Add(d))
+}
+
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).Chan()
+}
+
+// BlockUntil blocks until there are at least n active timers and tickers, it's used to
+// wait for the goroutines under test to create their timers before calling Advance.
+func (c *FakeClock) BlockUntil(n int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for NewTicker")
+	}
+
+	return fakeTicker{fakeWaiter: c.addWaiter(d, d)}
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	return fakeTimer{fakeWaiter: c.addWaiter(d, 0)}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+// Set moves the clock to t, and fires the timers and tickers due.
+// Moving backward doesn't fire anything.
+func (c *FakeClock) Set(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.setLocked(t)
+}
+
+func (c *FakeClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+func (c *FakeClock) addWaiter(d, period time.Duration) *fakeWaiter {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	w := &fakeWaiter{
+		clock:    c,
+		ch:       make(chan time.Time, 1),
+		deadline: c.now.Add(d),
+		period:   period,
+	}
+	// 与 time.NewTimer 一致，d <= 0 时立即触发
+	if d <= 0 && period == 0 {
+		w.ch <- c.now
+		return w
+	}
+
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+	return w
+}
+
+// removeLocked 返回 w 是否处于活动状态
+func (c *FakeClock) removeLocked(w *fakeWaiter) bool {
+	for i, waiter := range c.waiters {
+		if waiter == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// setLocked 按到期时间的顺序触发定时器
+func (c *FakeClock) setLocked(t time.Time) {
+	if t.Before(c.now) {
+		c.now = t
+		return
+	}
+
+	c.now = t
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+
+	var active []*fakeWaiter
+	for _, w := range c.waiters {
+		if w.deadline.After(t) {
+			active = append(active, w)
+			continue
+		}
+
+		select {
+		case w.ch <- w.deadline:
+		default:
+		}
+
+		if w.period > 0 {
+			// 跳过错过的周期，与 time.Ticker 一致
+			for !w.deadline.After(t) {
+				w.deadline = w.deadline.Add(w.period)
+			}
+			active = append(active, w)
+		}
+	}
+	c.waiters = active
+}
+
+func (w *fakeWaiter) Chan() <-chan time.Time {
+	return w.ch
+}
+
+// reset 重新计时，返回是否处于活动状态
+func (w *fakeWaiter) reset(d, period time.Duration) bool {
+	c := w.clock
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	active := c.removeLocked(w)
+	w.deadline = c.now.Add(d)
+	w.period = period
+	if d <= 0 && period == 0 {
+		select {
+		case w.ch <- c.now:
+		default:
+		}
+		return active
+	}
+
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+
+	return active
+}
+
+func (w *fakeWaiter) stop() bool {
+	c := w.clock
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.removeLocked(w)
+}
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("non-positive interval for Ticker.Reset")
+	}
+
+	t.reset(d, d)
+}
+
+func (t fakeTicker) Stop() {
+	t.stop()
+}
+
+func (t fakeTimer) Reset(d time.Duration) bool {
+	return t.reset(d, 0)
+}
+
+func (t fakeTimer) Stop() bool {
+	return t.stop()
+}
@@ -0,0 +1,24 @@
+package timex
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReprOfDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{0, "0.0ms"},
+		{time.Millisecond, "1.0ms"},
+		{1500 * time.Microsecond, "1.5ms"},
+		{2 * time.Second, "2000.0ms"},
+	}
+
+	for _, test := range tests {
+		if got := ReprOfDuration(test.duration); got != test.want {
+			t.Errorf("ReprOfDuration(%v) = %s, want %s", test.duration, got, test.want)
+		}
+	}
+}